@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"aiweb3news/internal/config"
+)
+
+// runEmbed computes missing embeddings for stored items, e.g. after enabling
+// a provider or switching EMBEDDING_MODEL. A running server picks them up on
+// its next index refresh.
+func runEmbed(ctx context.Context, cfg config.Config, logger *log.Logger, args []string) error {
+	fs := flag.NewFlagSet("embed", flag.ContinueOnError)
+	batch := fs.Int("batch", 64, "items embedded per provider call")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *batch <= 0 {
+		return fmt.Errorf("invalid -batch %d", *batch)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	svc, store, err := newService(ctx, cfg, logger)
+	if err != nil {
+		return fmt.Errorf("init service: %w", err)
+	}
+	defer store.Close()
+
+	n, err := svc.BackfillEmbeddings(ctx, *batch)
+	logger.Printf("embed finished: %d items embedded", n)
+	return err
+}
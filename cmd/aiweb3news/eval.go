@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"aiweb3news/internal/analysis"
+	"aiweb3news/internal/config"
+	"aiweb3news/internal/eval"
+	"aiweb3news/internal/fewshot"
+	"aiweb3news/internal/storage"
+)
+
+// runEval grades the classifier against a labeled JSONL dataset and, with
+// -compare-model or -compare-prompt, grades a second configuration side by
+// side. -record captures the model responses so later runs can -replay them
+// offline and free of charge.
+func runEval(ctx context.Context, cfg config.Config, logger *log.Logger, args []string) error {
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	model := fs.String("model", cfg.OpenAIModel, "model of the baseline configuration")
+	prompt := fs.String("prompt", analysis.DefaultPromptVersion, "prompt version of the baseline configuration")
+	compareModel := fs.String("compare-model", "", "model of the candidate configuration (default: -model)")
+	comparePrompt := fs.String("compare-prompt", "", "prompt version of the candidate configuration (default: -prompt)")
+	record := fs.String("record", "", "append model responses to this JSONL file")
+	replay := fs.String("replay", "", "answer from responses recorded with -record instead of calling the model")
+	concurrency := fs.Int("concurrency", 4, "parallel model calls")
+	pricePrompt := fs.Float64("price-prompt", 0, "USD per million prompt tokens")
+	priceCompletion := fs.Float64("price-completion", 0, "USD per million completion tokens")
+	asJSON := fs.Bool("json", false, "print the reports as JSON")
+	labeled := fs.Bool("labeled", false, "use the items labeled through the feedback API as dataset")
+	cached := fs.Bool("cache", false, "answer cases already classified with the same model and prompt from the analysis cache")
+	fewShot := fs.Bool("few-shot", cfg.FewShotEnabled, "show the model similar labeled items as examples, as the pipeline does")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 && !(*labeled && fs.NArg() == 0) {
+		return errors.New("usage: aiweb3news eval [-model m] [-prompt v] [-compare-model m] [-compare-prompt v] [-record f | -replay f] (-labeled | dataset.jsonl)")
+	}
+	if *record != "" && *replay != "" {
+		return errors.New("-record and -replay are mutually exclusive")
+	}
+
+	// The store is opened once, for the options that read from it.
+	var (
+		store *storage.Store
+		err   error
+	)
+	if *labeled || *cached || *fewShot {
+		if store, err = storage.NewMySQLStore(ctx, cfg, logger); err != nil {
+			return err
+		}
+		defer store.Close()
+	}
+
+	cases, err := loadCases(ctx, store, *labeled, fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("load dataset: %w", err)
+	}
+	if len(cases) == 0 {
+		return errors.New("dataset is empty")
+	}
+
+	var base *analysis.Client
+	switch {
+	case *replay != "":
+		rf, err := os.Open(*replay)
+		if err != nil {
+			return err
+		}
+		backend, err := analysis.LoadReplay(rf)
+		rf.Close()
+		if err != nil {
+			return err
+		}
+		logger.Printf("replaying %d recorded responses from %s", backend.Len(), *replay)
+		base = analysis.NewBackendClient(backend, analysis.ProviderReplay, cfg.OpenAIModel, logger)
+	default:
+		if base, err = newLLM(cfg, logger); err != nil {
+			return err
+		}
+		if !base.Ready() {
+			return errors.New("model client is not configured; set OPENAI_API_KEY or use -replay")
+		}
+		if *record != "" {
+			rf, err := os.OpenFile(*record, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+			if err != nil {
+				return err
+			}
+			defer rf.Close()
+			base = analysis.NewBackendClient(analysis.NewRecorder(base.Backend(), rf), base.Provider(), cfg.OpenAIModel, logger)
+		}
+	}
+
+	if *cached {
+		base = base.WithCache(store, cfg.AnalysisCacheTTL)
+	}
+	var examples eval.Examples
+	if *fewShot {
+		if examples, err = loadExamples(ctx, cfg, store); err != nil {
+			return fmt.Errorf("load few-shot examples: %w", err)
+		}
+	}
+
+	type variant struct{ name, model, prompt string }
+	variants := []variant{{"baseline", *model, *prompt}}
+	if *compareModel != "" || *comparePrompt != "" {
+		variants = append(variants, variant{"candidate", *compareModel, *comparePrompt})
+		if variants[1].model == "" {
+			variants[1].model = *model
+		}
+		if variants[1].prompt == "" {
+			variants[1].prompt = *prompt
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	pricing := eval.Pricing{PromptPerMillion: *pricePrompt, CompletionPerMillion: *priceCompletion}
+	var reports []eval.Report
+	for _, v := range variants {
+		client, err := base.WithModel(v.model, v.prompt)
+		if err != nil {
+			return fmt.Errorf("%s: %w", v.name, err)
+		}
+		logger.Printf("evaluating %s (%s, prompt %s) on %d cases", v.name, v.model, v.prompt, len(cases))
+		reports = append(reports, eval.Run(ctx, v.name, client, cases, examples, pricing, *concurrency))
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reports)
+	}
+	return eval.WriteText(os.Stdout, reports)
+}
+
+// loadCases reads the golden dataset from a JSONL file or, with labeled, from
+// the latest feedback label of every labeled item.
+func loadCases(ctx context.Context, store *storage.Store, labeled bool, path string) ([]eval.Case, error) {
+	if !labeled {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return eval.LoadCases(f)
+	}
+	items, err := store.LabeledItems(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	cases := make([]eval.Case, 0, len(items))
+	for _, l := range items {
+		cases = append(cases, eval.LabeledCase(l))
+	}
+	return cases, nil
+}
+
+// loadExamples selects few-shot examples from the stored labels the way the
+// pipeline does, never showing a case its own label.
+func loadExamples(ctx context.Context, cfg config.Config, store *storage.Store) (eval.Examples, error) {
+	labeled, err := store.LabeledItems(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	pool := fewshot.NewPool()
+	for _, l := range labeled {
+		pool.Put(fewshot.FromLabel(l))
+	}
+	return func(c eval.Case) []analysis.Example {
+		return pool.Similar(c.ItemID, c.Title, c.Summary, cfg.FewShotExamples, cfg.FewShotMaxChars)
+	}, nil
+}
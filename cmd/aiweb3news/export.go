@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+
+	"aiweb3news/internal/config"
+	"aiweb3news/internal/export"
+	"aiweb3news/internal/storage"
+)
+
+// runExport writes filtered items to a file or stdout. Every query parameter
+// of GET /api/v1/items is accepted as a flag of the same name.
+func runExport(ctx context.Context, cfg config.Config, logger *log.Logger, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "csv", "output format: csv, jsonl or xlsx")
+	out := fs.String("out", "", "output file (default stdout)")
+	params := make(map[string]*string, len(storage.ItemFilterParams))
+	for _, p := range storage.ItemFilterParams {
+		params[p.Name] = fs.String(p.Name, "", "filter: "+p.Usage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	f, err := export.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+	values := url.Values{}
+	for name, v := range params {
+		if *v != "" {
+			values.Set(name, *v)
+		}
+	}
+	filter, err := storage.ParseItemFilter(values)
+	if err != nil {
+		return err
+	}
+
+	store, err := storage.NewMySQLStore(ctx, cfg, logger)
+	if err != nil {
+		return fmt.Errorf("init mysql store: %w", err)
+	}
+	defer store.Close()
+
+	var dst io.Writer = os.Stdout
+	if *out == "" {
+		// Keep stdout clean for the exported data.
+		logger.SetOutput(os.Stderr)
+	} else {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		dst = file
+	}
+	buf := bufio.NewWriter(dst)
+
+	ew, err := export.NewWriter(f, buf)
+	if err != nil {
+		return err
+	}
+	count := 0
+	if err := store.StreamItems(ctx, filter, func(item storage.StoredItem) error {
+		count++
+		return ew.Write(item)
+	}); err != nil {
+		return err
+	}
+	if err := ew.Close(); err != nil {
+		return err
+	}
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	logger.Printf("exported %d items as %s", count, f)
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"aiweb3news/internal/config"
+	"aiweb3news/internal/importer"
+	"aiweb3news/internal/service"
+	"aiweb3news/internal/storage"
+)
+
+// importPipeline combines the store's duplicate check with the service's ingest step.
+type importPipeline struct {
+	*storage.Store
+	*service.Service
+}
+
+// runImport backfills historical articles from JSONL/CSV archives, OPML feed
+// lists or plain text URL lists. Interrupted runs can simply be restarted.
+func runImport(ctx context.Context, cfg config.Config, logger *log.Logger, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	kind := fs.String("kind", "", "source kind: jsonl, csv, opml or urls (default: by file extension)")
+	rate := fs.Int("rate", 20, "maximum analyzed items per minute (0 = unlimited)")
+	notify := fs.Bool("notify", false, "push relevant imported items to the webhook")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("usage: aiweb3news import [-kind k] [-rate n] [-notify] file...")
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	svc, store, err := newService(ctx, cfg, logger)
+	if err != nil {
+		return fmt.Errorf("init service: %w", err)
+	}
+	defer store.Close()
+
+	opts := importer.Options{Notify: *notify}
+	if *rate > 0 {
+		opts.Interval = time.Minute / time.Duration(*rate)
+	}
+	runner := importer.NewRunner(importPipeline{Store: store, Service: svc}, opts, logger)
+
+	for _, path := range fs.Args() {
+		k := importer.Kind(*kind)
+		if k == "" {
+			if k, err = importer.DetectKind(path); err != nil {
+				return err
+			}
+		}
+		if err := runner.Import(ctx, path, k); err != nil {
+			logger.Printf("import stopped: %+v", runner.Stats())
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	logger.Printf("import finished: %+v", runner.Stats())
+	return nil
+}
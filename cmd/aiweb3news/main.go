@@ -2,14 +2,21 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"aiweb3news/internal/analysis"
 	"aiweb3news/internal/config"
+	"aiweb3news/internal/entity"
+	"aiweb3news/internal/notify"
+	"aiweb3news/internal/prefilter"
 	"aiweb3news/internal/rss"
 	"aiweb3news/internal/service"
 	"aiweb3news/internal/storage"
+	"aiweb3news/internal/usage"
 )
 
 func main() {
@@ -17,21 +24,219 @@ func main() {
 	logger := log.New(os.Stdout, "[aiweb3news] ", log.LstdFlags)
 	ctx := context.Background()
 
-	if cfg.OpenAIKey == "" {
-		logger.Println("warning: OPENAI_API_KEY is not set, analysis calls will fail")
+	cmd := "serve"
+	args := os.Args[1:]
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
 	}
 
-	analyzer := analysis.NewClient(cfg.OpenAIKey, cfg.OpenAIModel, cfg.OpenAIBase, logger)
-	fetcher := rss.NewFetcher(cfg.FeedURL, logger)
-	store, err := storage.NewMySQLStore(ctx, cfg, logger)
+	var err error
+	switch cmd {
+	case "serve":
+		serve(ctx, cfg, logger)
+	case "export":
+		err = runExport(ctx, cfg, logger, args)
+	case "import":
+		err = runImport(ctx, cfg, logger, args)
+	case "embed":
+		err = runEmbed(ctx, cfg, logger, args)
+	case "eval":
+		err = runEval(ctx, cfg, logger, args)
+	case "reanalyze":
+		err = runReanalyze(ctx, cfg, logger, args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\nusage: aiweb3news [serve|export|import|embed|eval|reanalyze] [flags]\n", cmd)
+		os.Exit(2)
+	}
 	if err != nil {
-		logger.Fatalf("failed to init mysql store: %v", err)
+		logger.Fatalf("%s failed: %v", cmd, err)
 	}
-	defer store.Close()
+}
 
-	svc := service.NewService(fetcher, analyzer, store, logger, cfg)
+func serve(ctx context.Context, cfg config.Config, logger *log.Logger) {
+	svc, store, err := newService(ctx, cfg, logger)
+	if err != nil {
+		logger.Fatalf("failed to init service: %v", err)
+	}
+	defer store.Close()
 
 	if err := svc.Run(ctx); err != nil {
 		logger.Fatalf("service stopped with error: %v", err)
 	}
 }
+
+// newService wires the analyzer, fetcher and store the same way for every
+// command that runs items through the pipeline.
+func newService(ctx context.Context, cfg config.Config, logger *log.Logger) (*service.Service, *storage.Store, error) {
+	if usesStub(cfg) && !cfg.LLMStubAllowed {
+		return nil, nil, errors.New("the stub LLM provider stores and pushes placeholder analyses; set LLM_STUB_ALLOWED=true to use it with the pipeline")
+	}
+	destinations, err := notify.LoadDestinations(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	entities, err := entity.Load(cfg.EntityAliasesFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	var rules *prefilter.Engine
+	switch cfg.PrefilterMode {
+	case "off":
+	case "shadow", "enforce":
+		if rules, err = prefilter.Load(cfg.PrefilterRulesFile, cfg.PrefilterThreshold); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, fmt.Errorf("unknown PREFILTER_MODE %q", cfg.PrefilterMode)
+	}
+	prices, err := usage.LoadPrices(cfg.LLMPricesFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	llm, err := newLLM(cfg, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	store, err := storage.NewMySQLStore(ctx, cfg, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	var cache analysis.CacheStore
+	if cfg.AnalysisCache {
+		cache = store
+		llm = llm.WithCache(cache, cfg.AnalysisCacheTTL)
+	}
+	analyzers := analyzerFactory(cfg, llm, cache, logger)
+	analyzer, err := analyzers("", "")
+	if err != nil {
+		store.Close()
+		return nil, nil, err
+	}
+	fetcher := rss.NewFetcher(cfg.FeedURL, logger)
+
+	return service.NewService(fetcher, analyzer, analyzers, llm, store, notify.New(destinations, logger), entities, rules, prices, logger, cfg), store, nil
+}
+
+// usesStub reports whether the primary, a fallback or an ensemble model is
+// served by the stub provider.
+func usesStub(cfg config.Config) bool {
+	if cfg.LLMProvider == analysis.ProviderStub {
+		return true
+	}
+	for _, spec := range append(append([]config.ModelSpec(nil), cfg.LLMFallback...), cfg.LLMEnsemble...) {
+		if spec.Provider == analysis.ProviderStub {
+			return true
+		}
+	}
+	return false
+}
+
+// newLLM builds the model client selected by LLM_PROVIDER.
+func newLLM(cfg config.Config, logger *log.Logger) (*analysis.Client, error) {
+	switch cfg.LLMProvider {
+	case analysis.ProviderOpenAI:
+		if cfg.OpenAIKey == "" {
+			logger.Println("warning: OPENAI_API_KEY is not set, analysis calls will fail")
+		}
+		return analysis.NewClient(cfg.OpenAIKey, cfg.OpenAIModel, cfg.OpenAIBase, logger), nil
+	case analysis.ProviderStub:
+		logger.Println("using stub LLM provider, analyses are placeholders")
+		return analysis.NewStubClient(cfg.OpenAIModel, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM_PROVIDER %q", cfg.LLMProvider)
+	}
+}
+
+// analyzerFactory builds analyzers for the pipeline and for re-analysis jobs
+// alike. An explicit model replaces the primary client's, and classifies
+// alone when an ensemble is configured; a prompt version applies to every
+// member.
+func analyzerFactory(cfg config.Config, llm *analysis.Client, cache analysis.CacheStore, logger *log.Logger) service.AnalyzerFactory {
+	return func(model, promptVersion string) (analysis.Analyzer, error) {
+		primary, err := llm.WithModel(model, promptVersion)
+		if err != nil {
+			return nil, err
+		}
+		if model != "" && len(cfg.LLMEnsemble) > 0 {
+			name := cfg.LLMProvider + ":" + primary.Model()
+			return withBreaker(cfg, name, primary, logger), nil
+		}
+		return newAnalyzer(cfg, primary, promptVersion, cache, logger)
+	}
+}
+
+// newAnalyzer wraps the primary client in the ensemble configured by
+// LLM_ENSEMBLE or the fallback chain configured by LLM_FALLBACK; without
+// either it is the primary client itself. A non-nil cache is shared by every
+// member, and with LLM_BREAKER every model gets its own circuit breaker.
+func newAnalyzer(cfg config.Config, primary *analysis.Client, promptVersion string, cache analysis.CacheStore, logger *log.Logger) (analysis.Analyzer, error) {
+	primaryName := cfg.LLMProvider + ":" + primary.Model()
+	switch {
+	case len(cfg.LLMEnsemble) > 0:
+		members, err := modelMembers(cfg, cfg.LLMEnsemble, promptVersion, cache, logger)
+		if err != nil {
+			return nil, err
+		}
+		ensemble, err := analysis.NewEnsemble(members, cfg.LLMEnsembleVoting, cfg.LLMTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("LLM_ENSEMBLE: %w", err)
+		}
+		logger.Printf("classifying with an ensemble of %s (%s voting)", ensemble.Names(), cfg.LLMEnsembleVoting)
+		return ensemble, nil
+	case len(cfg.LLMFallback) > 0:
+		fallbacks, err := modelMembers(cfg, cfg.LLMFallback, promptVersion, cache, logger)
+		if err != nil {
+			return nil, err
+		}
+		members := append([]analysis.Member{{Name: primaryName, Analyzer: withBreaker(cfg, primaryName, primary, logger)}}, fallbacks...)
+		return analysis.NewFallback(members, cfg.LLMTimeout, logger), nil
+	default:
+		return withBreaker(cfg, primaryName, primary, logger), nil
+	}
+}
+
+// withBreaker wraps a model in a circuit breaker when LLM_BREAKER is on.
+func withBreaker(cfg config.Config, name string, a analysis.Analyzer, logger *log.Logger) analysis.Analyzer {
+	if !cfg.LLMBreaker {
+		return a
+	}
+	return analysis.NewBreaker(name, a, analysis.BreakerConfig{
+		Failures:    cfg.LLMBreakerFailures,
+		Cooldown:    cfg.LLMBreakerCooldown,
+		BackoffBase: cfg.LLMBackoffBase,
+		BackoffMax:  cfg.LLMBackoffMax,
+	}, logger)
+}
+
+// modelMembers builds one client per spec; a non-empty promptVersion
+// replaces the configured classification prompt.
+func modelMembers(cfg config.Config, specs []config.ModelSpec, promptVersion string, cache analysis.CacheStore, logger *log.Logger) ([]analysis.Member, error) {
+	members := make([]analysis.Member, 0, len(specs))
+	for _, spec := range specs {
+		var client *analysis.Client
+		switch spec.Provider {
+		case analysis.ProviderOpenAI:
+			client = analysis.NewClient(cfg.OpenAIKey, spec.Model, cfg.OpenAIBase, logger)
+		case analysis.ProviderStub:
+			client = analysis.NewStubClient(spec.Model, logger)
+		default:
+			endpoint := cfg.LLMEndpoints[spec.Provider]
+			if endpoint.BaseURL == "" {
+				return nil, fmt.Errorf("model %s: LLM_PROVIDER_%s_BASE_URL is not set", spec, strings.ToUpper(spec.Provider))
+			}
+			client = analysis.NewClient(endpoint.APIKey, spec.Model, endpoint.BaseURL, logger)
+		}
+		if !client.Ready() {
+			logger.Printf("warning: model %s has no API key, its calls will fail", spec)
+		}
+		client, err := client.WithModel("", promptVersion)
+		if err != nil {
+			return nil, err
+		}
+		if cache != nil {
+			client = client.WithCache(cache, cfg.AnalysisCacheTTL)
+		}
+		members = append(members, analysis.Member{Name: spec.String(), Analyzer: withBreaker(cfg, spec.String(), client, logger)})
+	}
+	return members, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"aiweb3news/internal/config"
+	"aiweb3news/internal/service"
+)
+
+// runReanalyze re-evaluates stored items with a chosen model and prompt
+// version in the foreground, then prints the relevance flips. Interrupting
+// pauses the job; -resume continues it.
+func runReanalyze(ctx context.Context, cfg config.Config, logger *log.Logger, args []string) error {
+	fs := flag.NewFlagSet("reanalyze", flag.ContinueOnError)
+	model := fs.String("model", "", "model to use (default OPENAI_MODEL)")
+	prompt := fs.String("prompt", "", "classification prompt version (default the current one)")
+	notify := fs.Bool("notify", false, "push items that become relevant")
+	rate := fs.Int("rate", 20, "maximum analyzed items per minute (0 = unlimited)")
+	bypassCache := fs.Bool("bypass-cache", false, "call the model even when the analysis cache has an answer")
+	resume := fs.Int64("resume", 0, "resume the paused or failed job with this id")
+	relevant := fs.String("relevant", "", "filter by relevance: true or false")
+	category := fs.String("category", "", "filter by category")
+	tag := fs.String("tag", "", "filter by tag")
+	query := fs.String("q", "", "keyword in title, summary or reason")
+	since := fs.String("since", "", "published at or after (YYYY-MM-DD or RFC3339)")
+	until := fs.String("until", "", "published before (YYYY-MM-DD or RFC3339)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New("usage: aiweb3news reanalyze [-model m] [-prompt v] [-notify] [-rate n] [-bypass-cache] [filters] | -resume id")
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	svc, store, err := newService(ctx, cfg, logger)
+	if err != nil {
+		return fmt.Errorf("init service: %w", err)
+	}
+	defer store.Close()
+
+	id := *resume
+	if id > 0 {
+		ok, err := svc.ResumeReanalysis(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("job %d is not paused or failed", id)
+		}
+	} else {
+		filter := map[string]string{}
+		for key, v := range map[string]string{
+			"relevant": *relevant, "category": *category, "tag": *tag, "q": *query, "since": *since, "until": *until,
+		} {
+			if v != "" {
+				filter[key] = v
+			}
+		}
+		job, err := svc.CreateReanalysis(ctx, service.ReanalysisRequest{
+			Filter:        filter,
+			Model:         *model,
+			PromptVersion: *prompt,
+			Notify:        *notify,
+			RatePerMinute: *rate,
+			BypassCache:   *bypassCache,
+		})
+		if err != nil {
+			return err
+		}
+		id = job.ID
+		logger.Printf("created reanalysis job %d over %d items", id, job.Total)
+	}
+
+	if err := svc.RunReanalysis(ctx, id); err != nil {
+		if ctx.Err() != nil {
+			logger.Printf("job %d paused, continue with: aiweb3news reanalyze -resume %d", id, id)
+		}
+		return err
+	}
+	report, err := svc.ReanalysisReport(context.Background(), id)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
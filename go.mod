@@ -1,11 +1,12 @@
 module aiweb3news
 
-go 1.21
+go 1.23.0
 
 require (
 	github.com/go-sql-driver/mysql v1.7.1
 	github.com/mmcdole/gofeed v1.2.0
 	github.com/sashabaranov/go-openai v1.23.0
+	golang.org/x/net v0.43.0
 )
 
 require (
@@ -15,6 +16,5 @@ require (
 	github.com/mmcdole/goxpp v0.0.0-20200921145534-2f3784f67354 // indirect
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
-	golang.org/x/net v0.4.0 // indirect
-	golang.org/x/text v0.5.0 // indirect
+	golang.org/x/text v0.28.0 // indirect
 )
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"strings"
 	"time"
 
@@ -18,6 +19,96 @@ type Result struct {
 	Category string   `json:"category"`
 	Reason   string   `json:"reason"`
 	Tags     []string `json:"tags"`
+	// Importance ranks long-term industry impact from 1 to 10.
+	Importance int `json:"importance"`
+	// Confidence is the model's certainty in Relevant and Category, 0 to 1.
+	// HasConfidence tells a reported 0 from an answer without the field, e.g.
+	// from a prompt version that did not ask for it.
+	Confidence    float64 `json:"confidence"`
+	HasConfidence bool    `json:"-"`
+	// Entities lists typed entities mentioned by the item.
+	Entities []Entity `json:"entities"`
+	// SecurityIncident marks hacks and exploits, whether relevant or not.
+	SecurityIncident bool `json:"security_incident"`
+
+	// Meta describes the model call that produced the result. It is not part of
+	// the JSON contract returned by the model.
+	Meta Meta `json:"-"`
+	// Attempts, Votes and Disagreement are filled by composite analyzers.
+	Attempts     []Attempt `json:"-"`
+	Votes        []Vote    `json:"-"`
+	Disagreement bool      `json:"-"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler, setting HasConfidence when
+// the answer contains a confidence.
+func (r *Result) UnmarshalJSON(b []byte) error {
+	type plain Result
+	var aux struct {
+		plain
+		Confidence *float64 `json:"confidence"`
+	}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	*r = Result(aux.plain)
+	if aux.Confidence != nil {
+		r.Confidence, r.HasConfidence = *aux.Confidence, true
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, leaving out a confidence that was
+// not reported so cached results keep HasConfidence.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type plain Result
+	aux := struct {
+		plain
+		Confidence *float64 `json:"confidence,omitempty"`
+	}{plain: plain(r)}
+	if r.HasConfidence {
+		aux.Confidence = &r.Confidence
+	}
+	return json.Marshal(aux)
+}
+
+// Entity types the model may return.
+const (
+	EntityOrganization = "organization"
+	EntityPerson       = "person"
+	EntityJurisdiction = "jurisdiction"
+	EntityChain        = "chain"
+	EntityToken        = "token"
+	EntityProtocol     = "protocol"
+	EntityAmount       = "amount"
+)
+
+// Entity is a typed mention extracted from a news item. Amount and Currency
+// are only set for EntityAmount.
+type Entity struct {
+	Type     string  `json:"type"`
+	Name     string  `json:"name"`
+	Amount   float64 `json:"amount,omitempty"`
+	Currency string  `json:"currency,omitempty"`
+}
+
+// Meta records how a Result was produced.
+type Meta struct {
+	Purpose          string
+	Model            string
+	PromptVersion    string
+	Prompt           string
+	RawResponse      string
+	FinishReason     string
+	Latency          time.Duration
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	// ExampleIDs lists the labeled items injected as few-shot examples.
+	ExampleIDs []int64
+	// CachedAt is set when the result was answered from the cache instead of
+	// a model call; it is when the cached call was made.
+	CachedAt time.Time
 }
 
 // Analyzer abstracts AI powered classification.
@@ -32,82 +123,241 @@ type ItemContext struct {
 	Link        string
 	PublishedAt time.Time
 	Summary     string
+	// Examples are human-labeled items similar to this one, shown to the
+	// model as reference judgements.
+	Examples []Example
+}
+
+// Example is a human-labeled item used as a few-shot example.
+type Example struct {
+	ID       int64
+	Title    string
+	Summary  string
+	Relevant bool
+	Category string
+	// Note is the analyst's comment, if any.
+	Note string
 }
 
 var errDisabled = errors.New("openai client disabled: missing OPENAI_API_KEY")
 
+// Backend is the part of the OpenAI API the client uses. *openai.Client
+// satisfies it; Stub replaces it for offline runs.
+type Backend interface {
+	CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error)
+	CreateEmbeddings(ctx context.Context, conv openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error)
+}
+
+// LatencyReporter is implemented by backends that answer without calling the
+// provider but know how long the provider took to answer req, such as Replay.
+// The client reports that latency instead of its own.
+type LatencyReporter interface {
+	ChatLatency(req openai.ChatCompletionRequest) (time.Duration, bool)
+}
+
 // Client implements Analyzer using the OpenAI chat completion API.
 type Client struct {
-	client    *openai.Client
-	model     string
-	logger    *log.Logger
-	activated bool
+	client        Backend
+	provider      string
+	model         string
+	promptVersion string
+	logger        *log.Logger
+	activated     bool
+	cache         *resultCache
 }
 
 // NewClient builds a new Analyzer. If apiKey is empty, calls will be no-op with errors.
 func NewClient(apiKey, model, baseURL string, logger *log.Logger) *Client {
-	var cli *openai.Client
+	var cli Backend
 	activated := apiKey != ""
 	if activated {
 		cfg := openai.DefaultConfig(apiKey)
 		if baseURL != "" {
 			cfg.BaseURL = baseURL
 		}
+		cfg.HTTPClient = &http.Client{Transport: retryAfterTransport{next: http.DefaultTransport}}
 		cli = openai.NewClientWithConfig(cfg)
 	}
 	return &Client{
-		client:    cli,
-		model:     model,
-		logger:    logger,
-		activated: activated,
+		client:        cli,
+		provider:      ProviderOpenAI,
+		model:         model,
+		promptVersion: DefaultPromptVersion,
+		logger:        logger,
+		activated:     activated,
+	}
+}
+
+// NewStubClient builds a client answering from Stub, for tests and offline development.
+func NewStubClient(model string, logger *log.Logger) *Client {
+	return &Client{
+		client:        Stub{},
+		provider:      ProviderStub,
+		model:         model,
+		promptVersion: DefaultPromptVersion,
+		logger:        logger,
+		activated:     true,
+	}
+}
+
+// NewBackendClient builds a client on top of an arbitrary backend, such as a
+// Recorder or Replay. provider names the backend for logs and reports.
+func NewBackendClient(backend Backend, provider, model string, logger *log.Logger) *Client {
+	return &Client{
+		client:        backend,
+		provider:      provider,
+		model:         model,
+		promptVersion: DefaultPromptVersion,
+		logger:        logger,
+		activated:     backend != nil,
 	}
 }
 
+// WithModel returns a copy of the client using model and the classification
+// prompt promptVersion; empty arguments keep the current values.
+func (c *Client) WithModel(model, promptVersion string) (*Client, error) {
+	out := *c
+	if model != "" {
+		out.model = model
+	}
+	if promptVersion != "" {
+		if _, err := SystemPrompt(promptVersion); err != nil {
+			return nil, err
+		}
+		out.promptVersion = promptVersion
+	}
+	return &out, nil
+}
+
+// Backend returns the backend the client talks to.
+func (c *Client) Backend() Backend {
+	return c.client
+}
+
+// Model returns the chat model name.
+func (c *Client) Model() string {
+	return c.model
+}
+
+// PromptVersion returns the classification prompt version.
+func (c *Client) PromptVersion() string {
+	return c.promptVersion
+}
+
+// Provider reports which backend the client talks to.
+func (c *Client) Provider() string {
+	return c.provider
+}
+
 // Ready indicates whether the analyzer is usable.
 func (c *Client) Ready() bool {
 	return c.activated && c.client != nil
 }
 
 // Evaluate asks the model to categorize the news item and decide whether it matches our criteria.
+// On failure the returned Result still carries whatever Meta was collected so
+// callers can audit the call. With a cache, an unexpired classification of
+// the same content is returned instead of calling the model.
 func (c *Client) Evaluate(ctx context.Context, item ItemContext) (Result, error) {
 	if !c.Ready() {
 		return Result{}, errDisabled
 	}
+	if c.cache == nil {
+		return c.evaluate(ctx, item)
+	}
+	key := CacheKey(c.model, c.promptVersion, item)
+	if looked, _ := ctx.Value(lookedUpKey{}).(bool); !looked {
+		if out, ok := c.cached(ctx, key, item); ok {
+			return out, nil
+		}
+	}
+	out, err := c.evaluate(ctx, item)
+	if err == nil {
+		c.storeCached(ctx, key, out)
+	}
+	return out, err
+}
 
-	systemPrompt := "你是一个 Web3 资讯分析助手，风格参考“机构级 Web3 与金融科技融合”的资深投研分析师的人工筛选偏好。\n你的目标是从大量新闻中挑出具有中长期行业意义的结构性事件，而不是短期价格噪音或单一项目营销。\n\n一、判断资讯是否“重要”（relevant）\n\n仅当满足以下至少一项时，认为 `relevant=true`，否则为 `false`：\n\n1. 监管 / 政策 / 官方试点  \n   - 国家级或重要金融监管机构发布、通过、更新与加密资产 / 稳定币 / 代币化 / 交易平台相关的法规、牌照框架、监管指引或试点计划。  \n   - 典型主体：要经济体（美、港、新、欧、日、俄）政府或监管机构（如 SEC, 金管局, 央行）等。\n\n2. 主流机构 & TradFi 深度参与  \n   - 大型银行、支付巨头、互联网巨头、国际组织与加密行业达成合作、上线相关产品或实质使用区块链 / 稳定币 / 代币化资产。  \n   - 如：JPMorgan、PayPal、Stripe、Visa、Mastercard、Google、Cloudflare、Volkswagen 等。\n\n3. 公链 / 核心基础设施的长期路线或重大升级  \n   - 以太坊、Solana 等主流公链基金会或核心团队公布中长期路线图、性能目标、关键协议升级、或新型基础设施平台（如分布式账本、结算平台）。\n\n4. RWA、稳定币与支付基础设施  \n   - 现实世界资产（基金、国债、货币市场基金、股票等）上链或代币化的重大里程碑。  \n   - 稳定币、跨链/多链流动性、支付网络、订阅/结算方案、可编程支付、银行负债代币化等基础设施落地或监管突破。\n\n5. 大额融资或标志性项目发布  \n   - 金额较大的融资（通常 ≥ 500 万美元）或IPO相关且方向为：  \n     - 交易所、RWA、稳定币、支付网络、预测市场、机构 DeFi、合规基础设施、与金融 / Web3相关的 AI 平台等。  \n   - 或头部机构（如顶级 VC、大型银行/支付公司、主流公链基金会）领投/参投。  \n   - 重要项目/平台正式发布或明确上线时间表，且方向同上。\n\n6. 新兴赛道与生态热点  \n   - 具有明显“新模式”或“新市场”的项目/路线图，如预测市场、互联网资本市场、代币化、稳定币支付网络、AI+Web3、合规/风控基础设施等，且有一定规模或机构背书。\n\n以下通常视为不重要（relevant=false）：\n- 单一代币/项目的小额融资，且无明显 RWA / 支付 / 机构 / 监管属性。  \n- 单一交易所上币、期货合约上架、常规功能迭代。  \n- 单纯的代币价格波动或行情分析。\n- KOL 观点、空投/活动公告、单纯营销合作。  \n- 一般安全事件/黑客攻击（除非引发监管框架或机构行为变化）。\n- 娱乐性强但无金融实质的 GameFi 或 Meme 币资讯。\n\n二、输出格式\n\n只返回 JSON，不要输出任何多余文字。\n\n字段：\n- `relevant`：布尔值，表示是否符合上述重要性标准。    \n- `category`：字符串，仅限上述 6个类别中的一项（仅在 `relevant=true` 时有意义；如果 `relevant=false`，可置为 `\"\"`）。 \n- `reason`：简要中文理由，说明你判定的重要性与类别依据。  \n- `tags`：字符串数组，包含涉及的链 / 机构 / 赛道，例如：`[\"Ethereum\",\"Solana\",\"RWA\",\"稳定币\",\"PayPal\",\"预测市场\"]`。\n\n例如（仅示意，不要在真实回答里解释该示例）：\n\n{\n  \"relevant\": true,\n  \"category\": \"RWA、稳定币与支付基础设施\",\n  \"reason\": \"大型支付机构推出基于稳定币的订阅支付功能，强化稳定币在跨境结算和经常性支付中的应用，属于支付基础设施重大进展。\",\n  \"tags\": [\"稳定币\", \"支付\", \"USDC\", \"Stripe\", \"Base\", \"Polygon\"]\n}\n"
-
-	userPrompt := fmt.Sprintf("标题: %s\n链接: %s\n发布时间: %s\n摘要: %s\n请输出JSON。",
-		item.Title,
-		item.Link,
-		item.PublishedAt.Format(time.RFC3339),
-		trimText(item.Summary, 800),
-	)
-
-	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model: c.model,
-		Messages: []openai.ChatCompletionMessage{
-			{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
-			{Role: openai.ChatMessageRoleUser, Content: userPrompt},
-		},
-		Temperature: 0.2,
-	})
+func (c *Client) evaluate(ctx context.Context, item ItemContext) (Result, error) {
+	systemPrompt, err := SystemPrompt(c.promptVersion)
 	if err != nil {
 		return Result{}, err
 	}
-	if len(resp.Choices) == 0 {
-		return Result{}, errors.New("no choices returned by OpenAI")
+
+	userPrompt := renderItem(item)
+
+	messages := []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleSystem, Content: systemPrompt}}
+	if len(item.Examples) > 0 {
+		messages = append(messages, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleSystem, Content: renderExamples(item.Examples)})
 	}
+	messages = append(messages, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: userPrompt})
 
-	content := cleanupResponse(resp.Choices[0].Message.Content)
+	raw, meta, err := c.chat(ctx, PurposeClassification, c.promptVersion, messages)
+	for _, e := range item.Examples {
+		meta.ExampleIDs = append(meta.ExampleIDs, e.ID)
+	}
+	if err != nil {
+		return Result{Meta: meta}, err
+	}
+
+	content := cleanupResponse(raw)
 	var out Result
 	if err := json.Unmarshal([]byte(content), &out); err != nil {
 		c.logger.Printf("failed to parse OpenAI response, content=%q, err=%v", content, err)
-		return Result{}, fmt.Errorf("parse openai response: %w", err)
+		return Result{Meta: meta}, fmt.Errorf("parse openai response: %w", err)
 	}
+	out.normalize()
+	out.Meta = meta
 
 	return out, nil
 }
 
+// renderItem formats an item as the user message shared by all item prompts.
+func renderItem(item ItemContext) string {
+	return fmt.Sprintf("标题: %s\n链接: %s\n发布时间: %s\n摘要: %s\n请输出JSON。",
+		item.Title,
+		item.Link,
+		item.PublishedAt.Format(time.RFC3339),
+		trimText(item.Summary, 800),
+	)
+}
+
+// renderExamples lists labeled items as reference judgements for the model.
+func renderExamples(examples []Example) string {
+	var b strings.Builder
+	b.WriteString("以下是团队人工标注过的相似资讯，请参考其判断标准（仅供参考，仍按上述规则输出 JSON）：")
+	for i, e := range examples {
+		verdict := "不相关"
+		if e.Relevant {
+			verdict = "相关"
+			if e.Category != "" {
+				verdict += "，分类: " + e.Category
+			}
+		}
+		fmt.Fprintf(&b, "\n%d. 标题: %s\n   摘要: %s\n   人工判断: %s", i+1, e.Title, e.Summary, verdict)
+		if e.Note != "" {
+			fmt.Fprintf(&b, "\n   备注: %s", e.Note)
+		}
+	}
+	return b.String()
+}
+
+// normalize clamps scores the model reported outside their documented ranges.
+func (r *Result) normalize() {
+	if r.Importance < 0 {
+		r.Importance = 0
+	}
+	if r.Importance > 10 {
+		r.Importance = 10
+	}
+	if r.Confidence < 0 {
+		r.Confidence = 0
+	}
+	if r.Confidence > 1 {
+		r.Confidence = 1
+	}
+}
+
 func trimText(s string, max int) string {
 	if len([]rune(s)) <= max {
 		return s
@@ -0,0 +1,41 @@
+package analysis
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestResultConfidenceJSON(t *testing.T) {
+	tests := []struct {
+		name       string
+		in         string
+		confidence float64
+		has        bool
+	}{
+		{"reported", `{"relevant":true,"confidence":0.8}`, 0.8, true},
+		{"reported zero", `{"relevant":true,"confidence":0}`, 0, true},
+		{"missing", `{"relevant":true}`, 0, false},
+		{"null", `{"relevant":true,"confidence":null}`, 0, false},
+	}
+	for _, tt := range tests {
+		var r Result
+		if err := json.Unmarshal([]byte(tt.in), &r); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !r.Relevant || r.Confidence != tt.confidence || r.HasConfidence != tt.has {
+			t.Errorf("%s: got %+v", tt.name, r)
+		}
+		// A cached result must come back the same.
+		b, err := json.Marshal(r)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var back Result
+		if err := json.Unmarshal(b, &back); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if back.Confidence != r.Confidence || back.HasConfidence != r.HasConfidence {
+			t.Errorf("%s: round trip %s gave %+v", tt.name, b, back)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AskPromptVersion identifies the question answering prompt.
+const AskPromptVersion = "ask-v1"
+
+// PurposeAnswer labels question answering calls for auditing.
+const PurposeAnswer = "answer"
+
+const askPrompt = `你是一个 Web3 行业研究助手，只能依据用户提供的资讯回答问题。只返回 JSON，不要输出任何多余文字。
+
+要求：
+- 使用中文回答，简洁、客观，按时间顺序梳理进展。
+- 每个事实陈述后用方括号标注来源编号，如 [1]、[2][3]，编号只能来自下方列出的资讯。
+- 资讯不足以回答时如实说明，不要编造，也不要引用未列出的来源。
+
+字段：
+- ` + "`answer`" + `：回答正文，含来源编号。
+- ` + "`citations`" + `：回答中引用到的来源编号数组，如 [1, 3]。
+`
+
+// Source is a stored item offered to the model as evidence, labelled [Ref].
+type Source struct {
+	Ref         int
+	Title       string
+	Link        string
+	PublishedAt time.Time
+	Summary     string
+}
+
+// Answer is the model's reply to a question over a set of sources.
+type Answer struct {
+	Answer    string `json:"answer"`
+	Citations []int  `json:"citations"`
+
+	Meta Meta `json:"-"`
+}
+
+// Ask has the model answer question using only sources. Callers must still
+// check that every cited reference belongs to sources.
+func (c *Client) Ask(ctx context.Context, question string, sources []Source) (Answer, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "问题: %s\n\n资讯:\n", question)
+	for _, s := range sources {
+		fmt.Fprintf(&b, "[%d] %s（%s）\n链接: %s\n摘要: %s\n\n", s.Ref, s.Title, s.PublishedAt.Format("2006-01-02"), s.Link, trimText(s.Summary, 500))
+	}
+	b.WriteString("请输出JSON。")
+
+	var out Answer
+	meta, err := c.completeJSON(ctx, PurposeAnswer, AskPromptVersion, askPrompt, b.String(), &out)
+	out.Meta = meta
+	return out, err
+}
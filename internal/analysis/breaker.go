@@ -0,0 +1,278 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"aiweb3news/internal/metrics"
+)
+
+// Circuit breaker states.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// ErrBreakerOpen is returned without calling the model while the circuit is
+// open. Callers should retry the item later instead of dropping it.
+var ErrBreakerOpen = errors.New("model provider circuit breaker is open")
+
+var (
+	breakerState = metrics.NewGauge("aiweb3news_llm_breaker_state",
+		"Circuit breaker state per model: 0 closed, 1 half-open, 2 open.", "name")
+	breakerTrips = metrics.NewCounter("aiweb3news_llm_breaker_trips_total",
+		"Times the circuit breaker opened, by model and cause (failures or rate_limit).", "name", "cause")
+	breakerRejected = metrics.NewCounter("aiweb3news_llm_breaker_rejected_total",
+		"Calls refused while the circuit breaker was open.", "name")
+)
+
+// BreakerConfig tunes a Breaker.
+type BreakerConfig struct {
+	// Failures is how many consecutive provider failures open the circuit.
+	Failures int
+	// Cooldown is how long the circuit stays open before a half-open probe.
+	Cooldown time.Duration
+	// BackoffBase and BackoffMax bound the jittered exponential delay applied
+	// after consecutive 429 responses without Retry-After.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+}
+
+// BreakerState is a snapshot of a Breaker for health checks.
+type BreakerState struct {
+	Name      string     `json:"name"`
+	State     string     `json:"state"`
+	Failures  int        `json:"consecutive_failures"`
+	OpenUntil *time.Time `json:"open_until,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// BreakerReporter is implemented by analyzers that contain circuit breakers.
+type BreakerReporter interface {
+	BreakerStates() []BreakerState
+}
+
+// Breaker is a circuit breaker around an Analyzer. After Failures
+// consecutive provider failures (5xx, timeouts, network errors) it refuses
+// calls for Cooldown, then lets a single probe through: success closes the
+// circuit, failure opens it again. A 429 opens it at once, for the
+// provider's Retry-After or else a jittered exponential backoff.
+type Breaker struct {
+	name   string
+	next   Analyzer
+	cfg    BreakerConfig
+	logger *log.Logger
+
+	mu          sync.Mutex
+	state       string
+	failures    int
+	rateLimited int
+	openUntil   time.Time
+	lastErr     string
+}
+
+// NewBreaker wraps next; name identifies it in logs, health and metrics.
+func NewBreaker(name string, next Analyzer, cfg BreakerConfig, logger *log.Logger) *Breaker {
+	b := &Breaker{name: name, next: next, cfg: cfg, logger: logger, state: BreakerClosed}
+	breakerState.Set(0, name)
+	return b
+}
+
+// Ready implements Analyzer.
+func (b *Breaker) Ready() bool {
+	return b.next.Ready()
+}
+
+// cacheAnswerer is implemented by clients that can answer from their cache
+// without calling the provider.
+type cacheAnswerer interface {
+	CachedResult(ctx context.Context, item ItemContext) (Result, context.Context, bool)
+}
+
+// Evaluate implements Analyzer. Cache hits are served whatever the state of
+// the circuit and never count as a probe of the provider.
+func (b *Breaker) Evaluate(ctx context.Context, item ItemContext) (Result, error) {
+	if c, ok := b.next.(cacheAnswerer); ok {
+		var (
+			res Result
+			hit bool
+		)
+		if res, ctx, hit = c.CachedResult(ctx, item); hit {
+			return res, nil
+		}
+	}
+	if err := b.acquire(); err != nil {
+		return Result{}, err
+	}
+	res, err := b.next.Evaluate(ctx, item)
+	if errors.Is(ctx.Err(), context.Canceled) || !res.Meta.CachedAt.IsZero() {
+		// Our own cancellation says nothing about the provider; a deadline
+		// does, it means the provider was too slow. Nor does an answer the
+		// provider was not asked for.
+		b.release()
+		return res, err
+	}
+	b.report(err)
+	return res, err
+}
+
+// BreakerStates implements BreakerReporter.
+func (b *Breaker) BreakerStates() []BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := BreakerState{Name: b.name, State: b.state, Failures: b.failures, LastError: b.lastErr}
+	if b.state != BreakerClosed {
+		until := b.openUntil
+		s.OpenUntil = &until
+	}
+	return []BreakerState{s}
+}
+
+// acquire refuses calls while open and admits one probe once the cooldown
+// is over.
+func (b *Breaker) acquire() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerClosed:
+		return nil
+	case BreakerOpen:
+		if time.Now().Before(b.openUntil) {
+			break
+		}
+		b.setState(BreakerHalfOpen)
+		return nil
+	}
+	breakerRejected.Inc(b.name)
+	return fmt.Errorf("%s: %w until %s", b.name, ErrBreakerOpen, b.openUntil.Format(time.RFC3339))
+}
+
+// release returns a half-open probe slot without judging the provider.
+func (b *Breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerHalfOpen {
+		b.setState(BreakerOpen)
+	}
+}
+
+func (b *Breaker) report(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var perr *ProviderError
+	switch {
+	case err == nil || !providerFailure(err):
+		if b.state != BreakerClosed {
+			b.logger.Printf("circuit breaker %s closed", b.name)
+		}
+		b.failures, b.rateLimited, b.lastErr = 0, 0, ""
+		b.setState(BreakerClosed)
+	case errors.As(err, &perr) && perr.RateLimited():
+		b.rateLimited++
+		b.lastErr = err.Error()
+		delay := perr.RetryAfter
+		if delay <= 0 {
+			delay = b.backoff(b.rateLimited)
+		}
+		b.open(delay, "rate_limit")
+	default:
+		b.failures++
+		b.lastErr = err.Error()
+		if b.state == BreakerHalfOpen || b.failures >= b.cfg.Failures {
+			delay := b.cfg.Cooldown
+			if errors.As(err, &perr) && perr.RetryAfter > delay {
+				delay = perr.RetryAfter
+			}
+			b.open(delay, "failures")
+		}
+	}
+}
+
+// backoff is BackoffBase doubled per consecutive 429, capped at BackoffMax,
+// with full jitter over its upper half so throttled workers spread out.
+func (b *Breaker) backoff(attempt int) time.Duration {
+	d := b.cfg.BackoffBase
+	for i := 1; i < attempt && d < b.cfg.BackoffMax; i++ {
+		d *= 2
+	}
+	if b.cfg.BackoffMax > 0 && d > b.cfg.BackoffMax {
+		d = b.cfg.BackoffMax
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func (b *Breaker) open(delay time.Duration, cause string) {
+	b.openUntil = time.Now().Add(delay)
+	if b.state != BreakerOpen {
+		breakerTrips.Inc(b.name, cause)
+	}
+	b.setState(BreakerOpen)
+	b.logger.Printf("circuit breaker %s open for %s (%s): %s", b.name, delay.Round(time.Second), cause, b.lastErr)
+}
+
+func (b *Breaker) setState(state string) {
+	b.state = state
+	value := map[string]float64{BreakerClosed: 0, BreakerHalfOpen: 1, BreakerOpen: 2}[state]
+	breakerState.Set(value, b.name)
+}
+
+// providerFailure reports errors that say the provider is unavailable, as
+// opposed to answers we could not use.
+func providerFailure(err error) bool {
+	var perr *ProviderError
+	if errors.As(err, &perr) {
+		return perr.RateLimited() || perr.Unavailable()
+	}
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr)
+}
+
+// circuitOpen reports whether a failed member refused the call or has its
+// circuit open after the failure, such as a half-open probe that failed.
+func circuitOpen(a Analyzer, err error) bool {
+	if errors.Is(err, ErrBreakerOpen) {
+		return true
+	}
+	r, ok := a.(BreakerReporter)
+	if !ok {
+		return false
+	}
+	states := r.BreakerStates()
+	for _, s := range states {
+		if s.State != BreakerOpen {
+			return false
+		}
+	}
+	return len(states) > 0
+}
+
+// BreakerStates implements BreakerReporter for breakers among the members.
+func (f *Fallback) BreakerStates() []BreakerState {
+	return memberBreakers(f.members)
+}
+
+// BreakerStates implements BreakerReporter for breakers among the members.
+func (e *Ensemble) BreakerStates() []BreakerState {
+	return memberBreakers(e.members)
+}
+
+func memberBreakers(members []Member) []BreakerState {
+	var out []BreakerState
+	for _, m := range members {
+		if r, ok := m.Analyzer.(BreakerReporter); ok {
+			out = append(out, r.BreakerStates()...)
+		}
+	}
+	return out
+}
@@ -0,0 +1,187 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var (
+	errUnavailable = &ProviderError{StatusCode: http.StatusServiceUnavailable, Err: errors.New("503 service unavailable")}
+	errThrottled   = &ProviderError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Hour, Err: errors.New("429 too many requests")}
+	errUnparsable  = errors.New("parse openai response")
+)
+
+// scriptedAnalyzer fails its calls with errs in order, then succeeds.
+type scriptedAnalyzer struct {
+	errs  []error
+	calls int
+}
+
+func (s *scriptedAnalyzer) Ready() bool { return true }
+
+func (s *scriptedAnalyzer) Evaluate(context.Context, ItemContext) (Result, error) {
+	s.calls++
+	if s.calls <= len(s.errs) {
+		return Result{}, s.errs[s.calls-1]
+	}
+	return Result{Relevant: true}, nil
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	type step struct {
+		err     error // returned by the provider when the call gets through
+		refused bool
+		state   string
+	}
+	tests := []struct {
+		name     string
+		failures int
+		cooldown time.Duration
+		steps    []step
+	}{
+		{
+			name: "opens after consecutive failures", failures: 2, cooldown: time.Hour,
+			steps: []step{
+				{err: errUnavailable, state: BreakerClosed},
+				{err: errUnavailable, state: BreakerOpen},
+				{refused: true, state: BreakerOpen},
+			},
+		},
+		{
+			name: "unusable answers reset the count", failures: 2, cooldown: time.Hour,
+			steps: []step{
+				{err: errUnavailable, state: BreakerClosed},
+				{err: errUnparsable, state: BreakerClosed},
+				{err: errUnavailable, state: BreakerClosed},
+			},
+		},
+		{
+			name: "a 429 opens at once", failures: 5, cooldown: time.Hour,
+			steps: []step{
+				{err: errThrottled, state: BreakerOpen},
+				{refused: true, state: BreakerOpen},
+			},
+		},
+		{
+			name: "timeouts count as failures", failures: 1, cooldown: time.Hour,
+			steps: []step{
+				{err: context.DeadlineExceeded, state: BreakerOpen},
+			},
+		},
+		{
+			name: "successful probe closes", failures: 1, cooldown: 0,
+			steps: []step{
+				{err: errUnavailable, state: BreakerOpen},
+				{state: BreakerClosed},
+				{state: BreakerClosed},
+			},
+		},
+		{
+			name: "failed probe reopens", failures: 3, cooldown: 0,
+			steps: []step{
+				{err: errUnavailable, state: BreakerClosed},
+				{err: errUnavailable, state: BreakerClosed},
+				{err: errUnavailable, state: BreakerOpen},
+				{err: errUnavailable, state: BreakerOpen},
+				{state: BreakerClosed},
+			},
+		},
+	}
+	for _, tt := range tests {
+		next := &scriptedAnalyzer{}
+		for _, s := range tt.steps {
+			if s.err != nil {
+				next.errs = append(next.errs, s.err)
+			}
+			if !s.refused && s.err == nil {
+				next.errs = append(next.errs, nil)
+			}
+		}
+		b := NewBreaker("test", next, BreakerConfig{Failures: tt.failures, Cooldown: tt.cooldown}, log.New(io.Discard, "", 0))
+		for i, s := range tt.steps {
+			calls := next.calls
+			_, err := b.Evaluate(context.Background(), ItemContext{})
+			if refused := next.calls == calls; refused != s.refused {
+				t.Errorf("%s: step %d refused = %v, want %v", tt.name, i, refused, s.refused)
+			}
+			if s.refused && !errors.Is(err, ErrBreakerOpen) {
+				t.Errorf("%s: step %d error %v, want ErrBreakerOpen", tt.name, i, err)
+			}
+			if state := b.BreakerStates()[0].State; state != s.state {
+				t.Errorf("%s: step %d state %s, want %s", tt.name, i, state, s.state)
+			}
+		}
+	}
+}
+
+func TestBreakerBackoff(t *testing.T) {
+	b := &Breaker{cfg: BreakerConfig{BackoffBase: time.Second, BackoffMax: 4 * time.Second}}
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{1, 500 * time.Millisecond, time.Second},
+		{2, time.Second, 2 * time.Second},
+		{3, 2 * time.Second, 4 * time.Second},
+		{10, 2 * time.Second, 4 * time.Second},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			if d := b.backoff(tt.attempt); d < tt.min || d > tt.max {
+				t.Errorf("attempt %d: backoff %s outside [%s, %s]", tt.attempt, d, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestFallbackAllOpen(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	breaker := func(name string, errs ...error) Member {
+		next := &scriptedAnalyzer{errs: errs}
+		return Member{Name: name, Analyzer: NewBreaker(name, next, BreakerConfig{Failures: 1, Cooldown: time.Hour}, logger)}
+	}
+	tests := []struct {
+		name    string
+		members []Member
+		open    bool
+	}{
+		{"every member trips", []Member{breaker("a", errUnavailable), breaker("b", errThrottled)}, true},
+		{"last member answers badly", []Member{breaker("a", errUnavailable), breaker("b", errUnparsable)}, false},
+		{"members without breakers", []Member{{Name: "a", Analyzer: &scriptedAnalyzer{errs: []error{errUnavailable}}}}, false},
+	}
+	for _, tt := range tests {
+		_, err := NewFallback(tt.members, 0, logger).Evaluate(context.Background(), ItemContext{})
+		if err == nil {
+			t.Fatalf("%s: no error", tt.name)
+		}
+		if open := errors.Is(err, ErrBreakerOpen); open != tt.open {
+			t.Errorf("%s: ErrBreakerOpen = %v, want %v (%v)", tt.name, open, tt.open, err)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"0", 0},
+		{"-5", 0},
+		{"soon", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
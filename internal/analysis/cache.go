@@ -0,0 +1,154 @@
+package analysis
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"aiweb3news/internal/metrics"
+)
+
+var cacheRequests = metrics.NewCounter("aiweb3news_analysis_cache_requests_total",
+	"Classification cache lookups by result (hit, miss, bypass, error).", "result")
+
+// CacheEntry is a stored classification.
+type CacheEntry struct {
+	Key           string
+	Model         string
+	PromptVersion string
+	Result        Result
+	RawResponse   string
+	CreatedAt     time.Time
+}
+
+// CacheStore persists classifications by cache key.
+type CacheStore interface {
+	// CachedAnalysis returns the entry for key unless it is older than since.
+	CachedAnalysis(ctx context.Context, key string, since time.Time) (CacheEntry, bool, error)
+	SaveCachedAnalysis(ctx context.Context, entry CacheEntry) error
+}
+
+type resultCache struct {
+	store CacheStore
+	ttl   time.Duration
+}
+
+type bypassKey struct{}
+
+// BypassCache marks ctx so that classifications skip the cache lookup, e.g.
+// for forced re-analysis. Fresh results are still stored.
+func BypassCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassKey{}).(bool)
+	return bypass
+}
+
+// WithCache returns a copy of the client that answers classifications from
+// store when the same content was classified by the same model and prompt
+// version within ttl.
+func (c *Client) WithCache(store CacheStore, ttl time.Duration) *Client {
+	out := *c
+	out.cache = &resultCache{store: store, ttl: ttl}
+	return &out
+}
+
+var (
+	markupPattern = regexp.MustCompile(`<[^>]*>|https?://\S+`)
+	spacePattern  = regexp.MustCompile(`\s+`)
+)
+
+// NormalizeContent reduces an item to what the classification depends on:
+// lower-cased title and summary without markup, URLs or spacing differences,
+// so republished copies of an article share a hash.
+func NormalizeContent(title, summary string) string {
+	text := markupPattern.ReplaceAllString(title+"\n"+summary, " ")
+	return strings.TrimSpace(spacePattern.ReplaceAllString(strings.ToLower(text), " "))
+}
+
+// CacheKey identifies a classification of item by model, prompt version and
+// the few-shot examples shown, so a new or corrected label changes the key
+// of the items it is shown for.
+func CacheKey(model, promptVersion string, item ItemContext) string {
+	ids := exampleIDs(item)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	examples := make([]string, len(ids))
+	for i, id := range ids {
+		examples[i] = strconv.FormatInt(id, 10)
+	}
+	sum := sha256.Sum256([]byte(model + "\n" + promptVersion + "\n" + strings.Join(examples, ",") + "\n" + NormalizeContent(item.Title, item.Summary)))
+	return hex.EncodeToString(sum[:])
+}
+
+func exampleIDs(item ItemContext) []int64 {
+	var ids []int64
+	for _, e := range item.Examples {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+// lookedUpKey marks a context whose cache lookup was already made, and missed.
+type lookedUpKey struct{}
+
+// CachedResult answers item from the cache without calling the model, so
+// wrappers such as the circuit breaker can serve hits before deciding whether
+// the provider may be called. On a miss it returns ctx marked so that the
+// following Evaluate does not look up again.
+func (c *Client) CachedResult(ctx context.Context, item ItemContext) (Result, context.Context, bool) {
+	if c.cache == nil || !c.Ready() {
+		return Result{}, ctx, false
+	}
+	if out, ok := c.cached(ctx, CacheKey(c.model, c.promptVersion, item), item); ok {
+		return out, ctx, true
+	}
+	return Result{}, context.WithValue(ctx, lookedUpKey{}, true), false
+}
+
+// cached answers item from the cache. Lookup errors count as misses.
+func (c *Client) cached(ctx context.Context, key string, item ItemContext) (Result, bool) {
+	if cacheBypassed(ctx) {
+		cacheRequests.Inc("bypass")
+		return Result{}, false
+	}
+	entry, ok, err := c.cache.store.CachedAnalysis(ctx, key, time.Now().Add(-c.cache.ttl))
+	switch {
+	case err != nil:
+		c.logger.Printf("analysis cache lookup failed: %v", err)
+		cacheRequests.Inc("error")
+		return Result{}, false
+	case !ok:
+		cacheRequests.Inc("miss")
+		return Result{}, false
+	}
+	cacheRequests.Inc("hit")
+	out := entry.Result
+	out.Meta = Meta{
+		Purpose:       PurposeClassification,
+		Model:         entry.Model,
+		PromptVersion: entry.PromptVersion,
+		RawResponse:   entry.RawResponse,
+		ExampleIDs:    exampleIDs(item),
+		CachedAt:      entry.CreatedAt,
+	}
+	return out, true
+}
+
+func (c *Client) storeCached(ctx context.Context, key string, result Result) {
+	if err := c.cache.store.SaveCachedAnalysis(ctx, CacheEntry{
+		Key:           key,
+		Model:         result.Meta.Model,
+		PromptVersion: result.Meta.PromptVersion,
+		Result:        result,
+		RawResponse:   result.Meta.RawResponse,
+	}); err != nil {
+		c.logger.Printf("analysis cache store failed: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package analysis
+
+import "strings"
+
+// The six relevance categories of the classification prompt. The model does
+// not always reproduce them verbatim, so use the Is* helpers for matching.
+const (
+	CategoryRegulation     = "监管 / 政策 / 官方试点"
+	CategoryInstitutions   = "主流机构 & TradFi 深度参与"
+	CategoryInfrastructure = "公链 / 核心基础设施的长期路线或重大升级"
+	CategoryRWAPayments    = "RWA、稳定币与支付基础设施"
+	CategoryFinancing      = "大额融资或标志性项目发布"
+	CategoryEmerging       = "新兴赛道与生态热点"
+)
+
+// IsFinancing reports whether category is the financing / launch category.
+func IsFinancing(category string) bool {
+	return strings.Contains(category, "融资")
+}
+
+// IsRegulation reports whether category is the regulation / policy category.
+func IsRegulation(category string) bool {
+	return strings.Contains(category, "监管")
+}
+
+// categoryFragments identify the six categories in model output that does
+// not reproduce the names verbatim.
+var categoryFragments = []struct{ fragment, category string }{
+	{"监管", CategoryRegulation},
+	{"TradFi", CategoryInstitutions},
+	{"基础设施的长期路线", CategoryInfrastructure},
+	{"RWA", CategoryRWAPayments},
+	{"融资", CategoryFinancing},
+	{"新兴赛道", CategoryEmerging},
+}
+
+// CanonicalCategory maps a category reported by the model to one of the six
+// category constants, or returns it trimmed when it matches none.
+func CanonicalCategory(category string) string {
+	category = strings.TrimSpace(category)
+	for _, c := range categoryFragments {
+		if strings.Contains(category, c.fragment) {
+			return c.category
+		}
+	}
+	return category
+}
+
+// categoryEnglish names the categories for English readers, keyed by a
+// fragment of the Chinese name.
+var categoryEnglish = []struct{ fragment, name string }{
+	{"监管", "Regulation & Policy"},
+	{"TradFi", "Institutions & TradFi"},
+	{"基础设施的长期路线", "Core Infrastructure"},
+	{"RWA", "RWA, Stablecoins & Payments"},
+	{"融资", "Financing & Launches"},
+	{"新兴赛道", "Emerging Sectors"},
+}
+
+// CategoryEnglish returns the English name of a category, or the category
+// itself when it is not one of the six.
+func CategoryEnglish(category string) string {
+	for _, c := range categoryEnglish {
+		if strings.Contains(category, c.fragment) {
+			return c.name
+		}
+	}
+	return category
+}
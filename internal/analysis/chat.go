@@ -0,0 +1,89 @@
+package analysis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Purposes label model calls for auditing.
+const (
+	PurposeClassification = "classification"
+	PurposeExtraction     = "extraction"
+)
+
+// chat sends a chat completion and returns the raw reply together with the
+// metadata needed to audit the call. Meta is filled as far as the call got,
+// even when an error is returned.
+func (c *Client) chat(ctx context.Context, purpose, promptVersion string, messages []openai.ChatCompletionMessage) (string, Meta, error) {
+	meta := Meta{
+		Purpose:       purpose,
+		Model:         c.model,
+		PromptVersion: promptVersion,
+		Prompt:        renderMessages(messages),
+	}
+	req := openai.ChatCompletionRequest{
+		Model:       c.model,
+		Messages:    messages,
+		Temperature: 0.2,
+	}
+	ctx, retryAfter := withRetryAfter(ctx)
+	start := time.Now()
+	resp, err := c.client.CreateChatCompletion(ctx, req)
+	meta.Latency = time.Since(start)
+	if r, ok := c.client.(LatencyReporter); ok {
+		if d, ok := r.ChatLatency(req); ok {
+			meta.Latency = d
+		}
+	}
+	if err != nil {
+		return "", meta, providerError(err, *retryAfter)
+	}
+	meta.PromptTokens = resp.Usage.PromptTokens
+	meta.CompletionTokens = resp.Usage.CompletionTokens
+	meta.TotalTokens = resp.Usage.TotalTokens
+	if len(resp.Choices) == 0 {
+		return "", meta, errors.New("no choices returned by OpenAI")
+	}
+	meta.RawResponse = resp.Choices[0].Message.Content
+	meta.FinishReason = string(resp.Choices[0].FinishReason)
+	return meta.RawResponse, meta, nil
+}
+
+// renderMessages serializes the prompt exactly as it was sent.
+func renderMessages(messages []openai.ChatCompletionMessage) string {
+	type message struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+	out := make([]message, 0, len(messages))
+	for _, m := range messages {
+		out = append(out, message{Role: m.Role, Content: m.Content})
+	}
+	b, _ := json.Marshal(out)
+	return string(b)
+}
+
+// completeJSON runs an item-level extraction prompt and decodes the JSON reply into out.
+func (c *Client) completeJSON(ctx context.Context, purpose, promptVersion, systemPrompt, userPrompt string, out any) (Meta, error) {
+	if !c.Ready() {
+		return Meta{}, errDisabled
+	}
+	raw, meta, err := c.chat(ctx, purpose, promptVersion, []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+		{Role: openai.ChatMessageRoleUser, Content: userPrompt},
+	})
+	if err != nil {
+		return meta, err
+	}
+	content := cleanupResponse(raw)
+	if err := json.Unmarshal([]byte(content), out); err != nil {
+		c.logger.Printf("failed to parse %s response, content=%q, err=%v", purpose, content, err)
+		return meta, fmt.Errorf("parse %s response: %w", purpose, err)
+	}
+	return meta, nil
+}
@@ -0,0 +1,266 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Voting strategies of an Ensemble.
+const (
+	VotingMajority = "majority"
+	VotingWeighted = "weighted"
+)
+
+// Attempt is a model call made while producing a Result other than the one
+// described by Result.Meta, e.g. a failed call before a fallback succeeded.
+type Attempt struct {
+	Meta Meta
+	Err  error
+}
+
+// Vote is one ensemble member's answer.
+type Vote struct {
+	Model      string  `json:"model"`
+	Relevant   bool    `json:"relevant"`
+	Category   string  `json:"category"`
+	Confidence float64 `json:"confidence"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Member is a named Analyzer taking part in a Fallback or Ensemble.
+type Member struct {
+	Name     string
+	Analyzer Analyzer
+}
+
+// Fallback tries its members in order and returns the first successful
+// result. Each attempt is bounded by Timeout when it is positive.
+type Fallback struct {
+	members []Member
+	timeout time.Duration
+	logger  *log.Logger
+}
+
+// NewFallback builds a fallback chain; the first member is the primary.
+func NewFallback(members []Member, timeout time.Duration, logger *log.Logger) *Fallback {
+	return &Fallback{members: members, timeout: timeout, logger: logger}
+}
+
+// Ready reports whether any member is usable.
+func (f *Fallback) Ready() bool {
+	for _, m := range f.members {
+		if m.Analyzer.Ready() {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate implements Analyzer. When every member fails, the last failure
+// is returned with the earlier ones in Attempts; when every member's circuit
+// is open afterwards, the error wraps ErrBreakerOpen so callers wait for
+// the cooldown instead of counting a failure.
+func (f *Fallback) Evaluate(ctx context.Context, item ItemContext) (Result, error) {
+	var attempts []Attempt
+	lastErr := errors.New("no analyzer available")
+	allOpen := true
+	for _, m := range f.members {
+		if !m.Analyzer.Ready() {
+			continue
+		}
+		res, err := evaluateWithTimeout(ctx, m.Analyzer, item, f.timeout)
+		if err == nil {
+			res.Attempts = append(attempts, res.Attempts...)
+			return res, nil
+		}
+		if ctx.Err() != nil {
+			res.Attempts = attempts
+			return res, err
+		}
+		f.logger.Printf("analyzer %s failed, trying next: %v", m.Name, err)
+		attempts = append(attempts, Attempt{Meta: res.Meta, Err: err})
+		lastErr = fmt.Errorf("%s: %w", m.Name, err)
+		allOpen = allOpen && circuitOpen(m.Analyzer, err)
+	}
+	if len(attempts) == 0 {
+		return Result{}, lastErr
+	}
+	if allOpen && !errors.Is(lastErr, ErrBreakerOpen) {
+		lastErr = fmt.Errorf("%w for every analyzer, last failure: %v", ErrBreakerOpen, lastErr)
+	}
+	last := attempts[len(attempts)-1]
+	return Result{Meta: last.Meta, Attempts: attempts[:len(attempts)-1]}, lastErr
+}
+
+// Ensemble asks every member and combines their answers by vote.
+type Ensemble struct {
+	members []Member
+	voting  string
+	timeout time.Duration
+}
+
+// NewEnsemble builds an ensemble; voting is VotingMajority or VotingWeighted.
+func NewEnsemble(members []Member, voting string, timeout time.Duration) (*Ensemble, error) {
+	if len(members) < 2 {
+		return nil, errors.New("ensemble needs at least two members")
+	}
+	if voting != VotingMajority && voting != VotingWeighted {
+		return nil, fmt.Errorf("unknown voting %q", voting)
+	}
+	return &Ensemble{members: members, voting: voting, timeout: timeout}, nil
+}
+
+// Ready reports whether any member is usable.
+func (e *Ensemble) Ready() bool {
+	for _, m := range e.members {
+		if m.Analyzer.Ready() {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate implements Analyzer. The returned result is the answer of the
+// most confident member on the winning side, with Confidence replaced by the
+// winning share of the vote. The other members' calls are in Attempts, every
+// answer is in Votes, and Disagreement is set when members differed on
+// relevance or, among relevant answers, on category.
+//
+// Majority voting counts one vote per member and breaks ties by confidence;
+// weighted voting sums confidences, counting unreported confidence as 0.5.
+func (e *Ensemble) Evaluate(ctx context.Context, item ItemContext) (Result, error) {
+	results := make([]Result, len(e.members))
+	errs := make([]error, len(e.members))
+	var wg sync.WaitGroup
+	for i, m := range e.members {
+		wg.Add(1)
+		go func(i int, m Member) {
+			defer wg.Done()
+			results[i], errs[i] = evaluateWithTimeout(ctx, m.Analyzer, item, e.timeout)
+		}(i, m)
+	}
+	wg.Wait()
+
+	var (
+		votes []Vote
+		ok    []int
+	)
+	for i, m := range e.members {
+		v := Vote{Model: m.Name}
+		if errs[i] != nil {
+			v.Error = errs[i].Error()
+		} else {
+			v.Relevant = results[i].Relevant
+			v.Category = CanonicalCategory(results[i].Category)
+			v.Confidence = results[i].Confidence
+			ok = append(ok, i)
+		}
+		votes = append(votes, v)
+	}
+	if len(ok) == 0 {
+		out := results[0]
+		for i := 1; i < len(results); i++ {
+			out.Attempts = append(out.Attempts, Attempt{Meta: results[i].Meta, Err: errs[i]})
+		}
+		out.Votes = votes
+		return out, fmt.Errorf("all ensemble members failed: %w", errs[0])
+	}
+
+	weight := func(i int) float64 {
+		if e.voting == VotingMajority {
+			return 1
+		}
+		if results[i].HasConfidence {
+			return results[i].Confidence
+		}
+		return 0.5
+	}
+	var yes, no, yesConf, noConf, total float64
+	for _, i := range ok {
+		total += weight(i)
+		if results[i].Relevant {
+			yes += weight(i)
+			yesConf += results[i].Confidence
+		} else {
+			no += weight(i)
+			noConf += results[i].Confidence
+		}
+	}
+	relevant := yes > no || (yes == no && yesConf > noConf)
+
+	categories := map[string]float64{}
+	for _, i := range ok {
+		if results[i].Relevant && relevant {
+			categories[CanonicalCategory(results[i].Category)] += weight(i)
+		}
+	}
+	category := ""
+	if len(categories) > 0 {
+		names := make([]string, 0, len(categories))
+		for c := range categories {
+			names = append(names, c)
+		}
+		sort.Slice(names, func(a, b int) bool {
+			if categories[names[a]] != categories[names[b]] {
+				return categories[names[a]] > categories[names[b]]
+			}
+			return names[a] < names[b]
+		})
+		category = names[0]
+	}
+
+	base := -1
+	for _, i := range ok {
+		if results[i].Relevant != relevant || (relevant && CanonicalCategory(results[i].Category) != category) {
+			continue
+		}
+		if base < 0 || results[i].Confidence > results[base].Confidence {
+			base = i
+		}
+	}
+	out := results[base]
+	out.Category = category
+	if relevant {
+		out.Confidence = yes / total
+	} else {
+		out.Confidence = no / total
+	}
+	out.HasConfidence = true
+	for i := range results {
+		if i != base {
+			out.Attempts = append(out.Attempts, Attempt{Meta: results[i].Meta, Err: errs[i]})
+		}
+	}
+	out.Votes = votes
+	out.Disagreement = len(categories) > 1
+	for _, i := range ok {
+		if results[i].Relevant != relevant {
+			out.Disagreement = true
+		}
+	}
+	return out, nil
+}
+
+// Names lists the member names, for logs.
+func (e *Ensemble) Names() string {
+	names := make([]string, 0, len(e.members))
+	for _, m := range e.members {
+		names = append(names, m.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func evaluateWithTimeout(ctx context.Context, a Analyzer, item ItemContext, timeout time.Duration) (Result, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return a.Evaluate(ctx, item)
+}
@@ -0,0 +1,45 @@
+package analysis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// PurposeEmbedding labels embedding calls for auditing.
+const PurposeEmbedding = "embedding"
+
+// Embed returns one vector per text from the embeddings endpoint of the
+// configured provider. Meta carries the token usage of the call.
+func (c *Client) Embed(ctx context.Context, model string, texts []string) ([][]float32, Meta, error) {
+	if !c.Ready() {
+		return nil, Meta{}, errDisabled
+	}
+	prompt, _ := json.Marshal(texts)
+	meta := Meta{Purpose: PurposeEmbedding, Model: model, Prompt: string(prompt)}
+	start := time.Now()
+	resp, err := c.client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: texts,
+		Model: openai.EmbeddingModel(model),
+	})
+	meta.Latency = time.Since(start)
+	if err != nil {
+		return nil, meta, err
+	}
+	meta.PromptTokens = resp.Usage.PromptTokens
+	meta.TotalTokens = resp.Usage.TotalTokens
+	if len(resp.Data) != len(texts) {
+		return nil, meta, fmt.Errorf("embeddings: got %d vectors for %d inputs", len(resp.Data), len(texts))
+	}
+	vectors := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, meta, fmt.Errorf("embeddings: unexpected index %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, meta, nil
+}
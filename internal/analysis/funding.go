@@ -0,0 +1,42 @@
+package analysis
+
+import "context"
+
+// FundingPromptVersion identifies the funding extraction prompt.
+const FundingPromptVersion = "funding-v1"
+
+const fundingPrompt = `你是一个 Web3 投融资数据助手。阅读资讯并抽取其中的融资事件，只返回 JSON，不要输出任何多余文字。
+
+字段：
+- ` + "`is_funding`" + `：布尔值，资讯是否报道了一笔具体的融资（含 IPO）；否则其余字段留空。
+- ` + "`project`" + `：获得融资的项目或公司名称，英文名优先。
+- ` + "`amount_usd`" + `：融资金额折合美元的数值（如 5000 万美元即 50000000），未披露填 0。
+- ` + "`round`" + `：轮次，如 "Pre-Seed"、"Seed"、"Series A"、"Strategic"、"IPO"，未披露填 ""。
+- ` + "`lead_investors`" + `：领投方名称数组。
+- ` + "`investors`" + `：其他参投方名称数组，不要重复领投方。
+- ` + "`date`" + `：宣布日期，格式 YYYY-MM-DD，未知时使用资讯发布日期。
+- ` + "`sector`" + `：赛道，仅限 "交易所"、"RWA"、"稳定币"、"支付"、"预测市场"、"DeFi"、"基础设施"、"AI"、"合规"、"其他" 之一。
+`
+
+// Funding is a structured financing event extracted from an item.
+type Funding struct {
+	IsFunding     bool     `json:"is_funding"`
+	Project       string   `json:"project"`
+	AmountUSD     float64  `json:"amount_usd"`
+	Round         string   `json:"round"`
+	LeadInvestors []string `json:"lead_investors"`
+	Investors     []string `json:"investors"`
+	Date          string   `json:"date"`
+	Sector        string   `json:"sector"`
+
+	Meta Meta `json:"-"`
+}
+
+// ExtractFunding asks the model for the financing details of an item. Callers
+// should only use it for items classified as financing.
+func (c *Client) ExtractFunding(ctx context.Context, item ItemContext) (Funding, error) {
+	var out Funding
+	meta, err := c.completeJSON(ctx, PurposeExtraction, FundingPromptVersion, fundingPrompt, renderItem(item), &out)
+	out.Meta = meta
+	return out, err
+}
@@ -0,0 +1,81 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultPromptVersion is the classification prompt used unless another
+// version is requested. Add a new version whenever the system prompt or the
+// expected output changes so analyses made with different prompts can be compared.
+const DefaultPromptVersion = "v4"
+
+// criteriaPrompt describes what counts as relevant. It is shared by all prompt versions.
+const criteriaPrompt = "你是一个 Web3 资讯分析助手，风格参考“机构级 Web3 与金融科技融合”的资深投研分析师的人工筛选偏好。\n你的目标是从大量新闻中挑出具有中长期行业意义的结构性事件，而不是短期价格噪音或单一项目营销。\n\n一、判断资讯是否“重要”（relevant）\n\n仅当满足以下至少一项时，认为 `relevant=true`，否则为 `false`：\n\n1. 监管 / 政策 / 官方试点  \n   - 国家级或重要金融监管机构发布、通过、更新与加密资产 / 稳定币 / 代币化 / 交易平台相关的法规、牌照框架、监管指引或试点计划。  \n   - 典型主体：要经济体（美、港、新、欧、日、俄）政府或监管机构（如 SEC, 金管局, 央行）等。\n\n2. 主流机构 & TradFi 深度参与  \n   - 大型银行、支付巨头、互联网巨头、国际组织与加密行业达成合作、上线相关产品或实质使用区块链 / 稳定币 / 代币化资产。  \n   - 如：JPMorgan、PayPal、Stripe、Visa、Mastercard、Google、Cloudflare、Volkswagen 等。\n\n3. 公链 / 核心基础设施的长期路线或重大升级  \n   - 以太坊、Solana 等主流公链基金会或核心团队公布中长期路线图、性能目标、关键协议升级、或新型基础设施平台（如分布式账本、结算平台）。\n\n4. RWA、稳定币与支付基础设施  \n   - 现实世界资产（基金、国债、货币市场基金、股票等）上链或代币化的重大里程碑。  \n   - 稳定币、跨链/多链流动性、支付网络、订阅/结算方案、可编程支付、银行负债代币化等基础设施落地或监管突破。\n\n5. 大额融资或标志性项目发布  \n   - 金额较大的融资（通常 ≥ 500 万美元）或IPO相关且方向为：  \n     - 交易所、RWA、稳定币、支付网络、预测市场、机构 DeFi、合规基础设施、与金融 / Web3相关的 AI 平台等。  \n   - 或头部机构（如顶级 VC、大型银行/支付公司、主流公链基金会）领投/参投。  \n   - 重要项目/平台正式发布或明确上线时间表，且方向同上。\n\n6. 新兴赛道与生态热点  \n   - 具有明显“新模式”或“新市场”的项目/路线图，如预测市场、互联网资本市场、代币化、稳定币支付网络、AI+Web3、合规/风控基础设施等，且有一定规模或机构背书。\n\n以下通常视为不重要（relevant=false）：\n- 单一代币/项目的小额融资，且无明显 RWA / 支付 / 机构 / 监管属性。  \n- 单一交易所上币、期货合约上架、常规功能迭代。  \n- 单纯的代币价格波动或行情分析。\n- KOL 观点、空投/活动公告、单纯营销合作。  \n- 一般安全事件/黑客攻击（除非引发监管框架或机构行为变化）。\n- 娱乐性强但无金融实质的 GameFi 或 Meme 币资讯。\n\n"
+
+// The output-format section of each prompt version is assembled from the
+// field descriptions and example lines below, so a wording fix applies to
+// every version that shares the fragment.
+const (
+	outputHeader = "二、输出格式\n\n只返回 JSON，不要输出任何多余文字。\n\n字段：\n"
+	exampleIntro = "\n例如（仅示意，不要在真实回答里解释该示例）：\n\n{\n"
+
+	fieldBasics = "- `relevant`：布尔值，表示是否符合上述重要性标准。    \n" +
+		"- `category`：字符串，仅限上述 6个类别中的一项（仅在 `relevant=true` 时有意义；如果 `relevant=false`，可置为 `\"\"`）。 \n" +
+		"- `reason`：简要中文理由，说明你判定的重要性与类别依据。  \n" +
+		"- `tags`：字符串数组，包含涉及的链 / 机构 / 赛道，例如：`[\"Ethereum\",\"Solana\",\"RWA\",\"稳定币\",\"PayPal\",\"预测市场\"]`。\n"
+	fieldScores = "- `importance`：1-10 的整数，表示事件的中长期行业影响；10 为里程碑级（如主要经济体通过稳定币立法），5 为值得关注的常规进展，1 为几乎没有影响。不相关资讯通常不超过 3。\n" +
+		"- `confidence`：0 到 1 的小数，表示你对 `relevant` 与 `category` 判断的把握程度；信息不足或处于标准边缘时应低于 0.6。\n"
+	fieldEntities = "- `entities`：对象数组，列出资讯中出现的实体，每项包含 `type` 与 `name`：\n" +
+		"  - `type` 仅限 `organization`（公司/机构/监管部门）、`person`、`jurisdiction`（国家或地区）、`chain`（公链/L2）、`token`（代币或股票代码）、`protocol`（协议/产品）、`amount`（金额）。\n" +
+		"  - `name` 使用最常用的正式名称，英文优先（如 `Stripe`、`Hong Kong Monetary Authority`），代币使用代码（如 `USDC`）。\n" +
+		"  - `amount` 类型额外给出 `amount`（数值，按原币种换算成个位，如 5000 万即 50000000）与 `currency`（ISO 代码，如 `USD`、`HKD`），`name` 填原文表述。\n"
+	fieldSecurity = "- `security_incident`：布尔值，资讯是否报道了具体的安全事件（黑客攻击、漏洞利用、私钥泄露、钓鱼、跑路等造成资金损失的事件）。该字段与 `relevant` 独立判断，安全事件即使不重要也要标记为 true。\n"
+
+	exampleBasics = "  \"relevant\": true,\n" +
+		"  \"category\": \"RWA、稳定币与支付基础设施\",\n" +
+		"  \"reason\": \"大型支付机构推出基于稳定币的订阅支付功能，强化稳定币在跨境结算和经常性支付中的应用，属于支付基础设施重大进展。\",\n" +
+		"  \"tags\": [\"稳定币\", \"支付\", \"USDC\", \"Stripe\", \"Base\", \"Polygon\"]"
+	exampleScores   = "  \"importance\": 7,\n  \"confidence\": 0.85"
+	exampleSecurity = "  \"security_incident\": false"
+	exampleEntities = "  \"entities\": [\n" +
+		"    {\"type\": \"organization\", \"name\": \"Stripe\"},\n" +
+		"    {\"type\": \"token\", \"name\": \"USDC\"},\n" +
+		"    {\"type\": \"chain\", \"name\": \"Base\"},\n" +
+		"    {\"type\": \"amount\", \"name\": \"1 亿美元\", \"amount\": 100000000, \"currency\": \"USD\"}\n" +
+		"  ]"
+)
+
+// outputPrompt renders an output-format section from field descriptions and
+// the members of the example object.
+func outputPrompt(fields []string, example []string) string {
+	return outputHeader + strings.Join(fields, "") + exampleIntro + strings.Join(example, ",\n") + "\n}\n"
+}
+
+// outputPrompts holds the output-format section of each prompt version.
+var outputPrompts = map[string]string{
+	"v1": outputPrompt(
+		[]string{fieldBasics},
+		[]string{exampleBasics}),
+	// v2 adds importance and confidence.
+	"v2": outputPrompt(
+		[]string{fieldBasics, fieldScores},
+		[]string{exampleBasics, exampleScores}),
+	// v3 adds typed entities.
+	"v3": outputPrompt(
+		[]string{fieldBasics, fieldScores, fieldEntities},
+		[]string{exampleBasics, exampleScores, exampleEntities}),
+	// v4 flags security incidents independently of relevance.
+	"v4": outputPrompt(
+		[]string{fieldBasics, fieldScores, fieldEntities, fieldSecurity},
+		[]string{exampleBasics, exampleScores, exampleSecurity, exampleEntities}),
+}
+
+// SystemPrompt renders the classification system prompt for a version.
+func SystemPrompt(version string) (string, error) {
+	output, ok := outputPrompts[version]
+	if !ok {
+		return "", fmt.Errorf("unknown prompt version %q", version)
+	}
+	return criteriaPrompt + output, nil
+}
@@ -0,0 +1,103 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ProviderError is a model call the provider rejected with an HTTP status.
+// RetryAfter carries the provider's Retry-After header, when it sent one.
+type ProviderError struct {
+	StatusCode int
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *ProviderError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%v (retry after %s)", e.Err, e.RetryAfter)
+	}
+	return e.Err.Error()
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// RateLimited reports whether the provider asked us to slow down.
+func (e *ProviderError) RateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// Unavailable reports a server side failure that should be retried later.
+func (e *ProviderError) Unavailable() bool {
+	return e.StatusCode >= http.StatusInternalServerError
+}
+
+// retryAfterKey holds the *time.Duration a call's Retry-After header is written to.
+type retryAfterKey struct{}
+
+// retryAfterTransport records the Retry-After header of throttled responses
+// into the request context, since the OpenAI client does not expose headers
+// of failed calls.
+type retryAfterTransport struct {
+	next http.RoundTripper
+}
+
+func (t retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable) {
+		return resp, err
+	}
+	if dst, ok := req.Context().Value(retryAfterKey{}).(*time.Duration); ok {
+		*dst = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	}
+	return resp, nil
+}
+
+// parseRetryAfter accepts delay seconds or an HTTP date.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// withRetryAfter prepares ctx for a call whose Retry-After header should be
+// captured and returns where it will be stored.
+func withRetryAfter(ctx context.Context) (context.Context, *time.Duration) {
+	d := new(time.Duration)
+	return context.WithValue(ctx, retryAfterKey{}, d), d
+}
+
+// providerError wraps err in a *ProviderError when the provider answered
+// with an HTTP error status.
+func providerError(err error, retryAfter time.Duration) error {
+	var (
+		apiErr *openai.APIError
+		reqErr *openai.RequestError
+		status int
+	)
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		status = reqErr.HTTPStatusCode
+	}
+	if status == 0 {
+		return err
+	}
+	return &ProviderError{StatusCode: status, RetryAfter: retryAfter, Err: err}
+}
@@ -0,0 +1,130 @@
+package analysis
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ProviderReplay marks clients answering from recorded responses.
+const ProviderReplay = "replay"
+
+// ErrNotRecorded is returned by Replay for requests missing from the recording.
+var ErrNotRecorded = errors.New("no recorded response for request")
+
+// Recording is one chat completion captured by Recorder, stored as a JSONL line.
+type Recording struct {
+	Key       string                        `json:"key"`
+	Model     string                        `json:"model"`
+	LatencyMS int64                         `json:"latency_ms"`
+	Response  openai.ChatCompletionResponse `json:"response"`
+}
+
+// RequestKey identifies a chat request by its model and messages, so a
+// recording can be replayed by any client sending the same prompt.
+func RequestKey(req openai.ChatCompletionRequest) string {
+	h := sha256.New()
+	h.Write([]byte(req.Model))
+	for _, m := range req.Messages {
+		h.Write([]byte{0})
+		h.Write([]byte(m.Role))
+		h.Write([]byte{0})
+		h.Write([]byte(m.Content))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Recorder is a Backend that forwards to another Backend and appends every
+// successful chat completion to w. It is safe for concurrent use.
+type Recorder struct {
+	next Backend
+	mu   sync.Mutex
+	enc  *json.Encoder
+}
+
+// NewRecorder wraps next, writing recordings to w.
+func NewRecorder(next Backend, w io.Writer) *Recorder {
+	return &Recorder{next: next, enc: json.NewEncoder(w)}
+}
+
+// CreateChatCompletion implements Backend.
+func (r *Recorder) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	start := time.Now()
+	resp, err := r.next.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(Recording{Key: RequestKey(req), Model: req.Model, LatencyMS: time.Since(start).Milliseconds(), Response: resp}); err != nil {
+		return resp, fmt.Errorf("record response: %w", err)
+	}
+	return resp, nil
+}
+
+// CreateEmbeddings implements Backend without recording.
+func (r *Recorder) CreateEmbeddings(ctx context.Context, conv openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	return r.next.CreateEmbeddings(ctx, conv)
+}
+
+// Replay is an offline Backend answering chat completions from recordings.
+type Replay struct {
+	recordings map[string]Recording
+}
+
+// LoadReplay reads recordings written by Recorder. Later lines win when a
+// request was recorded more than once.
+func LoadReplay(r io.Reader) (*Replay, error) {
+	out := &Replay{recordings: make(map[string]Recording)}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var rec Recording
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("recording line %d: %w", line, err)
+		}
+		out.recordings[rec.Key] = rec
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read recordings: %w", err)
+	}
+	return out, nil
+}
+
+// Len returns the number of recorded requests.
+func (r *Replay) Len() int {
+	return len(r.recordings)
+}
+
+// CreateChatCompletion implements Backend.
+func (r *Replay) CreateChatCompletion(_ context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	rec, ok := r.recordings[RequestKey(req)]
+	if !ok {
+		return openai.ChatCompletionResponse{}, ErrNotRecorded
+	}
+	return rec.Response, nil
+}
+
+// CreateEmbeddings implements Backend; embeddings are not recorded.
+func (r *Replay) CreateEmbeddings(context.Context, openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	return openai.EmbeddingResponse{}, ErrNotRecorded
+}
+
+// ChatLatency implements LatencyReporter with the latency observed when req
+// was recorded, so replayed evaluations report the original timings.
+func (r *Replay) ChatLatency(req openai.ChatCompletionRequest) (time.Duration, bool) {
+	rec, ok := r.recordings[RequestKey(req)]
+	return time.Duration(rec.LatencyMS) * time.Millisecond, ok
+}
@@ -0,0 +1,56 @@
+package analysis
+
+import "context"
+
+// RegulationPromptVersion identifies the regulatory event extraction prompt.
+const RegulationPromptVersion = "regulation-v1"
+
+// Instrument types of a regulatory action.
+const (
+	InstrumentLaw          = "law"
+	InstrumentGuidance     = "guidance"
+	InstrumentLicense      = "license"
+	InstrumentConsultation = "consultation"
+	InstrumentEnforcement  = "enforcement"
+)
+
+// Stages of a regulatory action.
+const (
+	StageProposed  = "proposed"
+	StagePassed    = "passed"
+	StageEffective = "effective"
+)
+
+const regulationPrompt = `你是一个 Web3 合规研究助手。阅读资讯并抽取其中的监管或政策事件，只返回 JSON，不要输出任何多余文字。
+
+字段：
+- ` + "`is_regulatory`" + `：布尔值，资讯是否报道了具体的监管、立法、牌照、征求意见或执法行动；否则其余字段留空。
+- ` + "`jurisdiction`" + `：司法辖区，使用 ISO 国家/地区代码，如 "US"、"HK"、"SG"、"JP"、"GB"，欧盟层面使用 "EU"；国际组织（如 FSB、BIS）填 "INTL"。
+- ` + "`regulator`" + `：发布或执行的机构，使用正式英文名称，如 "SEC"、"Hong Kong Monetary Authority"；立法机构同样适用。
+- ` + "`instrument`" + `：类型，仅限 "law"（法律法规）、"guidance"（指引/声明）、"license"（牌照/许可）、"consultation"（征求意见/试点方案）、"enforcement"（执法/处罚/诉讼）之一。
+- ` + "`stage`" + `：进展阶段，仅限 "proposed"（提出/征询中）、"passed"（已通过/已发布但未生效）、"effective"（已生效/已执行）之一。
+- ` + "`effective_date`" + `：生效日期，格式 YYYY-MM-DD，未提及填 ""。
+- ` + "`summary`" + `：一句话中文概述该监管行动。
+`
+
+// Regulation is a structured regulatory action extracted from an item.
+type Regulation struct {
+	IsRegulatory  bool   `json:"is_regulatory"`
+	Jurisdiction  string `json:"jurisdiction"`
+	Regulator     string `json:"regulator"`
+	Instrument    string `json:"instrument"`
+	Stage         string `json:"stage"`
+	EffectiveDate string `json:"effective_date"`
+	Summary       string `json:"summary"`
+
+	Meta Meta `json:"-"`
+}
+
+// ExtractRegulation asks the model for the regulatory details of an item.
+// Callers should only use it for items classified as regulation.
+func (c *Client) ExtractRegulation(ctx context.Context, item ItemContext) (Regulation, error) {
+	var out Regulation
+	meta, err := c.completeJSON(ctx, PurposeExtraction, RegulationPromptVersion, regulationPrompt, renderItem(item), &out)
+	out.Meta = meta
+	return out, err
+}
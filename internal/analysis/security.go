@@ -0,0 +1,58 @@
+package analysis
+
+import "context"
+
+// SecurityPromptVersion identifies the security incident extraction prompt.
+const SecurityPromptVersion = "security-v1"
+
+// Attack vectors of a security incident.
+const (
+	VectorContractExploit    = "smart_contract_exploit"
+	VectorPrivateKey         = "private_key_compromise"
+	VectorOracleManipulation = "oracle_manipulation"
+	VectorBridge             = "bridge_exploit"
+	VectorPhishing           = "phishing"
+	VectorRugPull            = "rug_pull"
+	VectorInsider            = "insider"
+	VectorOther              = "other"
+)
+
+// AttackVectors lists every attack vector the extraction prompt may return.
+var AttackVectors = []string{VectorContractExploit, VectorPrivateKey, VectorOracleManipulation, VectorBridge,
+	VectorPhishing, VectorRugPull, VectorInsider, VectorOther}
+
+const securityPrompt = `你是一个 Web3 安全事件数据助手。阅读资讯并抽取其中的安全事件，只返回 JSON，不要输出任何多余文字。
+
+字段：
+- ` + "`is_incident`" + `：布尔值，资讯是否报道了一起具体的安全事件（黑客攻击、漏洞利用、私钥泄露、钓鱼、跑路等）；否则其余字段留空。
+- ` + "`protocol`" + `：受害项目、协议或交易所名称，英文名优先。
+- ` + "`chain`" + `：事件发生的公链，如 "Ethereum"、"BNB Chain"、"Solana"，跨多条链时填主要的一条，未知填 ""。
+- ` + "`loss_usd`" + `：损失金额折合美元的数值（如 1200 万美元即 12000000），未披露填 0。
+- ` + "`attack_vector`" + `：攻击方式，仅限 "smart_contract_exploit"（合约漏洞，含闪电贷）、"private_key_compromise"（私钥/多签泄露）、"oracle_manipulation"（预言机操纵）、"bridge_exploit"（跨链桥）、"phishing"（钓鱼/社工）、"rug_pull"（项目方跑路）、"insider"（内部人员）、"other" 之一。
+- ` + "`recovered_usd`" + `：已追回或冻结的金额折合美元，未追回填 0。
+- ` + "`date`" + `：事件发生日期，格式 YYYY-MM-DD，未知时使用资讯发布日期。
+- ` + "`summary`" + `：一句话中文概述事件经过。
+`
+
+// Security is a structured security incident extracted from an item.
+type Security struct {
+	IsIncident   bool    `json:"is_incident"`
+	Protocol     string  `json:"protocol"`
+	Chain        string  `json:"chain"`
+	LossUSD      float64 `json:"loss_usd"`
+	AttackVector string  `json:"attack_vector"`
+	RecoveredUSD float64 `json:"recovered_usd"`
+	Date         string  `json:"date"`
+	Summary      string  `json:"summary"`
+
+	Meta Meta `json:"-"`
+}
+
+// ExtractSecurity asks the model for the details of a security incident.
+// Callers should only use it for items flagged as security incidents.
+func (c *Client) ExtractSecurity(ctx context.Context, item ItemContext) (Security, error) {
+	var out Security
+	meta, err := c.completeJSON(ctx, PurposeExtraction, SecurityPromptVersion, securityPrompt, renderItem(item), &out)
+	out.Meta = meta
+	return out, err
+}
@@ -0,0 +1,111 @@
+package analysis
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"math"
+	"regexp"
+	"strconv"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Providers selectable with LLM_PROVIDER.
+const (
+	ProviderOpenAI = "openai"
+	ProviderStub   = "stub"
+)
+
+// stubDim is the size of stub embedding vectors.
+const stubDim = 64
+
+// sourceRef matches the "[n]" source labels of the ask prompt.
+var sourceRef = regexp.MustCompile(`(?m)^\[(\d+)\]`)
+
+// Stub is an offline Backend with deterministic replies. Every chat reply is
+// one JSON object carrying the fields of all prompts with neutral values, so
+// any prompt parses: items are irrelevant, nothing is extracted, and answers
+// cite the first sources they were given.
+type Stub struct{}
+
+// CreateChatCompletion implements Backend.
+func (Stub) CreateChatCompletion(_ context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	var user string
+	for _, m := range req.Messages {
+		if m.Role == openai.ChatMessageRoleUser {
+			user = m.Content
+		}
+	}
+
+	citations := []int{}
+	answer := "（stub）资料不足，无法回答。"
+	for _, m := range sourceRef.FindAllStringSubmatch(user, 2) {
+		n, _ := strconv.Atoi(m[1])
+		citations = append(citations, n)
+	}
+	if len(citations) > 0 {
+		answer = "（stub）相关进展见所引资讯"
+		for _, n := range citations {
+			answer += " [" + strconv.Itoa(n) + "]"
+		}
+		answer += "。"
+	}
+
+	reply, _ := json.Marshal(map[string]any{
+		"relevant":          false,
+		"category":          "",
+		"reason":            "stub provider",
+		"summary_zh":        "（stub）摘要。",
+		"title_en":          "(stub) title",
+		"summary_en":        "(stub) summary.",
+		"tags":              []string{},
+		"importance":        1,
+		"confidence":        1,
+		"entities":          []any{},
+		"security_incident": false,
+		"is_funding":        false,
+		"is_regulatory":     false,
+		"is_incident":       false,
+		"answer":            answer,
+		"citations":         citations,
+	})
+	promptTokens := len(user) / 4
+	completionTokens := len(reply) / 4
+	return openai.ChatCompletionResponse{
+		Model: req.Model,
+		Choices: []openai.ChatCompletionChoice{{
+			Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: string(reply)},
+			FinishReason: openai.FinishReasonStop,
+		}},
+		Usage: openai.Usage{PromptTokens: promptTokens, CompletionTokens: completionTokens, TotalTokens: promptTokens + completionTokens},
+	}, nil
+}
+
+// CreateEmbeddings implements Backend with hashed byte trigrams.
+func (Stub) CreateEmbeddings(_ context.Context, conv openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	req := conv.Convert()
+	inputs, _ := req.Input.([]string)
+	resp := openai.EmbeddingResponse{Model: req.Model}
+	for i, text := range inputs {
+		vec := make([]float32, stubDim)
+		for j := 0; j+3 <= len(text); j++ {
+			h := fnv.New32a()
+			_, _ = h.Write([]byte(text[j : j+3]))
+			vec[h.Sum32()%stubDim]++
+		}
+		var norm float64
+		for _, x := range vec {
+			norm += float64(x) * float64(x)
+		}
+		if norm > 0 {
+			for k := range vec {
+				vec[k] /= float32(math.Sqrt(norm))
+			}
+		}
+		resp.Data = append(resp.Data, openai.Embedding{Object: "embedding", Embedding: vec, Index: i})
+		resp.Usage.PromptTokens += len(text) / 4
+	}
+	resp.Usage.TotalTokens = resp.Usage.PromptTokens
+	return resp, nil
+}
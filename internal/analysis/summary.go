@@ -0,0 +1,35 @@
+package analysis
+
+import "context"
+
+// SummaryPromptVersion identifies the bilingual summary prompt. Bump it to
+// have stored summaries regenerated.
+const SummaryPromptVersion = "summary-v1"
+
+// PurposeSummary labels summarization calls for auditing.
+const PurposeSummary = "summary"
+
+const summaryPrompt = `你是一个 Web3 资讯编辑，为中英文读者撰写摘要。只返回 JSON，不要输出任何多余文字。
+
+字段：
+- ` + "`summary_zh`" + `：2-3 句中文摘要，说明发生了什么、涉及哪些主体、为何重要，不要照抄原文。
+- ` + "`title_en`" + `：准确、简洁的英文标题，机构和项目使用通用英文名称。
+- ` + "`summary_en`" + `：与中文摘要对应的 2-3 句英文摘要。
+`
+
+// Summary holds the generated summaries of an item.
+type Summary struct {
+	SummaryZH string `json:"summary_zh"`
+	TitleEN   string `json:"title_en"`
+	SummaryEN string `json:"summary_en"`
+
+	Meta Meta `json:"-"`
+}
+
+// Summarize writes a short Chinese summary plus an English title and summary.
+func (c *Client) Summarize(ctx context.Context, item ItemContext) (Summary, error) {
+	var out Summary
+	meta, err := c.completeJSON(ctx, PurposeSummary, SummaryPromptVersion, summaryPrompt, renderItem(item), &out)
+	out.Meta = meta
+	return out, err
+}
@@ -0,0 +1,57 @@
+package cluster
+
+// Candidate is a recently stored item a new item may be grouped with.
+type Candidate struct {
+	ItemID  int64
+	StoryID int64
+	Title   string
+	SimHash uint64
+	// Vector is the candidate's embedding when available.
+	Vector []float32
+}
+
+// Matcher decides whether two items report the same event.
+type Matcher struct {
+	// MaxDistance is the largest SimHash distance still treated as the same story.
+	MaxDistance int
+	// MinTitleSimilarity is the title shingle Jaccard above which items match
+	// even when their summaries differ too much for SimHash.
+	MinTitleSimilarity float64
+	// MinEmbeddingSimilarity matches by cosine of unit-length embeddings;
+	// zero disables it.
+	MinEmbeddingSimilarity float64
+}
+
+// Best returns the closest candidate matching the item, if any. vec may be
+// nil when the item has no embedding.
+func (m Matcher) Best(title string, fp uint64, vec []float32, candidates []Candidate) (Candidate, bool) {
+	var (
+		best      Candidate
+		bestScore = -1.0
+	)
+	for _, c := range candidates {
+		d := Distance(fp, c.SimHash)
+		sim := Jaccard(title, c.Title)
+		semantic := m.MinEmbeddingSimilarity > 0 && cosine(vec, c.Vector) >= m.MinEmbeddingSimilarity
+		if d > m.MaxDistance && sim < m.MinTitleSimilarity && !semantic {
+			continue
+		}
+		// Rank by combined closeness so an exact duplicate beats a loose title match.
+		score := sim + 1 - float64(d)/64
+		if score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best, bestScore >= 0
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
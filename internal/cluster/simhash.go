@@ -0,0 +1,118 @@
+// Package cluster detects reports of the same event using locality sensitive
+// fingerprints computed in process, without any external service.
+package cluster
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// shingleSize is the number of runes per feature. Three runes capture
+// Chinese words and short English fragments alike.
+const shingleSize = 3
+
+// maxSummaryRunes caps how much of the summary contributes to a fingerprint
+// so long bodies do not drown out the headline.
+const maxSummaryRunes = 300
+
+// Fingerprint returns the 64-bit SimHash of an item. The title is counted
+// twice because it carries most of the event identity.
+func Fingerprint(title, summary string) uint64 {
+	var weights [64]int
+	add := func(text string, weight int) {
+		for _, sh := range Shingles(text) {
+			h := fnv.New64a()
+			_, _ = h.Write([]byte(sh))
+			sum := h.Sum64()
+			for i := 0; i < 64; i++ {
+				if sum&(1<<uint(i)) != 0 {
+					weights[i] += weight
+				} else {
+					weights[i] -= weight
+				}
+			}
+		}
+	}
+	add(title, 2)
+	add(truncateRunes(summary, maxSummaryRunes), 1)
+
+	var fp uint64
+	for i, w := range weights {
+		if w > 0 {
+			fp |= 1 << uint(i)
+		}
+	}
+	return fp
+}
+
+// Distance is the Hamming distance between two fingerprints.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Shingles splits normalized text into overlapping rune n-grams.
+func Shingles(text string) []string {
+	runes := normalize(text)
+	if len(runes) == 0 {
+		return nil
+	}
+	if len(runes) < shingleSize {
+		return []string{string(runes)}
+	}
+	out := make([]string, 0, len(runes)-shingleSize+1)
+	for i := 0; i+shingleSize <= len(runes); i++ {
+		out = append(out, string(runes[i:i+shingleSize]))
+	}
+	return out
+}
+
+// Jaccard compares the shingle sets of two texts, 0 to 1.
+func Jaccard(a, b string) float64 {
+	return SetJaccard(ShingleSet(a), ShingleSet(b))
+}
+
+// ShingleSet returns the distinct shingles of text, for callers comparing
+// one text against many.
+func ShingleSet(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, s := range Shingles(text) {
+		set[s] = true
+	}
+	return set
+}
+
+// SetJaccard compares two shingle sets, 0 to 1.
+func SetJaccard(setA, setB map[string]bool) float64 {
+	if len(setA) == 0 || len(setB) == 0 {
+		return 0
+	}
+	inter := 0
+	for s := range setA {
+		if setB[s] {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(setA)+len(setB)-inter)
+}
+
+// normalize lower-cases text and keeps only letters and digits, so
+// punctuation and spacing differences between outlets do not matter.
+func normalize(text string) []rune {
+	var out []rune
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -19,6 +20,47 @@ const (
 	defaultDBUser       = "root"
 	defaultDBPass       = "123456"
 	defaultDBName       = "aiweb3news"
+
+	defaultNotifyWebhookURL      = "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=74cb55e7-0430-400a-b3e2-2e8d05d8cb06"
+	defaultReviewConfidenceBelow = 0.6
+
+	defaultEmbeddingModel = "text-embedding-3-small"
+
+	defaultEmbeddingIndexDays           = 365
+	defaultEmbeddingIndexRefreshMinutes = 60
+
+	defaultClusterWindowHours     = 48
+	defaultClusterMaxDistance     = 12
+	defaultClusterTitleSimilarity = 0.4
+	defaultClusterEmbeddingSim    = 0.9
+
+	defaultTrendWindowDays      = 7
+	defaultTrendBaselineWindows = 4
+	defaultTrendMinCount        = 5
+	defaultTrendMinRatio        = 2
+	defaultTrendMinZScore       = 3
+	defaultTrendIntervalHours   = 6
+	// defaultTrendAlertRetentionDays keeps trend alert records for dedup.
+	defaultTrendAlertRetentionDays = 90
+
+	defaultLLMTimeoutSeconds = 60
+
+	defaultLLMBreakerFailures        = 5
+	defaultLLMPromptPrice            = 2.5
+	defaultLLMCompletionPrice        = 10
+	defaultLLMBreakerCooldownSeconds = 60
+	defaultLLMBackoffBaseSeconds     = 5
+	defaultLLMBackoffMaxSeconds      = 600
+
+	defaultAnalysisCacheTTLHours = 168
+
+	defaultPrefilterThreshold = 10
+
+	defaultFewShotExamples = 3
+	defaultFewShotMaxChars = 1500
+
+	defaultRetentionIntervalHours = 24
+	defaultRetentionArchiveDir    = "archive"
 )
 
 // Config holds runtime configuration loaded from environment variables.
@@ -29,16 +71,140 @@ type Config struct {
 	OpenAIKey    string
 	OpenAIModel  string
 	OpenAIBase   string
+	// LLMProvider is "openai" or "stub" (deterministic offline replies).
+	LLMProvider string
+	// LLMStubAllowed lets the pipeline store and push stub placeholders.
+	LLMStubAllowed bool
+	// LLMFallback lists models tried in order when the primary model fails.
+	LLMFallback []ModelSpec
+	// LLMEnsemble, when it lists two or more models, classifies every item
+	// with all of them and combines the answers with LLMEnsembleVoting.
+	LLMEnsemble       []ModelSpec
+	LLMEnsembleVoting string
+	// LLMTimeout bounds each classification call of a fallback chain or ensemble.
+	LLMTimeout time.Duration
+	// EnsembleReviewDisagreements holds items the ensemble disagreed on for review.
+	EnsembleReviewDisagreements bool
+	// LLMPricesFile overrides or extends the built-in price table (USD per
+	// million tokens by model).
+	LLMPricesFile string
+	// LLMDefaultPromptPrice and LLMDefaultCompletionPrice price models
+	// missing from the table (USD per million tokens). They default to the
+	// gpt-4o price, the highest in the built-in table, so an unknown model is
+	// charged against the budget instead of running for free.
+	LLMDefaultPromptPrice     float64
+	LLMDefaultCompletionPrice float64
+	// LLMDailyBudget pauses non-critical model calls once the day's cost in
+	// USD reaches it; 0 disables the budget.
+	LLMDailyBudget float64
+	// LLMBreaker wraps classification calls in a circuit breaker that opens
+	// after LLMBreakerFailures consecutive provider failures for
+	// LLMBreakerCooldown, and after a 429 for the provider's Retry-After or a
+	// jittered exponential backoff between LLMBackoffBase and LLMBackoffMax.
+	LLMBreaker         bool
+	LLMBreakerFailures int
+	LLMBreakerCooldown time.Duration
+	LLMBackoffBase     time.Duration
+	LLMBackoffMax      time.Duration
+	// AnalysisCache answers classifications of already seen content (same
+	// normalized title and summary, model and prompt version) from the
+	// database for AnalysisCacheTTL.
+	AnalysisCache    bool
+	AnalysisCacheTTL time.Duration
+	// LLMEndpoints holds the credentials of the named providers used by
+	// LLMFallback and LLMEnsemble.
+	LLMEndpoints map[string]LLMEndpoint
 	MaxItems     int
 	DBHost       string
 	DBPort       int
 	DBUser       string
 	DBPass       string
 	DBName       string
+
+	// Notifications go to NOTIFY_WEBHOOK_URL unless NOTIFY_CONFIG lists routed destinations.
+	NotifyWebhookURL    string
+	NotifyMinImportance int
+	NotifyConfigFile    string
+	// NotifyLang is the default message language, "zh" or "en".
+	NotifyLang string
+	// WatchlistChannel is the notify destination for watchlist alerts of
+	// entries without their own channel; empty sends them everywhere.
+	WatchlistChannel string
+	// Items whose reported confidence is below this go to human review instead of being pushed.
+	ReviewConfidenceBelow float64
+
+	// EntityAliasesFile extends the built-in entity alias dictionary.
+	EntityAliasesFile string
+
+	// PrefilterMode is "off", "shadow" (score items but always ask the model)
+	// or "enforce" (skip the model for items the rules decide).
+	PrefilterMode string
+	// PrefilterRulesFile extends the built-in prefilter rules.
+	PrefilterRulesFile string
+	// PrefilterThreshold is the absolute rule score that rejects or accepts an item.
+	PrefilterThreshold float64
+
+	// SummaryEnabled generates bilingual summaries for relevant items.
+	SummaryEnabled bool
+
+	// EmbeddingProvider is "openai", "local" or "auto" (openai when an API key is set).
+	EmbeddingProvider string
+	EmbeddingModel    string
+
+	// The server searches vectors of items published within
+	// EmbeddingIndexMaxAge and reloads them every EmbeddingIndexRefresh.
+	EmbeddingIndexMaxAge  time.Duration
+	EmbeddingIndexRefresh time.Duration
+
+	// Story clustering groups reports of the same event published within
+	// ClusterWindow so only the first one is pushed.
+	ClusterEnabled         bool
+	ClusterWindow          time.Duration
+	ClusterMaxDistance     int
+	ClusterTitleSimilarity float64
+	// ClusterEmbeddingSimilarity also matches items by vector cosine; it only
+	// applies to provider embeddings, not the local fallback.
+	ClusterEmbeddingSimilarity float64
+
+	// Trend detection compares the last TrendWindow with the TrendBaselineWindows before it.
+	TrendWindow          time.Duration
+	TrendBaselineWindows int
+	TrendMinCount        int
+	TrendMinRatio        float64
+	TrendMinZScore       float64
+	// TrendAlerts pushes newly detected trends every TrendInterval.
+	TrendAlerts   bool
+	TrendInterval time.Duration
+	// TrendAlertRetentionDays keeps alert records at least one trend window.
+	TrendAlertRetentionDays int
+
+	// FewShotEnabled injects up to FewShotExamples similar labeled items,
+	// FewShotMaxChars characters in total, into the classification prompt.
+	FewShotEnabled  bool
+	FewShotExamples int
+	FewShotMaxChars int
+
+	// AdminToken protects /api/v1/admin endpoints when set.
+	AdminToken string
+
+	// Retention rules are disabled when their day count is zero.
+	RetentionStripIrrelevantDays int
+	RetentionArchiveDays         int
+	RetentionDeleteDays          int
+	RetentionArchiveDir          string
+	RetentionInterval            time.Duration
+	RetentionDryRun              bool
+
+	// Audit controls persistence of raw model prompts and completions.
+	AuditEnabled       bool
+	AuditCompress      bool
+	AuditRetentionDays int
 }
 
 // Load reads environment variables, filling in reasonable defaults.
 func Load() Config {
+	fallback := modelSpecs("LLM_FALLBACK")
+	ensemble := modelSpecs("LLM_ENSEMBLE")
 	return Config{
 		FeedURL:      stringWithDefault("FEED_URL", defaultFeedURL),
 		PollInterval: durationFromMinutes("POLL_INTERVAL_MINUTES", defaultPollMinutes),
@@ -46,15 +212,143 @@ func Load() Config {
 		OpenAIKey:    os.Getenv("OPENAI_API_KEY"),
 		OpenAIModel:  stringWithDefault("OPENAI_MODEL", defaultOpenAIModel),
 		OpenAIBase:   stringWithDefault("OPENAI_BASE_URL", defaultOpenAIBase),
-		MaxItems:     intWithDefault("MAX_ITEMS", defaultMaxItemStore),
-		DBHost:       stringWithDefault("DB_HOST", defaultDBHost),
-		DBPort:       intWithDefault("DB_PORT", defaultDBPort),
-		DBUser:       stringWithDefault("DB_USER", defaultDBUser),
-		DBPass:       stringWithDefault("DB_PASSWORD", defaultDBPass),
-		DBName:       stringWithDefault("DB_NAME", defaultDBName),
+		LLMProvider:  stringWithDefault("LLM_PROVIDER", "openai"),
+
+		LLMStubAllowed:              boolWithDefault("LLM_STUB_ALLOWED", false),
+		LLMFallback:                 fallback,
+		LLMEnsemble:                 ensemble,
+		LLMEnsembleVoting:           stringWithDefault("LLM_ENSEMBLE_VOTING", "majority"),
+		LLMTimeout:                  time.Duration(intWithDefault("LLM_TIMEOUT_SECONDS", defaultLLMTimeoutSeconds)) * time.Second,
+		EnsembleReviewDisagreements: boolWithDefault("ENSEMBLE_REVIEW_DISAGREEMENTS", true),
+		LLMEndpoints:                llmEndpoints(fallback, ensemble),
+		LLMPricesFile:               os.Getenv("LLM_PRICES_FILE"),
+		LLMBreaker:                  boolWithDefault("LLM_BREAKER", true),
+		LLMBreakerFailures:          intWithDefault("LLM_BREAKER_FAILURES", defaultLLMBreakerFailures),
+		LLMBreakerCooldown:          time.Duration(intWithDefault("LLM_BREAKER_COOLDOWN_SECONDS", defaultLLMBreakerCooldownSeconds)) * time.Second,
+		LLMBackoffBase:              time.Duration(intWithDefault("LLM_BACKOFF_BASE_SECONDS", defaultLLMBackoffBaseSeconds)) * time.Second,
+		LLMBackoffMax:               time.Duration(intWithDefault("LLM_BACKOFF_MAX_SECONDS", defaultLLMBackoffMaxSeconds)) * time.Second,
+		AnalysisCache:               boolWithDefault("ANALYSIS_CACHE", true),
+		AnalysisCacheTTL:            durationFromHours("ANALYSIS_CACHE_TTL_HOURS", defaultAnalysisCacheTTLHours),
+		LLMDailyBudget:              floatWithDefault("LLM_DAILY_BUDGET_USD", 0),
+
+		LLMDefaultPromptPrice:     floatWithDefault("LLM_DEFAULT_PROMPT_PRICE", defaultLLMPromptPrice),
+		LLMDefaultCompletionPrice: floatWithDefault("LLM_DEFAULT_COMPLETION_PRICE", defaultLLMCompletionPrice),
+
+		MaxItems: intWithDefault("MAX_ITEMS", defaultMaxItemStore),
+		DBHost:   stringWithDefault("DB_HOST", defaultDBHost),
+		DBPort:   intWithDefault("DB_PORT", defaultDBPort),
+		DBUser:   stringWithDefault("DB_USER", defaultDBUser),
+		DBPass:   stringWithDefault("DB_PASSWORD", defaultDBPass),
+		DBName:   stringWithDefault("DB_NAME", defaultDBName),
+
+		NotifyWebhookURL:      stringWithDefault("NOTIFY_WEBHOOK_URL", defaultNotifyWebhookURL),
+		NotifyMinImportance:   intWithDefault("NOTIFY_MIN_IMPORTANCE", 0),
+		NotifyConfigFile:      os.Getenv("NOTIFY_CONFIG"),
+		NotifyLang:            stringWithDefault("NOTIFY_LANG", "zh"),
+		WatchlistChannel:      os.Getenv("WATCHLIST_CHANNEL"),
+		ReviewConfidenceBelow: floatWithDefault("REVIEW_CONFIDENCE_BELOW", defaultReviewConfidenceBelow),
+
+		EntityAliasesFile: os.Getenv("ENTITY_ALIASES_FILE"),
+
+		PrefilterMode:      stringWithDefault("PREFILTER_MODE", "shadow"),
+		PrefilterRulesFile: os.Getenv("PREFILTER_RULES_FILE"),
+		PrefilterThreshold: floatWithDefault("PREFILTER_THRESHOLD", defaultPrefilterThreshold),
+
+		SummaryEnabled: boolWithDefault("SUMMARY_ENABLED", true),
+
+		EmbeddingProvider: stringWithDefault("EMBEDDING_PROVIDER", "auto"),
+		EmbeddingModel:    stringWithDefault("EMBEDDING_MODEL", defaultEmbeddingModel),
+
+		EmbeddingIndexMaxAge:  durationFromDays("EMBEDDING_INDEX_DAYS", defaultEmbeddingIndexDays),
+		EmbeddingIndexRefresh: durationFromMinutes("EMBEDDING_INDEX_REFRESH_MINUTES", defaultEmbeddingIndexRefreshMinutes),
+
+		ClusterEnabled:             boolWithDefault("CLUSTER_ENABLED", true),
+		ClusterWindow:              durationFromHours("CLUSTER_WINDOW_HOURS", defaultClusterWindowHours),
+		ClusterMaxDistance:         intWithDefault("CLUSTER_MAX_DISTANCE", defaultClusterMaxDistance),
+		ClusterTitleSimilarity:     floatWithDefault("CLUSTER_TITLE_SIMILARITY", defaultClusterTitleSimilarity),
+		ClusterEmbeddingSimilarity: floatWithDefault("CLUSTER_EMBEDDING_SIMILARITY", defaultClusterEmbeddingSim),
+
+		TrendWindow:          durationFromDays("TREND_WINDOW_DAYS", defaultTrendWindowDays),
+		TrendBaselineWindows: intWithDefault("TREND_BASELINE_WINDOWS", defaultTrendBaselineWindows),
+		TrendMinCount:        intWithDefault("TREND_MIN_COUNT", defaultTrendMinCount),
+		TrendMinRatio:        floatWithDefault("TREND_MIN_RATIO", defaultTrendMinRatio),
+		TrendMinZScore:       floatWithDefault("TREND_MIN_ZSCORE", defaultTrendMinZScore),
+		TrendAlerts:          boolWithDefault("TREND_ALERTS", false),
+		TrendInterval:        durationFromHours("TREND_INTERVAL_HOURS", defaultTrendIntervalHours),
+
+		TrendAlertRetentionDays: intWithDefault("TREND_ALERT_RETENTION_DAYS", defaultTrendAlertRetentionDays),
+
+		FewShotEnabled:  boolWithDefault("FEW_SHOT_ENABLED", true),
+		FewShotExamples: intWithDefault("FEW_SHOT_EXAMPLES", defaultFewShotExamples),
+		FewShotMaxChars: intWithDefault("FEW_SHOT_MAX_CHARS", defaultFewShotMaxChars),
+
+		AdminToken: os.Getenv("ADMIN_TOKEN"),
+
+		RetentionStripIrrelevantDays: intWithDefault("RETENTION_STRIP_IRRELEVANT_DAYS", 0),
+		RetentionArchiveDays:         intWithDefault("RETENTION_ARCHIVE_DAYS", 0),
+		RetentionDeleteDays:          intWithDefault("RETENTION_DELETE_DAYS", 0),
+		RetentionArchiveDir:          stringWithDefault("RETENTION_ARCHIVE_DIR", defaultRetentionArchiveDir),
+		RetentionInterval:            durationFromHours("RETENTION_INTERVAL_HOURS", defaultRetentionIntervalHours),
+		RetentionDryRun:              boolWithDefault("RETENTION_DRY_RUN", false),
+
+		AuditEnabled:       boolWithDefault("AUDIT_ENABLED", true),
+		AuditCompress:      boolWithDefault("AUDIT_COMPRESS", false),
+		AuditRetentionDays: intWithDefault("AUDIT_RETENTION_DAYS", 0),
 	}
 }
 
+// ModelSpec names a model of a provider: "openai" (OPENAI_API_KEY and
+// OPENAI_BASE_URL), "stub", or a name configured through
+// LLM_PROVIDER_<NAME>_BASE_URL and LLM_PROVIDER_<NAME>_API_KEY.
+type ModelSpec struct {
+	Provider string
+	Model    string
+}
+
+// String renders the spec as written in the environment.
+func (m ModelSpec) String() string {
+	return m.Provider + ":" + m.Model
+}
+
+// LLMEndpoint is an OpenAI-compatible gateway.
+type LLMEndpoint struct {
+	BaseURL string
+	APIKey  string
+}
+
+// modelSpecs parses a comma separated list of provider:model entries; an
+// entry without provider uses "openai".
+func modelSpecs(key string) []ModelSpec {
+	var out []ModelSpec
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		spec := ModelSpec{Provider: "openai", Model: entry}
+		if provider, model, ok := strings.Cut(entry, ":"); ok {
+			spec = ModelSpec{Provider: strings.ToLower(strings.TrimSpace(provider)), Model: strings.TrimSpace(model)}
+		}
+		out = append(out, spec)
+	}
+	return out
+}
+
+// llmEndpoints reads the credentials of every named provider in specs.
+func llmEndpoints(specs ...[]ModelSpec) map[string]LLMEndpoint {
+	out := map[string]LLMEndpoint{}
+	for _, list := range specs {
+		for _, spec := range list {
+			if spec.Provider == "openai" || spec.Provider == "stub" {
+				continue
+			}
+			prefix := "LLM_PROVIDER_" + strings.ToUpper(spec.Provider)
+			out[spec.Provider] = LLMEndpoint{BaseURL: os.Getenv(prefix + "_BASE_URL"), APIKey: os.Getenv(prefix + "_API_KEY")}
+		}
+	}
+	return out
+}
+
 func stringWithDefault(key, fallback string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
@@ -72,6 +366,46 @@ func durationFromMinutes(key string, fallback int) time.Duration {
 	return time.Duration(fallback) * time.Minute
 }
 
+func durationFromHours(key string, fallback int) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if hours, err := strconv.Atoi(v); err == nil && hours > 0 {
+			return time.Duration(hours) * time.Hour
+		}
+		log.Printf("invalid %s=%s, using default %d hours", key, v, fallback)
+	}
+	return time.Duration(fallback) * time.Hour
+}
+
+func durationFromDays(key string, fallback int) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if days, err := strconv.Atoi(v); err == nil && days > 0 {
+			return time.Duration(days) * 24 * time.Hour
+		}
+		log.Printf("invalid %s=%s, using default %d days", key, v, fallback)
+	}
+	return time.Duration(fallback) * 24 * time.Hour
+}
+
+func floatWithDefault(key string, fallback float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed >= 0 {
+			return parsed
+		}
+		log.Printf("invalid %s=%s, using default %g", key, v, fallback)
+	}
+	return fallback
+}
+
+func boolWithDefault(key string, fallback bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if parsed, err := strconv.ParseBool(v); err == nil {
+			return parsed
+		}
+		log.Printf("invalid %s=%s, using default %t", key, v, fallback)
+	}
+	return fallback
+}
+
 func intWithDefault(key string, fallback int) int {
 	if v := os.Getenv(key); v != "" {
 		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
@@ -0,0 +1,111 @@
+// Package embedding turns items into vectors and searches them in process.
+package embedding
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"aiweb3news/internal/analysis"
+)
+
+// Embedder produces vectors for texts. Vectors are only comparable between
+// embedders reporting the same Name.
+type Embedder interface {
+	Name() string
+	Embed(ctx context.Context, texts []string) ([][]float32, analysis.Meta, error)
+}
+
+// Remote embeds through the provider configured for the analysis client.
+type Remote struct {
+	client *analysis.Client
+	model  string
+}
+
+// NewRemote creates an embedder calling model through client.
+func NewRemote(client *analysis.Client, model string) *Remote {
+	return &Remote{client: client, model: model}
+}
+
+// Name implements Embedder.
+func (r *Remote) Name() string { return r.model }
+
+// Embed implements Embedder.
+func (r *Remote) Embed(ctx context.Context, texts []string) ([][]float32, analysis.Meta, error) {
+	return r.client.Embed(ctx, r.model, texts)
+}
+
+// localDim is the size of locally hashed vectors.
+const localDim = 256
+
+// Local is a deterministic, offline embedder based on hashed character
+// n-grams. It captures lexical overlap only, but needs no provider, which
+// keeps search usable without an API key and makes results reproducible.
+type Local struct{}
+
+// Name implements Embedder.
+func (Local) Name() string { return "local-hash-256" }
+
+// Embed implements Embedder.
+func (Local) Embed(_ context.Context, texts []string) ([][]float32, analysis.Meta, error) {
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		out[i] = hashVector(t)
+	}
+	return out, analysis.Meta{}, nil
+}
+
+func hashVector(text string) []float32 {
+	vec := make([]float32, localDim)
+	var runes []rune
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			runes = append(runes, r)
+		}
+	}
+	add := func(feature string) {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(feature))
+		sum := h.Sum32()
+		// The top bit picks the sign so unrelated features cancel out on average.
+		if sum&(1<<31) != 0 {
+			vec[sum%localDim]--
+		} else {
+			vec[sum%localDim]++
+		}
+	}
+	for i := range runes {
+		add(string(runes[i : i+1]))
+		if i+2 <= len(runes) {
+			add(string(runes[i : i+2]))
+		}
+	}
+	Normalize(vec)
+	return vec
+}
+
+// Normalize scales v to unit length in place so dot products are cosines.
+func Normalize(v []float32) {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= norm
+	}
+}
+
+// Text renders the part of an item that gets embedded.
+func Text(title, summary string) string {
+	r := []rune(summary)
+	if len(r) > 1000 {
+		summary = string(r[:1000])
+	}
+	return strings.TrimSpace(title + "\n" + summary)
+}
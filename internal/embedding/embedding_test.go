@@ -0,0 +1,96 @@
+package embedding
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestLocalEmbed(t *testing.T) {
+	texts := []string{"Bitcoin ETF inflows", "bitcoin etf INFLOWS!", "以太坊升级", ""}
+	vecs, _, err := Local{}.Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range vecs {
+		if len(v) != localDim {
+			t.Fatalf("%q: got %d dimensions, want %d", texts[i], len(v), localDim)
+		}
+	}
+	tests := []struct {
+		name string
+		a, b int
+		want func(float64) bool
+	}{
+		{"case and punctuation are ignored", 0, 1, func(s float64) bool { return math.Abs(s-1) < 1e-6 }},
+		{"unrelated scripts are far apart", 0, 2, func(s float64) bool { return s < 0.5 }},
+		{"empty text is the zero vector", 3, 3, func(s float64) bool { return s == 0 }},
+		{"unit length", 2, 2, func(s float64) bool { return math.Abs(s-1) < 1e-6 }},
+	}
+	for _, tt := range tests {
+		if got := dot(vecs[tt.a], vecs[tt.b]); !tt.want(got) {
+			t.Errorf("%s: similarity %v", tt.name, got)
+		}
+	}
+
+	again, _, _ := Local{}.Embed(context.Background(), texts[:1])
+	if !reflect.DeepEqual(again[0], vecs[0]) {
+		t.Error("embedding is not deterministic")
+	}
+}
+
+func TestIndexSearch(t *testing.T) {
+	x := NewIndex()
+	x.Add(1, []float32{1, 0, 0})
+	x.Add(2, []float32{2, 2, 0}) // normalized on Add
+	x.Add(3, []float32{0, 1, 0})
+	x.Add(4, []float32{-1, 0, 0})
+	x.Add(5, []float32{1, 0}) // other dimension, never matches
+
+	tests := []struct {
+		name     string
+		query    []float32
+		k        int
+		exclude  int64
+		minScore float64
+		want     []int64
+	}{
+		{"ranked by cosine", []float32{1, 0, 0}, 3, 0, -1, []int64{1, 2, 3}},
+		{"query is normalized", []float32{5, 0, 0}, 2, 0, -1, []int64{1, 2}},
+		{"exclude skips the item", []float32{1, 0, 0}, 2, 1, -1, []int64{2, 3}},
+		{"min score drops weak hits", []float32{1, 0, 0}, 10, 0, 0.5, []int64{1, 2}},
+		{"ties broken by newer id", []float32{1, 1, 0}, 2, 2, 0, []int64{3, 1}},
+		{"nothing above the threshold", []float32{0, 0, 1}, 3, 0, 0.1, nil},
+	}
+	for _, tt := range tests {
+		var got []int64
+		for _, h := range x.Search(tt.query, tt.k, tt.exclude, tt.minScore) {
+			got = append(got, h.ItemID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIndexReplaceKeepsNewerVectors(t *testing.T) {
+	x := NewIndex()
+	x.Add(1, []float32{1, 0})
+	x.Add(2, []float32{0, 1})
+	mark := x.Mark()
+	x.Add(3, []float32{1, 1}) // arrives while the fresh index loads
+
+	fresh := NewIndex()
+	fresh.Add(1, []float32{1, 0})
+	x.Replace(fresh, mark)
+
+	for _, tt := range []struct {
+		id   int64
+		want bool
+	}{{1, true}, {2, false}, {3, true}} {
+		if _, ok := x.Get(tt.id); ok != tt.want {
+			t.Errorf("item %d present = %v, want %v", tt.id, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package embedding
+
+import (
+	"sort"
+	"sync"
+)
+
+// Hit is a search result.
+type Hit struct {
+	ItemID int64   `json:"item_id"`
+	Score  float64 `json:"score"`
+}
+
+// Index is a brute-force in-memory vector index. At a few hundred thousand
+// vectors a linear scan is still well under the latency of an HTTP request.
+type Index struct {
+	mu      sync.RWMutex
+	vectors map[int64]entry
+	// gen counts additions, so Replace can tell which vectors arrived while
+	// a replacement was being loaded.
+	gen uint64
+}
+
+type entry struct {
+	vec []float32
+	gen uint64
+}
+
+// NewIndex creates an empty index.
+func NewIndex() *Index {
+	return &Index{vectors: make(map[int64]entry)}
+}
+
+// Add stores or replaces the vector of an item. The vector is normalized.
+func (x *Index) Add(itemID int64, vec []float32) {
+	v := append([]float32(nil), vec...)
+	Normalize(v)
+	x.mu.Lock()
+	x.gen++
+	x.vectors[itemID] = entry{vec: v, gen: x.gen}
+	x.mu.Unlock()
+}
+
+// Get returns the stored vector of an item.
+func (x *Index) Get(itemID int64) ([]float32, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	e, ok := x.vectors[itemID]
+	return e.vec, ok
+}
+
+// Mark returns a position to pass to Replace after loading a fresh index.
+func (x *Index) Mark() uint64 {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.gen
+}
+
+// Replace swaps in the vectors of fresh, keeping those added since mark that
+// fresh does not have, since the load may have missed them.
+func (x *Index) Replace(fresh *Index, mark uint64) {
+	fresh.mu.RLock()
+	vectors := make(map[int64]entry, len(fresh.vectors))
+	for id, e := range fresh.vectors {
+		vectors[id] = entry{vec: e.vec}
+	}
+	fresh.mu.RUnlock()
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for id, e := range x.vectors {
+		if _, ok := vectors[id]; !ok && e.gen > mark {
+			vectors[id] = e
+		}
+	}
+	x.vectors = vectors
+}
+
+// Len returns the number of indexed items.
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.vectors)
+}
+
+// Search returns the k items most similar to query by cosine similarity,
+// skipping exclude and anything scoring below minScore.
+func (x *Index) Search(query []float32, k int, exclude int64, minScore float64) []Hit {
+	q := append([]float32(nil), query...)
+	Normalize(q)
+
+	x.mu.RLock()
+	hits := make([]Hit, 0, k+1)
+	for id, e := range x.vectors {
+		if id == exclude || len(e.vec) != len(q) {
+			continue
+		}
+		score := dot(q, e.vec)
+		if score < minScore {
+			continue
+		}
+		hits = append(hits, Hit{ItemID: id, Score: score})
+		// Trim periodically instead of keeping a heap; k is small.
+		if len(hits) > 4*k+64 {
+			hits = topK(hits, k)
+		}
+	}
+	x.mu.RUnlock()
+	return topK(hits, k)
+}
+
+func topK(hits []Hit, k int) []Hit {
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ItemID > hits[j].ItemID
+	})
+	if len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
@@ -0,0 +1,181 @@
+package entity
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"aiweb3news/internal/analysis"
+)
+
+//go:embed aliases.json
+var defaultAliases []byte
+
+// corporateSuffixes are dropped before matching so "Stripe Inc." and "Stripe" meet.
+var corporateSuffixes = []string{" inc", " inc.", " ltd", " ltd.", " llc", " corp", " corp.", " corporation", " limited", " co.", "有限公司", "公司"}
+
+var currencyAliases = map[string]string{
+	"usd": "USD", "$": "USD", "us$": "USD", "美元": "USD", "美金": "USD",
+	"hkd": "HKD", "hk$": "HKD", "港元": "HKD", "港币": "HKD",
+	"cny": "CNY", "rmb": "CNY", "人民币": "CNY", "元": "CNY",
+	"eur": "EUR", "€": "EUR", "欧元": "EUR",
+	"jpy": "JPY", "¥": "JPY", "日元": "JPY",
+	"sgd": "SGD", "新加坡元": "SGD", "新元": "SGD",
+	"gbp": "GBP", "£": "GBP", "英镑": "GBP",
+}
+
+var knownTypes = map[string]bool{
+	analysis.EntityOrganization: true,
+	analysis.EntityPerson:       true,
+	analysis.EntityJurisdiction: true,
+	analysis.EntityChain:        true,
+	analysis.EntityToken:        true,
+	analysis.EntityProtocol:     true,
+	analysis.EntityAmount:       true,
+}
+
+// Dictionary maps entity aliases to canonical names per entity type.
+type Dictionary struct {
+	aliases map[string]map[string]string
+}
+
+// aliasFile is the on-disk layout: type -> canonical name -> aliases.
+type aliasFile map[string]map[string][]string
+
+// Load returns the dictionary shipped with the service, extended by the
+// aliases in path when it is not empty. Entries in path win on conflicts.
+func Load(path string) (*Dictionary, error) {
+	d := &Dictionary{aliases: map[string]map[string]string{}}
+	if err := d.add(defaultAliases); err != nil {
+		return nil, fmt.Errorf("parse built-in aliases: %w", err)
+	}
+	if path == "" {
+		return d, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read alias file: %w", err)
+	}
+	if err := d.add(raw); err != nil {
+		return nil, fmt.Errorf("parse alias file %s: %w", path, err)
+	}
+	return d, nil
+}
+
+func (d *Dictionary) add(raw []byte) error {
+	var file aliasFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return err
+	}
+	for typ, entries := range file {
+		typ = strings.ToLower(typ)
+		if d.aliases[typ] == nil {
+			d.aliases[typ] = map[string]string{}
+		}
+		for canonical, aliases := range entries {
+			d.aliases[typ][key(canonical)] = canonical
+			for _, alias := range aliases {
+				d.aliases[typ][key(alias)] = canonical
+			}
+		}
+	}
+	return nil
+}
+
+// Canonical returns the canonical spelling of name for the entity type. Unknown
+// names are returned cleaned up but otherwise unchanged.
+func (d *Dictionary) Canonical(typ, name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	if typ == analysis.EntityToken {
+		name = strings.ToUpper(strings.TrimPrefix(name, "$"))
+	}
+	known := d.aliases[typ]
+	if canonical, ok := known[key(name)]; ok {
+		return canonical
+	}
+	stripped := stripSuffix(name)
+	if canonical, ok := known[key(stripped)]; ok {
+		return canonical
+	}
+	return stripped
+}
+
+// Normalize canonicalizes entity names and currencies, drops unknown types and
+// empty names, and removes duplicates while keeping the model's order.
+func (d *Dictionary) Normalize(entities []analysis.Entity) []analysis.Entity {
+	seen := make(map[string]bool, len(entities))
+	out := make([]analysis.Entity, 0, len(entities))
+	for _, e := range entities {
+		e.Type = strings.ToLower(strings.TrimSpace(e.Type))
+		if !knownTypes[e.Type] || strings.TrimSpace(e.Name) == "" {
+			continue
+		}
+		if e.Type == analysis.EntityAmount {
+			e.Name = strings.TrimSpace(e.Name)
+			e.Currency = NormalizeCurrency(e.Currency)
+		} else {
+			e.Name = d.Canonical(e.Type, e.Name)
+			e.Amount, e.Currency = 0, ""
+		}
+		id := e.Type + "\x00" + strings.ToLower(e.Name)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		out = append(out, e)
+	}
+	return out
+}
+
+// NormalizeCurrency maps currency names and symbols to ISO codes.
+func NormalizeCurrency(c string) string {
+	c = strings.TrimSpace(c)
+	if code, ok := currencyAliases[strings.ToLower(c)]; ok {
+		return code
+	}
+	return strings.ToUpper(c)
+}
+
+func key(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+func stripSuffix(name string) string {
+	lower := strings.ToLower(name)
+	for _, suffix := range corporateSuffixes {
+		if strings.HasSuffix(lower, suffix) && len(name) > len(suffix) {
+			return strings.TrimRight(strings.TrimSpace(name[:len(name)-len(suffix)]), ",")
+		}
+	}
+	return name
+}
+
+var roundAliases = map[string]string{
+	"pre-seed": "Pre-Seed", "preseed": "Pre-Seed", "pre seed": "Pre-Seed", "前种子轮": "Pre-Seed",
+	"seed": "Seed", "种子轮": "Seed", "种子": "Seed",
+	"pre-a": "Pre-A", "pre-a轮": "Pre-A", "pre a": "Pre-A",
+	"angel": "Angel", "天使轮": "Angel", "天使": "Angel",
+	"strategic": "Strategic", "战略": "Strategic", "战略融资": "Strategic", "战略投资": "Strategic", "战略轮": "Strategic",
+	"ipo": "IPO", "上市": "IPO",
+	"private": "Private", "私募": "Private", "私募轮": "Private",
+}
+
+// NormalizeRound maps round spellings such as "A轮" or "series a" to a
+// canonical label such as "Series A".
+func NormalizeRound(round string) string {
+	r := strings.TrimSpace(round)
+	if r == "" {
+		return ""
+	}
+	lower := strings.ToLower(r)
+	if canonical, ok := roundAliases[lower]; ok {
+		return canonical
+	}
+	letter := strings.TrimSuffix(strings.TrimPrefix(lower, "series "), "轮")
+	if len(letter) == 1 && letter[0] >= 'a' && letter[0] <= 'z' && (strings.HasPrefix(lower, "series ") || strings.HasSuffix(lower, "轮")) {
+		return "Series " + strings.ToUpper(letter)
+	}
+	return r
+}
@@ -0,0 +1,281 @@
+// Package eval measures classification quality against a labeled dataset.
+package eval
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"aiweb3news/internal/analysis"
+	"aiweb3news/internal/storage"
+)
+
+// Irrelevant is the confusion matrix label of items judged not relevant.
+const Irrelevant = "不相关"
+
+// Case is one labeled item of a golden dataset, stored as a JSONL line.
+type Case struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Link        string    `json:"link"`
+	PublishedAt time.Time `json:"published_at"`
+	Summary     string    `json:"summary"`
+	// Relevant and Category are the expected answers. Category is ignored
+	// for irrelevant items and may be left empty to only grade relevance.
+	Relevant bool   `json:"relevant"`
+	Category string `json:"category"`
+	// ItemID is the stored item the case was labeled on, if any. Its own
+	// label is never shown to the model as an example.
+	ItemID int64 `json:"item_id,omitempty"`
+}
+
+// Examples picks the few-shot examples shown to the model for a case, as the
+// pipeline does for live items.
+type Examples func(c Case) []analysis.Example
+
+// LabeledCase converts an item's latest feedback label to a case.
+func LabeledCase(l storage.LabeledItem) Case {
+	return Case{
+		ID:          strconv.FormatInt(l.ItemID, 10),
+		Title:       l.Title,
+		Link:        l.Link,
+		PublishedAt: l.PublishedAt,
+		Summary:     l.Summary,
+		Relevant:    l.Relevant,
+		Category:    l.Category,
+		ItemID:      l.ItemID,
+	}
+}
+
+// LoadCases reads a JSONL dataset.
+func LoadCases(r io.Reader) ([]Case, error) {
+	var cases []Case
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		var c Case
+		if err := json.Unmarshal(sc.Bytes(), &c); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if strings.TrimSpace(c.Title) == "" {
+			return nil, fmt.Errorf("line %d: missing title", line)
+		}
+		if c.ID == "" {
+			c.ID = fmt.Sprintf("line-%d", line)
+		}
+		cases = append(cases, c)
+	}
+	return cases, sc.Err()
+}
+
+// Pricing converts token usage to USD. Prices are per million tokens.
+type Pricing struct {
+	PromptPerMillion     float64
+	CompletionPerMillion float64
+}
+
+// Cost returns the price of a call.
+func (p Pricing) Cost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*p.PromptPerMillion + float64(completionTokens)*p.CompletionPerMillion) / 1e6
+}
+
+// Mistake is a case the analyzer got wrong or failed on.
+type Mistake struct {
+	ID                string `json:"id"`
+	Title             string `json:"title"`
+	ExpectedRelevant  bool   `json:"expected_relevant"`
+	ExpectedCategory  string `json:"expected_category,omitempty"`
+	PredictedRelevant bool   `json:"predicted_relevant"`
+	PredictedCategory string `json:"predicted_category,omitempty"`
+	Reason            string `json:"reason,omitempty"`
+	Error             string `json:"error,omitempty"`
+}
+
+// Report summarizes one analyzer configuration over a dataset. Precision,
+// recall and F1 grade the relevance decision; CategoryAccuracy grades the
+// category of correctly accepted items whose expected category is labeled.
+type Report struct {
+	Name          string `json:"name"`
+	Model         string `json:"model"`
+	PromptVersion string `json:"prompt_version"`
+
+	Cases          int `json:"cases"`
+	Errors         int `json:"errors"`
+	TruePositives  int `json:"true_positives"`
+	FalsePositives int `json:"false_positives"`
+	FalseNegatives int `json:"false_negatives"`
+	TrueNegatives  int `json:"true_negatives"`
+
+	Precision        float64 `json:"precision"`
+	Recall           float64 `json:"recall"`
+	F1               float64 `json:"f1"`
+	Accuracy         float64 `json:"accuracy"`
+	CategoryAccuracy float64 `json:"category_accuracy"`
+	// Confusion counts expected category → predicted category, using
+	// Irrelevant for items on either side that are not relevant.
+	Confusion map[string]map[string]int `json:"confusion"`
+
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	AvgLatencyMS     int64   `json:"avg_latency_ms"`
+	P95LatencyMS     int64   `json:"p95_latency_ms"`
+
+	Mistakes []Mistake `json:"mistakes"`
+}
+
+type outcome struct {
+	result analysis.Result
+	err    error
+}
+
+// Run evaluates every case with analyzer, using up to concurrency parallel
+// calls. examples, when set, adds few-shot examples to every case.
+func Run(ctx context.Context, name string, analyzer analysis.Analyzer, cases []Case, examples Examples, pricing Pricing, concurrency int) Report {
+	outcomes := make([]outcome, len(cases))
+	sem := make(chan struct{}, max(concurrency, 1))
+	var wg sync.WaitGroup
+	for i, c := range cases {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, c Case) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			req := analysis.ItemContext{
+				Title:       c.Title,
+				Link:        c.Link,
+				PublishedAt: c.PublishedAt,
+				Summary:     c.Summary,
+			}
+			if examples != nil {
+				req.Examples = examples(c)
+			}
+			res, err := analyzer.Evaluate(ctx, req)
+			outcomes[i] = outcome{result: res, err: err}
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{
+		Name:      name,
+		Cases:     len(cases),
+		Confusion: make(map[string]map[string]int),
+	}
+	if d, ok := analyzer.(interface {
+		Model() string
+		PromptVersion() string
+	}); ok {
+		report.Model, report.PromptVersion = d.Model(), d.PromptVersion()
+	}
+	var latencies []time.Duration
+	var categoryGraded, categoryCorrect int
+	for i, c := range cases {
+		o := outcomes[i]
+		meta := o.result.Meta
+		if report.Model == "" && meta.Model != "" {
+			report.Model, report.PromptVersion = meta.Model, meta.PromptVersion
+		}
+		report.PromptTokens += meta.PromptTokens
+		report.CompletionTokens += meta.CompletionTokens
+		report.CostUSD += pricing.Cost(meta.PromptTokens, meta.CompletionTokens)
+		if meta.Latency > 0 {
+			latencies = append(latencies, meta.Latency)
+		}
+
+		expected := label(c.Relevant, c.Category)
+		if o.err != nil {
+			report.Errors++
+			report.Mistakes = append(report.Mistakes, Mistake{ID: c.ID, Title: c.Title, ExpectedRelevant: c.Relevant, ExpectedCategory: expected, Error: o.err.Error()})
+			continue
+		}
+		res := o.result
+		predicted := label(res.Relevant, res.Category)
+		if report.Confusion[expected] == nil {
+			report.Confusion[expected] = make(map[string]int)
+		}
+		report.Confusion[expected][predicted]++
+
+		switch {
+		case c.Relevant && res.Relevant:
+			report.TruePositives++
+		case !c.Relevant && res.Relevant:
+			report.FalsePositives++
+		case c.Relevant && !res.Relevant:
+			report.FalseNegatives++
+		default:
+			report.TrueNegatives++
+		}
+		categoryWrong := false
+		if c.Relevant && res.Relevant && strings.TrimSpace(c.Category) != "" {
+			categoryGraded++
+			if expected == predicted {
+				categoryCorrect++
+			} else {
+				categoryWrong = true
+			}
+		}
+		if c.Relevant != res.Relevant || categoryWrong {
+			report.Mistakes = append(report.Mistakes, Mistake{
+				ID:                c.ID,
+				Title:             c.Title,
+				ExpectedRelevant:  c.Relevant,
+				ExpectedCategory:  expected,
+				PredictedRelevant: res.Relevant,
+				PredictedCategory: predicted,
+				Reason:            res.Reason,
+			})
+		}
+	}
+
+	report.Precision = ratio(report.TruePositives, report.TruePositives+report.FalsePositives)
+	report.Recall = ratio(report.TruePositives, report.TruePositives+report.FalseNegatives)
+	if report.Precision+report.Recall > 0 {
+		report.F1 = round(2 * report.Precision * report.Recall / (report.Precision + report.Recall))
+	}
+	report.Accuracy = ratio(report.TruePositives+report.TrueNegatives, report.Cases-report.Errors)
+	report.CategoryAccuracy = ratio(categoryCorrect, categoryGraded)
+	report.CostUSD = math.Round(report.CostUSD*1e6) / 1e6
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		var total time.Duration
+		for _, l := range latencies {
+			total += l
+		}
+		report.AvgLatencyMS = (total / time.Duration(len(latencies))).Milliseconds()
+		report.P95LatencyMS = latencies[(len(latencies)*95+99)/100-1].Milliseconds()
+	}
+	return report
+}
+
+// label names the confusion matrix row or column of an answer.
+func label(relevant bool, category string) string {
+	if !relevant {
+		return Irrelevant
+	}
+	if c := analysis.CanonicalCategory(category); c != "" {
+		return c
+	}
+	return "(未标注)"
+}
+
+func ratio(num, den int) float64 {
+	if den == 0 {
+		return 0
+	}
+	return round(float64(num) / float64(den))
+}
+
+func round(f float64) float64 {
+	return math.Round(f*10000) / 10000
+}
@@ -0,0 +1,145 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"aiweb3news/internal/analysis"
+)
+
+// scripted answers chat requests with the reply whose key occurs in the
+// last message, standing in for the provider while recording.
+type scripted map[string]analysis.Result
+
+func (s scripted) CreateChatCompletion(_ context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	user := req.Messages[len(req.Messages)-1].Content
+	for title, res := range s {
+		if strings.Contains(user, title) {
+			content, _ := json.Marshal(res)
+			return openai.ChatCompletionResponse{
+				Model:   req.Model,
+				Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: string(content)}}},
+				Usage:   openai.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120},
+			}, nil
+		}
+	}
+	return openai.ChatCompletionResponse{}, errors.New("unscripted request")
+}
+
+func (scripted) CreateEmbeddings(context.Context, openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	return openai.EmbeddingResponse{}, errors.New("not supported")
+}
+
+var fixtureCases = []Case{
+	{ID: "sec-etf", Title: "SEC 批准现货以太坊 ETF", Relevant: true, Category: analysis.CategoryRegulation},
+	{ID: "meme", Title: "某 meme 币单日暴涨 300%", Relevant: false},
+	{ID: "tokenized-fund", Title: "贝莱德代币化基金规模突破 10 亿美元", Relevant: true, Category: analysis.CategoryRWAPayments},
+	{ID: "maintenance", Title: "交易所周末系统维护公告", Relevant: false},
+	{ID: "upgrade", Title: "以太坊 Pectra 升级主网上线", Relevant: true, Category: analysis.CategoryInfrastructure},
+}
+
+var fixtureExample = analysis.Example{ID: 7, Title: "以太坊 Dencun 升级完成", Relevant: true, Category: analysis.CategoryInfrastructure}
+
+// upgradeExamples shows the example to the upgrade case only; that case was
+// recorded with it, so runs without examples miss the recording.
+func upgradeExamples(c Case) []analysis.Example {
+	if c.ID == "upgrade" {
+		return []analysis.Example{fixtureExample}
+	}
+	return nil
+}
+
+// recordFixture records one response per case, as a live eval run with
+// -record would, and returns a client replaying them.
+func recordFixture(t *testing.T) *analysis.Client {
+	t.Helper()
+	logger := log.New(io.Discard, "", 0)
+	provider := scripted{
+		"SEC 批准": {Relevant: true, Category: analysis.CategoryRegulation, Reason: "监管审批"},
+		"meme 币": {Relevant: true, Category: analysis.CategoryEmerging, Reason: "热点"},
+		"代币化基金":  {Relevant: true, Category: analysis.CategoryInstitutions, Reason: "机构入场"},
+		"系统维护":   {Relevant: false, Reason: "运营公告"},
+		"Pectra": {Relevant: true, Category: analysis.CategoryInfrastructure, Reason: "重大升级"},
+	}
+	var buf bytes.Buffer
+	recorder := analysis.NewRecorder(provider, &buf)
+	live := analysis.NewBackendClient(recorder, "scripted", "gpt-test", logger)
+	for _, c := range fixtureCases {
+		req := analysis.ItemContext{Title: c.Title, Link: c.Link, PublishedAt: c.PublishedAt, Summary: c.Summary, Examples: upgradeExamples(c)}
+		if _, err := live.Evaluate(context.Background(), req); err != nil {
+			t.Fatalf("record %s: %v", c.ID, err)
+		}
+	}
+	replay, err := analysis.LoadReplay(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replay.Len() != len(fixtureCases) {
+		t.Fatalf("recorded %d responses, want %d", replay.Len(), len(fixtureCases))
+	}
+	return analysis.NewBackendClient(replay, analysis.ProviderReplay, "gpt-test", logger)
+}
+
+func TestRunReplay(t *testing.T) {
+	client := recordFixture(t)
+	pricing := Pricing{PromptPerMillion: 1, CompletionPerMillion: 2}
+
+	tests := []struct {
+		name     string
+		examples Examples
+		want     Report
+		mistakes []string
+	}{
+		{
+			name: "without examples the upgrade case is not recorded",
+			want: Report{
+				Cases: 5, Errors: 1,
+				TruePositives: 2, FalsePositives: 1, TrueNegatives: 1,
+				Precision: 0.6667, Recall: 1, F1: 0.8, Accuracy: 0.75, CategoryAccuracy: 0.5,
+				PromptTokens: 400, CompletionTokens: 80, CostUSD: 0.00056,
+			},
+			mistakes: []string{"meme", "tokenized-fund", "upgrade"},
+		},
+		{
+			name:     "with examples every case replays",
+			examples: upgradeExamples,
+			want: Report{
+				Cases:         5,
+				TruePositives: 3, FalsePositives: 1, TrueNegatives: 1,
+				Precision: 0.75, Recall: 1, F1: 0.8571, Accuracy: 0.8, CategoryAccuracy: 0.6667,
+				PromptTokens: 500, CompletionTokens: 100, CostUSD: 0.0007,
+			},
+			mistakes: []string{"meme", "tokenized-fund"},
+		},
+	}
+	for _, tt := range tests {
+		report := Run(context.Background(), tt.name, client, fixtureCases, tt.examples, pricing, 3)
+		if report.Model != "gpt-test" || report.PromptVersion != analysis.DefaultPromptVersion {
+			t.Errorf("%s: model %q prompt %q", tt.name, report.Model, report.PromptVersion)
+		}
+		var mistakes []string
+		for _, m := range report.Mistakes {
+			mistakes = append(mistakes, m.ID)
+		}
+		if !reflect.DeepEqual(mistakes, tt.mistakes) {
+			t.Errorf("%s: mistakes %v, want %v", tt.name, mistakes, tt.mistakes)
+		}
+		if n := report.Confusion[analysis.CategoryRWAPayments][analysis.CategoryInstitutions]; n != 1 {
+			t.Errorf("%s: confusion RWA→institutions = %d, want 1", tt.name, n)
+		}
+		report.Name, report.Model, report.PromptVersion = "", "", ""
+		report.Confusion, report.Mistakes = nil, nil
+		if !reflect.DeepEqual(report, tt.want) {
+			t.Errorf("%s:\n got %+v\nwant %+v", tt.name, report, tt.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteText prints the reports side by side, followed by the confusion
+// matrix and mistakes of each. With two reports a delta column shows how
+// the second configuration moved relative to the first.
+func WriteText(w io.Writer, reports []Report) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	header := []string{"metric"}
+	for _, r := range reports {
+		header = append(header, r.Name)
+	}
+	delta := len(reports) == 2
+	if delta {
+		header = append(header, "delta")
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t")+"\t")
+
+	row := func(name string, value func(Report) float64, format string) {
+		cells := []string{name}
+		for _, r := range reports {
+			cells = append(cells, fmt.Sprintf(format, value(r)))
+		}
+		if delta {
+			cells = append(cells, fmt.Sprintf("%+"+strings.TrimPrefix(format, "%"), value(reports[1])-value(reports[0])))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t")+"\t")
+	}
+	text := func(name string, value func(Report) string) {
+		cells := []string{name}
+		for _, r := range reports {
+			cells = append(cells, value(r))
+		}
+		if delta {
+			cells = append(cells, "")
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t")+"\t")
+	}
+	text("model", func(r Report) string { return r.Model })
+	text("prompt", func(r Report) string { return r.PromptVersion })
+	row("cases", func(r Report) float64 { return float64(r.Cases) }, "%.0f")
+	row("errors", func(r Report) float64 { return float64(r.Errors) }, "%.0f")
+	row("precision", func(r Report) float64 { return r.Precision }, "%.4f")
+	row("recall", func(r Report) float64 { return r.Recall }, "%.4f")
+	row("f1", func(r Report) float64 { return r.F1 }, "%.4f")
+	row("accuracy", func(r Report) float64 { return r.Accuracy }, "%.4f")
+	row("category accuracy", func(r Report) float64 { return r.CategoryAccuracy }, "%.4f")
+	row("prompt tokens", func(r Report) float64 { return float64(r.PromptTokens) }, "%.0f")
+	row("completion tokens", func(r Report) float64 { return float64(r.CompletionTokens) }, "%.0f")
+	row("cost usd", func(r Report) float64 { return r.CostUSD }, "%.4f")
+	row("avg latency ms", func(r Report) float64 { return float64(r.AvgLatencyMS) }, "%.0f")
+	row("p95 latency ms", func(r Report) float64 { return float64(r.P95LatencyMS) }, "%.0f")
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, r := range reports {
+		fmt.Fprintf(w, "\n[%s] confusion (rows expected, columns predicted)\n", r.Name)
+		if err := writeConfusion(w, r.Confusion); err != nil {
+			return err
+		}
+		if len(r.Mistakes) > 0 {
+			fmt.Fprintf(w, "\n[%s] mistakes\n", r.Name)
+		}
+		for _, m := range r.Mistakes {
+			if m.Error != "" {
+				fmt.Fprintf(w, "- %s %s: error: %s\n", m.ID, m.Title, m.Error)
+				continue
+			}
+			fmt.Fprintf(w, "- %s %s: expected %s, got %s (%s)\n", m.ID, m.Title, m.ExpectedCategory, m.PredictedCategory, m.Reason)
+		}
+	}
+	return nil
+}
+
+func writeConfusion(w io.Writer, confusion map[string]map[string]int) error {
+	seen := map[string]bool{}
+	for expected, row := range confusion {
+		seen[expected] = true
+		for predicted := range row {
+			seen[predicted] = true
+		}
+	}
+	labels := make([]string, 0, len(seen))
+	for l := range seen {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "\t"+strings.Join(labels, "\t")+"\t")
+	for _, expected := range labels {
+		cells := []string{expected}
+		for _, predicted := range labels {
+			cells = append(cells, fmt.Sprint(confusion[expected][predicted]))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t")+"\t")
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,176 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"aiweb3news/internal/storage"
+)
+
+// Format names an export file format.
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+	FormatXLSX  Format = "xlsx"
+)
+
+// ParseFormat validates a user supplied format name.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case FormatCSV, FormatJSONL, FormatXLSX:
+		return f, nil
+	case "":
+		return FormatCSV, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q", s)
+	}
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatJSONL:
+		return "application/x-ndjson; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// Writer encodes items one at a time. Close must be called to finish the file.
+type Writer interface {
+	Write(item storage.StoredItem) error
+	Close() error
+}
+
+// NewWriter returns a streaming Writer for the format.
+func NewWriter(f Format, w io.Writer) (Writer, error) {
+	switch f {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatJSONL:
+		return &jsonlWriter{enc: json.NewEncoder(w)}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", f)
+	}
+}
+
+var header = []string{"id", "guid", "title", "link", "published_at", "relevant", "category", "importance", "confidence", "reason", "tags", "summary", "summary_zh", "title_en", "summary_en"}
+
+func row(item storage.StoredItem) []string {
+	published := ""
+	if !item.PublishedAt.IsZero() {
+		published = item.PublishedAt.Format(time.RFC3339)
+	}
+	return []string{
+		strconv.FormatInt(item.ID, 10),
+		item.GUID,
+		item.Title,
+		item.Link,
+		published,
+		strconv.FormatBool(item.Relevant),
+		item.Category,
+		strconv.Itoa(item.Importance),
+		strconv.FormatFloat(item.Confidence, 'f', -1, 64),
+		item.Reason,
+		strings.Join(item.Tags, ";"),
+		item.Summary,
+		item.SummaryZH,
+		item.TitleEN,
+		item.SummaryEN,
+	}
+}
+
+// neutralizeFormula prefixes cells Excel would evaluate as a formula, such
+// as a feed title starting with "=", so they are shown as text.
+func neutralizeFormula(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	// The UTF-8 BOM makes Excel detect the encoding of Chinese text.
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return nil, err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: cw}, nil
+}
+
+func (c *csvWriter) Write(item storage.StoredItem) error {
+	cells := row(item)
+	for i, v := range cells {
+		cells[i] = neutralizeFormula(v)
+	}
+	return c.w.Write(cells)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+type jsonlItem struct {
+	ID          int64     `json:"id"`
+	GUID        string    `json:"guid"`
+	Title       string    `json:"title"`
+	Link        string    `json:"link"`
+	PublishedAt time.Time `json:"published_at"`
+	Relevant    bool      `json:"relevant"`
+	Category    string    `json:"category"`
+	Reason      string    `json:"reason"`
+	Importance  int       `json:"importance"`
+	Confidence  float64   `json:"confidence"`
+	Tags        []string  `json:"tags"`
+	Summary     string    `json:"summary"`
+	SummaryZH   string    `json:"summary_zh,omitempty"`
+	TitleEN     string    `json:"title_en,omitempty"`
+	SummaryEN   string    `json:"summary_en,omitempty"`
+}
+
+func (j *jsonlWriter) Write(item storage.StoredItem) error {
+	return j.enc.Encode(jsonlItem{
+		ID:          item.ID,
+		GUID:        item.GUID,
+		Title:       item.Title,
+		Link:        item.Link,
+		PublishedAt: item.PublishedAt,
+		Relevant:    item.Relevant,
+		Category:    item.Category,
+		Reason:      item.Reason,
+		Importance:  item.Importance,
+		Confidence:  item.Confidence,
+		Tags:        item.Tags,
+		Summary:     item.Summary,
+		SummaryZH:   item.SummaryZH,
+		TitleEN:     item.TitleEN,
+		SummaryEN:   item.SummaryEN,
+	})
+}
+
+func (j *jsonlWriter) Close() error {
+	return nil
+}
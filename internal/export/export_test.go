@@ -0,0 +1,51 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"aiweb3news/internal/storage"
+)
+
+func TestCSVNeutralizesFormulas(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"=HYPERLINK(\"http://evil\")", "'=HYPERLINK(\"http://evil\")"},
+		{"+1 BTC", "'+1 BTC"},
+		{"-20% 跌幅", "'-20% 跌幅"},
+		{"@SEC 回应", "'@SEC 回应"},
+		{"\tETF", "'\tETF"},
+		{"SEC 批准 ETF", "SEC 批准 ETF"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		w, err := NewWriter(FormatCSV, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Write(storage.StoredItem{ID: 1, Title: tt.title, Importance: 7, Confidence: 0.85, TitleEN: "=1+1"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		records, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(buf.Bytes(), []byte("\xEF\xBB\xBF")))).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]string{}
+		for i, name := range records[0] {
+			got[name] = records[1][i]
+		}
+		if got["title"] != tt.want {
+			t.Errorf("title %q written as %q, want %q", tt.title, got["title"], tt.want)
+		}
+		if got["importance"] != "7" || got["confidence"] != "0.85" || got["title_en"] != "'=1+1" {
+			t.Errorf("title %q: row %v", tt.title, got)
+		}
+	}
+}
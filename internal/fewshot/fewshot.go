@@ -0,0 +1,115 @@
+// Package fewshot selects human-labeled items similar to the one being
+// classified, shown to the model as reference judgements.
+package fewshot
+
+import (
+	"sort"
+	"sync"
+
+	"aiweb3news/internal/analysis"
+	"aiweb3news/internal/cluster"
+	"aiweb3news/internal/storage"
+)
+
+const (
+	// minSimilarity drops labeled items sharing little more than common
+	// words with the item being classified.
+	minSimilarity = 0.05
+	// maxSimilarity skips near-identical labeled items such as republished
+	// copies; the item's own label is excluded by id.
+	maxSimilarity = 0.95
+	// summaryRunes trims example summaries before the budget applies.
+	summaryRunes = 200
+)
+
+type entry struct {
+	example  analysis.Example
+	shingles map[string]bool
+}
+
+// Pool holds the latest label of every labeled item in memory. It is safe for
+// concurrent use.
+type Pool struct {
+	mu      sync.RWMutex
+	entries map[int64]entry
+}
+
+// NewPool returns an empty pool.
+func NewPool() *Pool {
+	return &Pool{entries: make(map[int64]entry)}
+}
+
+// Put adds or replaces the example of an item.
+func (p *Pool) Put(e analysis.Example) {
+	ent := entry{example: e, shingles: cluster.ShingleSet(e.Title + " " + e.Summary)}
+	p.mu.Lock()
+	p.entries[e.ID] = ent
+	p.mu.Unlock()
+}
+
+// Len reports how many examples the pool holds.
+func (p *Pool) Len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.entries)
+}
+
+// Similar returns up to k examples most similar to title and summary by
+// shingle overlap whose titles, summaries and notes fit in maxChars. The
+// label of excludeID, the item being classified, is never among them.
+func (p *Pool) Similar(excludeID int64, title, summary string, k, maxChars int) []analysis.Example {
+	query := cluster.ShingleSet(title + " " + summary)
+	type scored struct {
+		example analysis.Example
+		score   float64
+	}
+	var candidates []scored
+	p.mu.RLock()
+	for id, e := range p.entries {
+		if id == excludeID {
+			continue
+		}
+		score := cluster.SetJaccard(query, e.shingles)
+		if score >= minSimilarity && score < maxSimilarity {
+			candidates = append(candidates, scored{e.example, score})
+		}
+	}
+	p.mu.RUnlock()
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].example.ID > candidates[j].example.ID
+	})
+
+	var out []analysis.Example
+	used := 0
+	for _, c := range candidates {
+		if len(out) == k {
+			break
+		}
+		size := len([]rune(c.example.Title)) + len([]rune(c.example.Summary)) + len([]rune(c.example.Note))
+		if used+size > maxChars {
+			continue
+		}
+		used += size
+		out = append(out, c.example)
+	}
+	return out
+}
+
+// FromLabel converts a labeled item to a few-shot example.
+func FromLabel(l storage.LabeledItem) analysis.Example {
+	summary := []rune(l.Summary)
+	if len(summary) > summaryRunes {
+		summary = summary[:summaryRunes]
+	}
+	return analysis.Example{
+		ID:       l.ItemID,
+		Title:    l.Title,
+		Summary:  string(summary),
+		Relevant: l.Relevant,
+		Category: l.Category,
+		Note:     l.Comment,
+	}
+}
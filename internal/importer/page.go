@@ -0,0 +1,98 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"aiweb3news/internal/rss"
+)
+
+// maxPageBytes caps how much of an article page is read when scraping metadata.
+const maxPageBytes = 2 << 20
+
+// fetchPage downloads an article and fills title, description and publish
+// time from its <title> and meta tags (Open Graph preferred).
+func fetchPage(ctx context.Context, client *http.Client, item rss.Item) (rss.Item, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, item.Link, nil)
+	if err != nil {
+		return item, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return item, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return item, fmt.Errorf("fetch %s: %s", item.Link, resp.Status)
+	}
+
+	meta := map[string]string{}
+	var title string
+	z := html.NewTokenizer(io.LimitReader(resp.Body, maxPageBytes))
+	inTitle := false
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			switch tok.Data {
+			case "title":
+				inTitle = true
+			case "meta":
+				var key, content string
+				for _, a := range tok.Attr {
+					switch a.Key {
+					case "name", "property":
+						key = strings.ToLower(a.Val)
+					case "content":
+						content = a.Val
+					}
+				}
+				if key != "" && content != "" {
+					meta[key] = strings.TrimSpace(content)
+				}
+			case "body":
+				// Everything we need lives in <head>.
+				return applyPageMeta(item, title, meta)
+			}
+		case html.TextToken:
+			if inTitle && title == "" {
+				title = strings.TrimSpace(string(z.Text()))
+			}
+		case html.EndTagToken:
+			inTitle = false
+		}
+	}
+	return applyPageMeta(item, title, meta)
+}
+
+func applyPageMeta(item rss.Item, title string, meta map[string]string) (rss.Item, error) {
+	item.Title = firstNonEmpty(meta["og:title"], title)
+	item.Description = firstNonEmpty(meta["og:description"], meta["description"])
+	if v := firstNonEmpty(meta["article:published_time"], meta["pubdate"], meta["publishdate"]); v != "" {
+		if t, err := parseTime(v); err == nil {
+			item.PublishedAt = t
+		}
+	}
+	if item.Title == "" {
+		return item, fmt.Errorf("no title found at %s", item.Link)
+	}
+	return item, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,249 @@
+package importer
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"aiweb3news/internal/rss"
+)
+
+// Kind names an import source format.
+type Kind string
+
+const (
+	KindJSONL Kind = "jsonl"
+	KindCSV   Kind = "csv"
+	KindOPML  Kind = "opml"
+	KindURLs  Kind = "urls"
+)
+
+// DetectKind guesses the source format from the file extension.
+func DetectKind(path string) (Kind, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson":
+		return KindJSONL, nil
+	case ".csv":
+		return KindCSV, nil
+	case ".opml", ".xml":
+		return KindOPML, nil
+	case ".txt":
+		return KindURLs, nil
+	default:
+		return "", fmt.Errorf("cannot detect source kind of %s, pass -kind", path)
+	}
+}
+
+// record is the archive row layout shared by JSONL and CSV sources.
+type record struct {
+	GUID        string `json:"guid"`
+	Title       string `json:"title"`
+	Link        string `json:"link"`
+	PublishedAt string `json:"published_at"`
+	Description string `json:"description"`
+	Summary     string `json:"summary"`
+}
+
+// Read parses the source at path and calls fn for every item in file order.
+// URL lists yield items carrying only GUID and Link; the runner fetches the
+// page lazily so already imported URLs are never downloaded again.
+func Read(ctx context.Context, path string, kind Kind, logger *log.Logger, fn func(rss.Item) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	switch kind {
+	case KindJSONL:
+		return readJSONL(f, fn)
+	case KindCSV:
+		return readCSV(f, fn)
+	case KindOPML:
+		return readOPML(ctx, f, logger, fn)
+	case KindURLs:
+		return readURLs(f, fn)
+	default:
+		return fmt.Errorf("unsupported source kind %q", kind)
+	}
+}
+
+func readJSONL(r io.Reader, fn func(rss.Item) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var rec record
+		if err := json.Unmarshal([]byte(text), &rec); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		item, err := rec.item()
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func readCSV(r io.Reader, fn func(rss.Item) error) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("read csv header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\xEF\xBB\xBF")))] = i
+	}
+	get := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	line := 1
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		line++
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		rec := record{
+			GUID:        get(row, "guid"),
+			Title:       get(row, "title"),
+			Link:        get(row, "link"),
+			PublishedAt: get(row, "published_at"),
+			Description: get(row, "description"),
+			Summary:     get(row, "summary"),
+		}
+		item, err := rec.item()
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+}
+
+type opmlOutline struct {
+	XMLURL   string        `xml:"xmlUrl,attr"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+// readOPML fetches every feed listed in the OPML document and yields all of
+// its entries, without the newsletter filter used for live polling.
+func readOPML(ctx context.Context, r io.Reader, logger *log.Logger, fn func(rss.Item) error) error {
+	var doc struct {
+		Body struct {
+			Outlines []opmlOutline `xml:"outline"`
+		} `xml:"body"`
+	}
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return fmt.Errorf("parse opml: %w", err)
+	}
+
+	var feeds []string
+	var walk func([]opmlOutline)
+	walk = func(outlines []opmlOutline) {
+		for _, o := range outlines {
+			if o.XMLURL != "" {
+				feeds = append(feeds, o.XMLURL)
+			}
+			walk(o.Outlines)
+		}
+	}
+	walk(doc.Body.Outlines)
+
+	for _, feedURL := range feeds {
+		items, err := rss.NewFetcher(feedURL, logger).FetchAll(ctx)
+		if err != nil {
+			logger.Printf("import: fetch feed %s failed: %v", feedURL, err)
+			continue
+		}
+		logger.Printf("import: feed %s has %d items", feedURL, len(items))
+		for _, item := range items {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func readURLs(r io.Reader, fn func(rss.Item) error) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		u := strings.TrimSpace(scanner.Text())
+		if u == "" || strings.HasPrefix(u, "#") {
+			continue
+		}
+		if err := fn(rss.Item{GUID: u, Link: u}); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (r record) item() (rss.Item, error) {
+	item := rss.Item{
+		GUID:        strings.TrimSpace(r.GUID),
+		Title:       strings.TrimSpace(r.Title),
+		Link:        strings.TrimSpace(r.Link),
+		Description: strings.TrimSpace(r.Description),
+	}
+	if item.Description == "" {
+		item.Description = strings.TrimSpace(r.Summary)
+	}
+	if item.GUID == "" {
+		item.GUID = item.Link
+	}
+	if item.GUID == "" {
+		item.GUID = item.Title
+	}
+	if item.GUID == "" {
+		return rss.Item{}, fmt.Errorf("record has no guid, link or title")
+	}
+	if r.PublishedAt != "" {
+		t, err := parseTime(r.PublishedAt)
+		if err != nil {
+			return rss.Item{}, err
+		}
+		item.PublishedAt = t
+	}
+	return item, nil
+}
+
+var timeLayouts = []string{time.RFC3339, time.RFC1123Z, time.RFC1123, "2006-01-02 15:04:05", "2006-01-02"}
+
+func parseTime(v string) (time.Time, error) {
+	v = strings.TrimSpace(v)
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", v)
+}
@@ -0,0 +1,176 @@
+// Package metrics is a minimal registry of counters and gauges exposed in
+// the Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// collector writes its samples in the text exposition format.
+type collector interface {
+	write(w io.Writer)
+}
+
+var (
+	mu         sync.Mutex
+	collectors = map[string]collector{}
+)
+
+func register(name string, c collector) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := collectors[name]; ok {
+		panic("metrics: duplicate metric " + name)
+	}
+	collectors[name] = c
+}
+
+// Vec is a family of samples sharing a name and label names.
+type Vec struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func newVec(kind, name, help string, labels []string) *Vec {
+	v := &Vec{name: name, help: help, kind: kind, labels: labels, values: map[string]float64{}}
+	register(name, v)
+	return v
+}
+
+// NewCounter registers a monotonically increasing counter.
+func NewCounter(name, help string, labels ...string) *Vec {
+	return newVec("counter", name, help, labels)
+}
+
+// NewGauge registers a gauge.
+func NewGauge(name, help string, labels ...string) *Vec {
+	return newVec("gauge", name, help, labels)
+}
+
+// Add increases the sample with the given label values by delta.
+func (v *Vec) Add(delta float64, values ...string) {
+	key := v.key(values)
+	v.mu.Lock()
+	v.values[key] += delta
+	v.mu.Unlock()
+}
+
+// Inc adds one to the sample with the given label values.
+func (v *Vec) Inc(values ...string) {
+	v.Add(1, values...)
+}
+
+// Set replaces the sample with the given label values; meant for gauges.
+func (v *Vec) Set(value float64, values ...string) {
+	key := v.key(values)
+	v.mu.Lock()
+	v.values[key] = value
+	v.mu.Unlock()
+}
+
+func (v *Vec) key(values []string) string {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.name, len(v.labels), len(values)))
+	}
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = v.labels[i] + `="` + escape(value) + `"`
+	}
+	return strings.Join(parts, ",")
+}
+
+func (v *Vec) write(w io.Writer) {
+	v.mu.Lock()
+	keys := make([]string, 0, len(v.values))
+	for k := range v.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	samples := make([]float64, len(keys))
+	for i, k := range keys {
+		samples[i] = v.values[k]
+	}
+	v.mu.Unlock()
+
+	writeHeader(w, v.name, v.help, v.kind)
+	for i, k := range keys {
+		if k == "" {
+			fmt.Fprintf(w, "%s %s\n", v.name, formatFloat(samples[i]))
+		} else {
+			fmt.Fprintf(w, "%s{%s} %s\n", v.name, k, formatFloat(samples[i]))
+		}
+	}
+}
+
+// gaugeFunc reads its value when scraped.
+type gaugeFunc struct {
+	name, help string
+	fn         func() float64
+}
+
+// NewGaugeFunc registers an unlabeled gauge computed by fn at scrape time.
+func NewGaugeFunc(name, help string, fn func() float64) {
+	register(name, &gaugeFunc{name: name, help: help, fn: fn})
+}
+
+func (g *gaugeFunc) write(w io.Writer) {
+	writeHeader(w, g.name, g.help, "gauge")
+	fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(g.fn()))
+}
+
+// Write renders every registered metric, sorted by name.
+func Write(w io.Writer) {
+	mu.Lock()
+	names := make([]string, 0, len(collectors))
+	for name := range collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	list := make([]collector, len(names))
+	for i, name := range names {
+		list[i] = collectors[name]
+	}
+	mu.Unlock()
+
+	for _, c := range list {
+		c.write(w)
+	}
+}
+
+// Handler serves the registry for Prometheus scrapes.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		Write(w)
+	})
+}
+
+func writeHeader(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,257 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"aiweb3news/internal/analysis"
+	"aiweb3news/internal/config"
+)
+
+// Destination is a WeCom-compatible webhook together with its routing rules.
+type Destination struct {
+	Name       string `json:"name"`
+	WebhookURL string `json:"webhook_url"`
+	// MinImportance drops items scored below it; 0 accepts everything.
+	MinImportance int `json:"min_importance"`
+	// Categories limits the destination to categories containing one of the
+	// entries; empty accepts every category.
+	Categories []string `json:"categories"`
+	// Lang selects the message language, "zh" (default) or "en".
+	Lang string `json:"lang"`
+	// Trends opts the destination into trend alerts.
+	Trends bool `json:"trends"`
+}
+
+// Message is an analyzed item ready to be pushed.
+type Message struct {
+	Title      string
+	Link       string
+	Category   string
+	Reason     string
+	Importance int
+	// Generated summaries; empty when the summary stage did not run.
+	SummaryZH string
+	TitleEN   string
+	SummaryEN string
+	// Watchlist match reasons; a message carrying them is a watchlist alert.
+	WatchZH []string
+	WatchEN []string
+}
+
+// Accepts reports whether the destination's routing rules let msg through.
+func (d Destination) Accepts(msg Message) bool {
+	if d.MinImportance > 0 && msg.Importance < d.MinImportance {
+		return false
+	}
+	if len(d.Categories) == 0 {
+		return true
+	}
+	for _, c := range d.Categories {
+		if c != "" && strings.Contains(msg.Category, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadDestinations reads NOTIFY_CONFIG when set, otherwise it builds a single
+// destination from NOTIFY_WEBHOOK_URL and NOTIFY_MIN_IMPORTANCE.
+func LoadDestinations(cfg config.Config) ([]Destination, error) {
+	if cfg.NotifyConfigFile == "" {
+		return []Destination{{
+			Name:          "default",
+			WebhookURL:    cfg.NotifyWebhookURL,
+			MinImportance: cfg.NotifyMinImportance,
+			Lang:          cfg.NotifyLang,
+			Trends:        true,
+		}}, nil
+	}
+	raw, err := os.ReadFile(cfg.NotifyConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("read notify config: %w", err)
+	}
+	var dests []Destination
+	if err := json.Unmarshal([]byte(os.ExpandEnv(string(raw))), &dests); err != nil {
+		return nil, fmt.Errorf("parse notify config: %w", err)
+	}
+	for i, d := range dests {
+		if d.WebhookURL == "" {
+			return nil, fmt.Errorf("notify destination %d (%s) has no webhook_url", i, d.Name)
+		}
+		switch d.Lang {
+		case "":
+			dests[i].Lang = cfg.NotifyLang
+		case "zh", "en":
+		default:
+			return nil, fmt.Errorf("notify destination %d (%s) has unsupported lang %q", i, d.Name, d.Lang)
+		}
+	}
+	return dests, nil
+}
+
+// Notifier routes messages to destinations.
+type Notifier struct {
+	destinations []Destination
+	client       *http.Client
+	logger       *log.Logger
+}
+
+// New creates a Notifier.
+func New(destinations []Destination, logger *log.Logger) *Notifier {
+	return &Notifier{
+		destinations: destinations,
+		client:       http.DefaultClient,
+		logger:       logger,
+	}
+}
+
+// Notify pushes msg to every destination whose rules accept it, except those
+// named in skip, which already received the item.
+func (n *Notifier) Notify(ctx context.Context, msg Message, skip ...string) {
+	for _, d := range n.destinations {
+		if !d.Accepts(msg) || contains(skip, d.Name) {
+			continue
+		}
+		n.send(ctx, d, render(msg, d.Lang))
+	}
+}
+
+// Has reports whether a destination is configured under name.
+func (n *Notifier) Has(name string) bool {
+	for _, d := range n.destinations {
+		if d.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NotifyTo pushes msg to the destination called name, or to every destination
+// when name is empty, ignoring routing rules: watchlist alerts must arrive
+// whatever the item's category or importance. Destinations in skip already
+// received msg and are left out. It returns the destinations that received
+// msg now and fails unless every send succeeded.
+func (n *Notifier) NotifyTo(ctx context.Context, name string, msg Message, skip ...string) ([]string, error) {
+	var (
+		sent, failed []string
+		found        bool
+	)
+	for _, d := range n.destinations {
+		if name != "" && d.Name != name {
+			continue
+		}
+		found = true
+		if contains(skip, d.Name) {
+			continue
+		}
+		if err := n.send(ctx, d, render(msg, d.Lang)); err != nil {
+			failed = append(failed, d.Name)
+			continue
+		}
+		sent = append(sent, d.Name)
+	}
+	switch {
+	case !found:
+		return nil, fmt.Errorf("no notify destination named %q", name)
+	case len(failed) > 0:
+		return sent, fmt.Errorf("notify %s failed", strings.Join(failed, ", "))
+	}
+	return sent, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Broadcast sends a free-form trend alert to every destination opted into
+// trends, in Chinese or English according to the destination's language.
+// Category and importance rules do not apply: alerts are not tied to one item.
+func (n *Notifier) Broadcast(ctx context.Context, zh, en string) {
+	for _, d := range n.destinations {
+		if !d.Trends {
+			continue
+		}
+		content := zh
+		if d.Lang == "en" && en != "" {
+			content = en
+		}
+		n.send(ctx, d, content)
+	}
+}
+
+// render formats msg in lang, falling back to the original Chinese fields
+// when no English translation is available.
+func render(msg Message, lang string) string {
+	if lang == "en" && msg.TitleEN != "" {
+		content := fmt.Sprintf("%s\nCategory: %s", msg.TitleEN, analysis.CategoryEnglish(msg.Category))
+		if len(msg.WatchEN) > 0 {
+			content = "[Watchlist] " + content + "\nWatchlist: " + strings.Join(msg.WatchEN, "; ")
+		}
+		if msg.Importance > 0 {
+			content += fmt.Sprintf("\nImportance: %d/10", msg.Importance)
+		}
+		if msg.SummaryEN != "" {
+			content += "\nSummary: " + msg.SummaryEN
+		}
+		return content + "\nLink: " + msg.Link
+	}
+
+	content := fmt.Sprintf("%s\n分类: %s", msg.Title, msg.Category)
+	if len(msg.WatchZH) > 0 {
+		content = "【关注】" + content + "\n关注: " + strings.Join(msg.WatchZH, "；")
+	}
+	if msg.Importance > 0 {
+		content += fmt.Sprintf("\n重要性: %d/10", msg.Importance)
+	}
+	if msg.SummaryZH != "" {
+		content += "\n摘要: " + msg.SummaryZH
+	}
+	return content + fmt.Sprintf("\nAI分析: %s\n链接: %s", msg.Reason, msg.Link)
+}
+
+func (n *Notifier) send(ctx context.Context, d Destination, content string) error {
+	payload := map[string]any{
+		"msgtype": "text",
+		"text": map[string]string{
+			"content": content,
+		},
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		n.logger.Printf("marshal webhook payload failed: %v", err)
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		n.logger.Printf("build webhook request for %s failed: %v", d.Name, err)
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		n.logger.Printf("send webhook to %s failed: %v", d.Name, err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		n.logger.Printf("webhook %s returned non-2xx status: %s", d.Name, resp.Status)
+		return fmt.Errorf("webhook %s returned %s", d.Name, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+// Package prefilter scores items with deterministic keyword and regex rules
+// so obvious noise can be decided without a model call.
+package prefilter
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+//go:embed rules.json
+var defaultRules []byte
+
+// Decisions returned by Engine.Evaluate.
+const (
+	Accept = "accept"
+	Reject = "reject"
+	Pass   = "pass"
+)
+
+// Rule adds Score to an item when one of its keywords or its pattern matches
+// the title ("title") or the title and summary ("text", the default).
+// Negative scores mark noise, positive scores mark what must not be missed.
+type Rule struct {
+	Name     string   `json:"name"`
+	Field    string   `json:"field"`
+	Keywords []string `json:"keywords"`
+	Pattern  string   `json:"pattern"`
+	Score    float64  `json:"score"`
+	// Category is used for items the rules accept on their own.
+	Category string `json:"category"`
+
+	re *regexp.Regexp
+}
+
+// Hit is a rule that matched an item.
+type Hit struct {
+	Rule  string  `json:"rule"`
+	Match string  `json:"match"`
+	Score float64 `json:"score"`
+}
+
+// Verdict is the outcome of the rules for one item.
+type Verdict struct {
+	Decision string  `json:"decision"`
+	Score    float64 `json:"score"`
+	Hits     []Hit   `json:"hits"`
+	// Category is the category of the strongest accepting hit, if any.
+	Category string `json:"category,omitempty"`
+}
+
+// Engine evaluates a rule set.
+type Engine struct {
+	rules     []Rule
+	threshold float64
+}
+
+// Load returns the rules shipped with the service, extended by the rules in
+// path when it is not empty; a rule in path replaces a built-in rule of the
+// same name. Items scoring at or below -threshold are rejected, at or above
+// threshold accepted.
+func Load(path string, threshold float64) (*Engine, error) {
+	e := &Engine{threshold: threshold}
+	if err := e.add(defaultRules); err != nil {
+		return nil, fmt.Errorf("parse built-in rules: %w", err)
+	}
+	if path == "" {
+		return e, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rules file: %w", err)
+	}
+	if err := e.add(raw); err != nil {
+		return nil, fmt.Errorf("parse rules file %s: %w", path, err)
+	}
+	return e, nil
+}
+
+func (e *Engine) add(raw []byte) error {
+	var rules []Rule
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return err
+	}
+	for _, r := range rules {
+		if r.Name == "" {
+			return fmt.Errorf("rule without name")
+		}
+		switch r.Field {
+		case "":
+			r.Field = "text"
+		case "title", "text":
+		default:
+			return fmt.Errorf("rule %s: unknown field %q", r.Name, r.Field)
+		}
+		if r.Pattern != "" {
+			re, err := regexp.Compile(r.Pattern)
+			if err != nil {
+				return fmt.Errorf("rule %s: %w", r.Name, err)
+			}
+			r.re = re
+		}
+		if r.re == nil && len(r.Keywords) == 0 {
+			return fmt.Errorf("rule %s has neither keywords nor pattern", r.Name)
+		}
+		replaced := false
+		for i := range e.rules {
+			if e.rules[i].Name == r.Name {
+				e.rules[i], replaced = r, true
+			}
+		}
+		if !replaced {
+			e.rules = append(e.rules, r)
+		}
+	}
+	return nil
+}
+
+// Rules returns the loaded rules.
+func (e *Engine) Rules() []Rule {
+	return e.rules
+}
+
+// Evaluate scores an item. Each rule counts at most once.
+func (e *Engine) Evaluate(title, summary string) Verdict {
+	v := Verdict{Decision: Pass, Hits: []Hit{}}
+	text := title + "\n" + summary
+	var best float64
+	for _, r := range e.rules {
+		target := text
+		if r.Field == "title" {
+			target = title
+		}
+		match := r.match(target)
+		if match == "" {
+			continue
+		}
+		v.Hits = append(v.Hits, Hit{Rule: r.Name, Match: match, Score: r.Score})
+		v.Score += r.Score
+		if r.Category != "" && r.Score > best {
+			best, v.Category = r.Score, r.Category
+		}
+	}
+	switch {
+	case e.threshold > 0 && v.Score <= -e.threshold:
+		v.Decision = Reject
+	case e.threshold > 0 && v.Score >= e.threshold:
+		v.Decision = Accept
+	}
+	return v
+}
+
+func (r Rule) match(target string) string {
+	lower := strings.ToLower(target)
+	for _, k := range r.Keywords {
+		if k != "" && strings.Contains(lower, strings.ToLower(k)) {
+			return k
+		}
+	}
+	if r.re != nil {
+		return r.re.FindString(target)
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package prefilter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEvaluateBuiltinRules(t *testing.T) {
+	e, err := Load("", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		title    string
+		summary  string
+		decision string
+		rules    []string
+		category string
+	}{
+		{title: "稳定币市值突破 2000 亿美元", decision: Pass},
+		{title: "BTC 突破 $100,000 创历史新高", decision: Reject, rules: []string{"price-recap"}},
+		{title: "以太坊再度跌破 3000 美元", decision: Reject, rules: []string{"price-recap"}},
+		{title: "某交易所将上线 XYZ 永续合约", decision: Reject, rules: []string{"exchange-listing"}},
+		{title: "Layer2 项目公布空投快照时间", decision: Reject, rules: []string{"airdrop"}},
+		{
+			title:    "美国 SEC 批准贝莱德现货比特币 ETF",
+			decision: Accept,
+			rules:    []string{"regulator", "institution"},
+			category: "监管 / 政策 / 官方试点",
+		},
+		{title: "SEC 主席谈加密监管框架", decision: Pass, rules: []string{"regulator"}, category: "监管 / 政策 / 官方试点"},
+		{title: "Second Foundation 完成融资", decision: Pass},
+		{
+			title:    "meme 币热潮再起",
+			summary:  "多空比失衡，单日爆仓超 2 亿美元",
+			decision: Pass,
+			rules:    []string{"price-move", "meme"},
+		},
+	}
+	for _, tt := range tests {
+		v := e.Evaluate(tt.title, tt.summary)
+		var rules []string
+		for _, h := range v.Hits {
+			rules = append(rules, h.Rule)
+		}
+		if v.Decision != tt.decision || !reflect.DeepEqual(rules, tt.rules) || v.Category != tt.category {
+			t.Errorf("%q: got %s %v %q (score %g), want %s %v %q", tt.title, v.Decision, rules, v.Category, v.Score, tt.decision, tt.rules, tt.category)
+		}
+	}
+}
+
+func TestLoadOverridesRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	custom := `[
+		{"name": "meme", "keywords": ["meme"], "score": -10},
+		{"name": "portfolio", "field": "title", "pattern": "(?i)\\bacme\\b", "score": 10, "category": "大额融资或标志性项目发布"}
+	]`
+	if err := os.WriteFile(path, []byte(custom), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	e, err := Load(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		title    string
+		decision string
+	}{
+		{"meme 币热潮再起", Reject},
+		{"ACME 发布主网", Accept},
+		{"Acmecorp 发布主网", Pass},
+	}
+	for _, tt := range tests {
+		if v := e.Evaluate(tt.title, ""); v.Decision != tt.decision {
+			t.Errorf("%q: got %s (score %g), want %s", tt.title, v.Decision, v.Score, tt.decision)
+		}
+	}
+
+	for _, bad := range []string{
+		`[{"keywords": ["x"], "score": 1}]`,
+		`[{"name": "x", "field": "body", "keywords": ["x"]}]`,
+		`[{"name": "x", "pattern": "("}]`,
+		`[{"name": "x", "score": 1}]`,
+	} {
+		if err := os.WriteFile(path, []byte(bad), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path, 10); err == nil {
+			t.Errorf("Load accepted %s", bad)
+		}
+	}
+}
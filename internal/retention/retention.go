@@ -0,0 +1,205 @@
+package retention
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"aiweb3news/internal/config"
+	"aiweb3news/internal/storage"
+)
+
+// Policy configures the retention rules. A zero duration disables a rule.
+type Policy struct {
+	StripIrrelevantAfter time.Duration
+	ArchiveAfter         time.Duration
+	DeleteAfter          time.Duration
+	ArchiveDir           string
+	// AuditAfter purges stored model call payloads.
+	AuditAfter time.Duration
+	// CacheAfter purges expired analysis cache entries.
+	CacheAfter time.Duration
+	// TrendAlertsAfter purges trend alert records, which only need to outlive
+	// the trend window they deduplicate.
+	TrendAlertsAfter time.Duration
+}
+
+// PolicyFromConfig converts day based settings into a Policy.
+func PolicyFromConfig(cfg config.Config) Policy {
+	days := func(n int) time.Duration { return time.Duration(n) * 24 * time.Hour }
+	var cacheAfter time.Duration
+	if cfg.AnalysisCache {
+		cacheAfter = cfg.AnalysisCacheTTL
+	}
+	var trendAlertsAfter time.Duration
+	if cfg.TrendAlertRetentionDays > 0 {
+		trendAlertsAfter = max(days(cfg.TrendAlertRetentionDays), cfg.TrendWindow)
+	}
+	return Policy{
+		StripIrrelevantAfter: days(cfg.RetentionStripIrrelevantDays),
+		ArchiveAfter:         days(cfg.RetentionArchiveDays),
+		DeleteAfter:          days(cfg.RetentionDeleteDays),
+		ArchiveDir:           cfg.RetentionArchiveDir,
+		AuditAfter:           days(cfg.AuditRetentionDays),
+		CacheAfter:           cacheAfter,
+		TrendAlertsAfter:     trendAlertsAfter,
+	}
+}
+
+// Enabled reports whether any rule is active.
+func (p Policy) Enabled() bool {
+	return p.StripIrrelevantAfter > 0 || p.ArchiveAfter > 0 || p.DeleteAfter > 0 || p.AuditAfter > 0 || p.CacheAfter > 0 || p.TrendAlertsAfter > 0
+}
+
+// Report summarizes how many rows each rule touched.
+type Report struct {
+	DryRun      bool      `json:"dry_run"`
+	StartedAt   time.Time `json:"started_at"`
+	Stripped    int64     `json:"stripped"`
+	Archived    int64     `json:"archived"`
+	Deleted     int64     `json:"deleted"`
+	AuditPurged int64     `json:"audit_purged"`
+	CachePurged int64     `json:"cache_purged"`
+	TrendPurged int64     `json:"trend_alerts_purged"`
+	ArchiveFile string    `json:"archive_file,omitempty"`
+}
+
+// Job applies a retention Policy to the store.
+type Job struct {
+	store  *storage.Store
+	policy Policy
+	logger *log.Logger
+}
+
+// NewJob creates a retention job.
+func NewJob(store *storage.Store, policy Policy, logger *log.Logger) *Job {
+	return &Job{store: store, policy: policy, logger: logger}
+}
+
+// Policy returns the policy the job enforces.
+func (j *Job) Policy() Policy {
+	return j.policy
+}
+
+// Run executes the enabled rules in order: strip, archive, delete, audit purge, cache purge, trend alert purge. With dryRun
+// nothing is modified and the report contains the rows each rule would touch.
+func (j *Job) Run(ctx context.Context, dryRun bool) (Report, error) {
+	now := time.Now()
+	report := Report{DryRun: dryRun, StartedAt: now}
+
+	if j.policy.StripIrrelevantAfter > 0 {
+		n, err := j.store.StripIrrelevantSummaries(ctx, now.Add(-j.policy.StripIrrelevantAfter), dryRun)
+		if err != nil {
+			return report, err
+		}
+		report.Stripped = n
+	}
+
+	if j.policy.ArchiveAfter > 0 {
+		n, file, err := j.archive(ctx, now, dryRun)
+		report.Archived, report.ArchiveFile = n, file
+		if err != nil {
+			return report, err
+		}
+	}
+
+	if j.policy.DeleteAfter > 0 {
+		// When archiving is on, never delete something that has not been archived.
+		var archiveBefore time.Time
+		if j.policy.ArchiveAfter > 0 {
+			archiveBefore = now.Add(-j.policy.ArchiveAfter)
+		}
+		n, err := j.store.DeleteItems(ctx, now.Add(-j.policy.DeleteAfter), archiveBefore, dryRun)
+		if err != nil {
+			return report, err
+		}
+		report.Deleted = n
+	}
+
+	if j.policy.AuditAfter > 0 {
+		n, err := j.store.PurgeLLMCalls(ctx, now.Add(-j.policy.AuditAfter), dryRun)
+		if err != nil {
+			return report, err
+		}
+		report.AuditPurged = n
+	}
+
+	if j.policy.CacheAfter > 0 {
+		n, err := j.store.PurgeAnalysisCache(ctx, now.Add(-j.policy.CacheAfter), dryRun)
+		if err != nil {
+			return report, err
+		}
+		report.CachePurged = n
+	}
+
+	if j.policy.TrendAlertsAfter > 0 {
+		n, err := j.store.PurgeTrendAlerts(ctx, now.Add(-j.policy.TrendAlertsAfter), dryRun)
+		if err != nil {
+			return report, err
+		}
+		report.TrendPurged = n
+	}
+
+	j.logger.Printf("retention run (dry_run=%t): stripped=%d archived=%d deleted=%d audit_purged=%d cache_purged=%d trend_alerts_purged=%d",
+		dryRun, report.Stripped, report.Archived, report.Deleted, report.AuditPurged, report.CachePurged, report.TrendPurged)
+	return report, nil
+}
+
+func (j *Job) archive(ctx context.Context, now time.Time, dryRun bool) (int64, string, error) {
+	before := now.Add(-j.policy.ArchiveAfter)
+	if dryRun {
+		n, err := j.store.ArchiveItems(ctx, before, true, nil)
+		return n, "", err
+	}
+
+	if err := os.MkdirAll(j.policy.ArchiveDir, 0o755); err != nil {
+		return 0, "", fmt.Errorf("create archive dir: %w", err)
+	}
+	path := filepath.Join(j.policy.ArchiveDir, fmt.Sprintf("news-archive-%s.jsonl.gz", now.Format("20060102T150405")))
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, "", fmt.Errorf("create archive file: %w", err)
+	}
+	gz := gzip.NewWriter(f)
+	enc := json.NewEncoder(gz)
+
+	n, archiveErr := j.store.ArchiveItems(ctx, before, false, func(item storage.ArchivedItem) error {
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+		// Flush per item so rows marked archived are always on disk.
+		return gz.Flush()
+	})
+	if err := gz.Close(); err != nil && archiveErr == nil {
+		archiveErr = fmt.Errorf("close archive: %w", err)
+	}
+	if err := f.Close(); err != nil && archiveErr == nil {
+		archiveErr = fmt.Errorf("close archive: %w", err)
+	}
+	if n == 0 && archiveErr == nil {
+		_ = os.Remove(path)
+		return 0, "", nil
+	}
+	return n, path, archiveErr
+}
+
+// Schedule runs the job every interval until ctx is cancelled.
+func (j *Job) Schedule(ctx context.Context, interval time.Duration, dryRun bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := j.Run(ctx, dryRun); err != nil {
+			j.logger.Printf("retention run failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
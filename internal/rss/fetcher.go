@@ -36,6 +36,16 @@ func NewFetcher(feedURL string, logger *log.Logger) *Fetcher {
 
 // Fetch pulls the feed and returns the parsed items.
 func (f *Fetcher) Fetch(ctx context.Context) ([]Item, error) {
+	return f.fetch(ctx, true)
+}
+
+// FetchAll pulls the feed and returns every entry, including non-newsletter
+// ones. It is used when backfilling from archive feeds.
+func (f *Fetcher) FetchAll(ctx context.Context) ([]Item, error) {
+	return f.fetch(ctx, false)
+}
+
+func (f *Fetcher) fetch(ctx context.Context, newslettersOnly bool) ([]Item, error) {
 	feed, err := f.parser.ParseURLWithContext(f.feedURL, ctx)
 	if err != nil {
 		return nil, err
@@ -49,7 +59,7 @@ func (f *Fetcher) Fetch(ctx context.Context) ([]Item, error) {
 		}
 		guid := pickGUID(entry)
 		// Only process newsletter items; skip others early.
-		if !strings.Contains(guid, "/newsletter/") && !strings.Contains(entry.Link, "/newsletter/") {
+		if newslettersOnly && !strings.Contains(guid, "/newsletter/") && !strings.Contains(entry.Link, "/newsletter/") {
 			continue
 		}
 		items = append(items, Item{
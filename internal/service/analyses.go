@@ -0,0 +1,138 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"aiweb3news/internal/storage"
+)
+
+// analysesHandler returns the full analysis history of an item.
+func (s *Service) analysesHandler(w http.ResponseWriter, r *http.Request, itemID int64) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	records, err := s.store.ListAnalyses(r.Context(), itemID)
+	if err != nil {
+		s.logger.Printf("list analyses failed for item %d: %v", itemID, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, struct {
+		Count    int                      `json:"count"`
+		Analyses []storage.AnalysisRecord `json:"analyses"`
+	}{
+		Count:    len(records),
+		Analyses: records,
+	})
+}
+
+// votesHandler returns the ensemble votes behind an item's analyses.
+func (s *Service) votesHandler(w http.ResponseWriter, r *http.Request, itemID int64) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	votes, err := s.store.ListVotes(r.Context(), itemID)
+	if err != nil {
+		s.logger.Printf("list votes failed for item %d: %v", itemID, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, struct {
+		Count int                  `json:"count"`
+		Votes []storage.StoredVote `json:"votes"`
+	}{
+		Count: len(votes),
+		Votes: votes,
+	})
+}
+
+// analysesDiffHandler compares two analyses of an item. Without from/to it
+// compares the current analysis against the one before it; with only from it
+// compares from against the current analysis, and with only to it compares
+// to against the analysis before it.
+func (s *Service) analysesDiffHandler(w http.ResponseWriter, r *http.Request, itemID int64) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	q := r.URL.Query()
+	fromID, errFrom := parseOptionalID(q.Get("from"))
+	toID, errTo := parseOptionalID(q.Get("to"))
+	if errFrom != nil || errTo != nil {
+		http.Error(w, "from and to must be analysis ids", http.StatusBadRequest)
+		return
+	}
+
+	var from, to storage.AnalysisRecord
+	if fromID != 0 && toID != 0 {
+		for _, pick := range []struct {
+			id  int64
+			dst *storage.AnalysisRecord
+		}{{fromID, &from}, {toID, &to}} {
+			rec, err := s.store.GetAnalysis(r.Context(), itemID, pick.id)
+			if errors.Is(err, storage.ErrNotFound) {
+				http.Error(w, "analysis not found", http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				s.logger.Printf("get analysis %d failed: %v", pick.id, err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			*pick.dst = rec
+		}
+		s.writeJSON(w, http.StatusOK, storage.DiffAnalyses(from, to))
+		return
+	}
+
+	// Records are newest first.
+	records, err := s.store.ListAnalyses(r.Context(), itemID)
+	if err != nil {
+		s.logger.Printf("list analyses failed for item %d: %v", itemID, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	index := func(id int64) int {
+		for i, rec := range records {
+			if rec.ID == id {
+				return i
+			}
+		}
+		return -1
+	}
+	switch {
+	case fromID != 0:
+		i := index(fromID)
+		if i < 0 {
+			http.Error(w, "analysis not found", http.StatusNotFound)
+			return
+		}
+		from, to = records[i], records[0]
+	case toID != 0:
+		i := index(toID)
+		if i < 0 {
+			http.Error(w, "analysis not found", http.StatusNotFound)
+			return
+		}
+		if i+1 == len(records) {
+			http.Error(w, "analysis has no earlier analysis", http.StatusNotFound)
+			return
+		}
+		from, to = records[i+1], records[i]
+	default:
+		if len(records) < 2 {
+			http.Error(w, "item has fewer than two analyses", http.StatusNotFound)
+			return
+		}
+		from, to = records[1], records[0]
+	}
+	s.writeJSON(w, http.StatusOK, storage.DiffAnalyses(from, to))
+}
+
+func parseOptionalID(v string) (int64, error) {
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(v, 10, 64)
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// itemRoutes dispatches /api/v1/items/{id}/... requests.
+func (s *Service) itemRoutes(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/items/"), "/"), "/")
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid item id", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case len(parts) == 2 && parts[1] == "entities":
+		s.itemEntitiesHandler(w, r, id)
+	case len(parts) == 2 && parts[1] == "related":
+		s.relatedHandler(w, r, id)
+	case len(parts) == 2 && parts[1] == "review":
+		s.requireAdmin(func(w http.ResponseWriter, r *http.Request) { s.reviewHandler(w, r, id) })(w, r)
+	case len(parts) == 2 && parts[1] == "feedback" && r.Method == http.MethodPost:
+		s.requireAdmin(func(w http.ResponseWriter, r *http.Request) { s.feedbackHandler(w, r, id) })(w, r)
+	case len(parts) == 2 && parts[1] == "feedback":
+		s.feedbackHandler(w, r, id)
+	case len(parts) == 2 && parts[1] == "analyses":
+		s.analysesHandler(w, r, id)
+	case len(parts) == 3 && parts[1] == "analyses" && parts[2] == "diff":
+		s.analysesDiffHandler(w, r, id)
+	case len(parts) == 2 && parts[1] == "votes":
+		s.votesHandler(w, r, id)
+	case len(parts) == 2 && parts[1] == "watchlist":
+		s.itemWatchlistHandler(w, r, id)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Service) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.logger.Printf("write json response failed: %v", err)
+	}
+}
+
+// requireAdmin guards admin endpoints with ADMIN_TOKEN. Without a token they
+// are disabled rather than open.
+func (s *Service) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.cfg.AdminToken == "" {
+			http.Error(w, "admin endpoints are disabled, set ADMIN_TOKEN", http.StatusForbidden)
+			return
+		}
+		want := []byte("Bearer " + s.cfg.AdminToken)
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	return true
+}
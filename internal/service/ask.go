@@ -0,0 +1,154 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"aiweb3news/internal/analysis"
+	"aiweb3news/internal/storage"
+)
+
+const (
+	defaultAskSources = 8
+	maxAskSources     = 20
+	// maxAskBody bounds the request body; a question is a sentence or two.
+	maxAskBody = 16 << 10
+)
+
+// citationRef matches inline citation markers such as [3].
+var citationRef = regexp.MustCompile(`\[(\d+)\]`)
+
+// citation is a stored item an answer refers to.
+type citation struct {
+	Ref         int       `json:"ref"`
+	ItemID      int64     `json:"item_id"`
+	GUID        string    `json:"guid"`
+	Title       string    `json:"title"`
+	Link        string    `json:"link"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// askHandler answers a question from stored items: retrieve by semantic
+// search, let the model compose an answer citing [n] sources, then keep only
+// citations that point at retrieved items.
+func (s *Service) askHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	var req struct {
+		Question string `json:"question"`
+		Limit    int    `json:"limit"`
+		Since    string `json:"since"`
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxAskBody)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Question) == "" {
+		http.Error(w, `body must be {"question":"..."}`, http.StatusBadRequest)
+		return
+	}
+	limit := defaultAskSources
+	if req.Limit > 0 {
+		limit = min(req.Limit, maxAskSources)
+	}
+	since, err := storage.ParseFilterTime(req.Since)
+	if err != nil {
+		http.Error(w, "invalid since", http.StatusBadRequest)
+		return
+	}
+	if s.llm == nil || !s.llm.Ready() {
+		http.Error(w, "model unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	ctx := r.Context()
+	if s.overBudget(ctx) {
+		http.Error(w, "daily model budget exhausted", http.StatusTooManyRequests)
+		return
+	}
+
+	// Over-fetch so the since filter still leaves enough sources.
+	hits, err := s.semanticSearch(ctx, req.Question, limit*3, 0, "")
+	if err != nil {
+		s.logger.Printf("ask retrieval failed: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	var items []storage.StoredItem
+	for _, h := range hits {
+		if len(items) == limit {
+			break
+		}
+		if !since.IsZero() && h.Item.PublishedAt.Before(since) {
+			continue
+		}
+		items = append(items, h.Item)
+	}
+	if len(items) == 0 {
+		s.writeJSON(w, http.StatusOK, map[string]any{
+			"question":  req.Question,
+			"answer":    "没有找到相关资讯。",
+			"citations": []citation{},
+		})
+		return
+	}
+
+	sources := make([]analysis.Source, len(items))
+	for i, item := range items {
+		sources[i] = analysis.Source{Ref: i + 1, Title: item.Title, Link: item.Link, PublishedAt: item.PublishedAt, Summary: item.Summary}
+	}
+	answer, err := s.llm.Ask(ctx, req.Question, sources)
+	s.recordCall(ctx, "", answer.Meta, err)
+	if err != nil {
+		s.logger.Printf("ask failed: %v", err)
+		http.Error(w, "answer failed", http.StatusBadGateway)
+		return
+	}
+
+	text, refs := checkCitations(answer, len(items))
+	citations := make([]citation, 0, len(refs))
+	for _, ref := range refs {
+		item := items[ref-1]
+		citations = append(citations, citation{
+			Ref:         ref,
+			ItemID:      item.ID,
+			GUID:        item.GUID,
+			Title:       item.Title,
+			Link:        item.Link,
+			PublishedAt: item.PublishedAt,
+		})
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"question":  req.Question,
+		"answer":    text,
+		"citations": citations,
+	})
+}
+
+// checkCitations drops markers referring to sources that were not provided
+// and returns the cleaned answer with the sorted set of valid references.
+func checkCitations(answer analysis.Answer, n int) (string, []int) {
+	valid := func(ref int) bool { return ref >= 1 && ref <= n }
+	seen := make(map[int]bool)
+	text := citationRef.ReplaceAllStringFunc(answer.Answer, func(m string) string {
+		ref, _ := strconv.Atoi(m[1 : len(m)-1])
+		if !valid(ref) {
+			return ""
+		}
+		seen[ref] = true
+		return m
+	})
+	for _, ref := range answer.Citations {
+		if valid(ref) {
+			seen[ref] = true
+		}
+	}
+	refs := make([]int, 0, len(seen))
+	for ref := range seen {
+		refs = append(refs, ref)
+	}
+	sort.Ints(refs)
+	return text, refs
+}
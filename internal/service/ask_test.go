@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log"
+	"reflect"
+	"testing"
+
+	"aiweb3news/internal/analysis"
+)
+
+func TestCheckCitations(t *testing.T) {
+	tests := []struct {
+		name     string
+		answer   analysis.Answer
+		n        int
+		wantText string
+		wantRefs []int
+	}{
+		{
+			name:     "inline and listed refs are merged",
+			answer:   analysis.Answer{Answer: "A [2] and B [1].", Citations: []int{1, 3}},
+			n:        3,
+			wantText: "A [2] and B [1].",
+			wantRefs: []int{1, 2, 3},
+		},
+		{
+			name:     "out of range markers are dropped",
+			answer:   analysis.Answer{Answer: "A [0] B [4] C [2]", Citations: []int{5, -1}},
+			n:        3,
+			wantText: "A  B  C [2]",
+			wantRefs: []int{2},
+		},
+		{
+			name:     "no sources",
+			answer:   analysis.Answer{Answer: "资料不足 [1]", Citations: []int{1}},
+			n:        0,
+			wantText: "资料不足 ",
+			wantRefs: []int{},
+		},
+		{
+			name:     "duplicates collapse",
+			answer:   analysis.Answer{Answer: "[1][1]", Citations: []int{1, 1}},
+			n:        1,
+			wantText: "[1][1]",
+			wantRefs: []int{1},
+		},
+	}
+	for _, tt := range tests {
+		text, refs := checkCitations(tt.answer, tt.n)
+		if text != tt.wantText || !reflect.DeepEqual(refs, tt.wantRefs) {
+			t.Errorf("%s: got %q %v, want %q %v", tt.name, text, refs, tt.wantText, tt.wantRefs)
+		}
+	}
+}
+
+func TestCheckCitationsStubAnswer(t *testing.T) {
+	llm := analysis.NewStubClient("stub", log.New(io.Discard, "", 0))
+	sources := []analysis.Source{
+		{Ref: 1, Title: "ETF 获批", Summary: "现货 ETF 获批。"},
+		{Ref: 2, Title: "ETF 资金流入", Summary: "首日净流入。"},
+	}
+	answer, err := llm.Ask(context.Background(), "ETF 进展如何？", sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		n        int
+		wantText string
+		wantRefs []int
+	}{
+		{"every cited source provided", 2, "（stub）相关进展见所引资讯 [1] [2]。", []int{1, 2}},
+		{"second source withdrawn", 1, "（stub）相关进展见所引资讯 [1] 。", []int{1}},
+	}
+	for _, tt := range tests {
+		text, refs := checkCitations(answer, tt.n)
+		if text != tt.wantText || !reflect.DeepEqual(refs, tt.wantRefs) {
+			t.Errorf("%s: got %q %v, want %q %v", tt.name, text, refs, tt.wantText, tt.wantRefs)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"aiweb3news/internal/analysis"
+	"aiweb3news/internal/storage"
+)
+
+// recordCall persists the raw payload of a model call for later audit. Calls
+// that never reached the model (no model name recorded) are skipped.
+func (s *Service) recordCall(ctx context.Context, guid string, meta analysis.Meta, callErr error) {
+	// Cached answers made no call.
+	if meta.Model == "" || !meta.CachedAt.IsZero() {
+		return
+	}
+	cost := s.recordUsage(ctx, meta, callErr)
+	if !s.cfg.AuditEnabled {
+		return
+	}
+	if err := s.store.SaveLLMCall(ctx, guid, meta, callErr, cost, s.cfg.AuditCompress); err != nil {
+		s.logger.Printf("audit llm call failed for %s: %v", guid, err)
+	}
+}
+
+// adminItemRoutes dispatches /api/v1/admin/items/{id}/... requests.
+func (s *Service) adminItemRoutes(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/admin/items/"), "/"), "/")
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid item id", http.StatusBadRequest)
+		return
+	}
+	if len(parts) == 2 && parts[1] == "llm-calls" {
+		s.llmCallsHandler(w, r, id)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+// llmCallsHandler returns every audited model call made for an item.
+func (s *Service) llmCallsHandler(w http.ResponseWriter, r *http.Request, itemID int64) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	item, err := s.store.GetItem(r.Context(), itemID)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "item not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.logger.Printf("get item %d failed: %v", itemID, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeLLMCalls(w, r, item.GUID)
+}
+
+// llmCallsByGUIDHandler looks calls up by guid, which also covers items whose
+// analysis failed and therefore never got a news_analysis row.
+func (s *Service) llmCallsByGUIDHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	guid := r.URL.Query().Get("guid")
+	if guid == "" {
+		http.Error(w, "guid is required", http.StatusBadRequest)
+		return
+	}
+	s.writeLLMCalls(w, r, guid)
+}
+
+func (s *Service) writeLLMCalls(w http.ResponseWriter, r *http.Request, guid string) {
+	calls, err := s.store.ListLLMCalls(r.Context(), guid)
+	if err != nil {
+		s.logger.Printf("list llm calls failed for %s: %v", guid, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, struct {
+		Count int               `json:"count"`
+		Calls []storage.LLMCall `json:"calls"`
+	}{
+		Count: len(calls),
+		Calls: calls,
+	})
+}
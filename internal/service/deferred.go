@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"aiweb3news/internal/analysis"
+	"aiweb3news/internal/metrics"
+	"aiweb3news/internal/rss"
+)
+
+const (
+	// deferredBatch bounds how many deferred items one poll retries.
+	deferredBatch = 100
+	// maxDeferredAttempts gives up on items that keep failing for reasons
+	// other than an open circuit breaker.
+	maxDeferredAttempts = 5
+)
+
+var deferredItems = metrics.NewGauge("aiweb3news_deferred_items",
+	"Items waiting to be analyzed because the model provider was unavailable.")
+
+// deferItem queues an item the pipeline could not analyze now. Only
+// failures other than an open circuit breaker count as attempts.
+func (s *Service) deferItem(ctx context.Context, item rss.Item, push bool, cause error) {
+	if err := s.store.DeferItem(ctx, item, push, cause.Error(), !errors.Is(cause, analysis.ErrBreakerOpen)); err != nil {
+		s.logger.Printf("defer %s failed: %v", item.Title, err)
+	}
+}
+
+// drainDeferred retries deferred items, oldest first, and stops as soon as
+// the circuit breaker refuses calls again.
+func (s *Service) drainDeferred(ctx context.Context) {
+	defer s.countDeferred(ctx)
+	items, err := s.store.DeferredItems(ctx, deferredBatch)
+	if err != nil {
+		s.logger.Printf("list deferred items failed: %v", err)
+		return
+	}
+	if len(items) > 0 {
+		s.logger.Printf("retrying %d deferred items", len(items))
+	}
+	for _, d := range items {
+		exists, err := s.store.Exists(ctx, d.Item.GUID)
+		if err != nil {
+			s.logger.Printf("check exists failed for %s: %v", d.Item.GUID, err)
+			continue
+		}
+		if !exists {
+			err = s.Ingest(ctx, d.Item, d.Push)
+		}
+		switch {
+		case err == nil:
+		case errors.Is(err, analysis.ErrBreakerOpen):
+			s.logger.Printf("model provider still unavailable, keeping deferred items: %v", err)
+			return
+		case d.Attempts+1 >= maxDeferredAttempts:
+			s.logger.Printf("giving up on deferred %s after %d attempts: %v", d.Item.Title, d.Attempts+1, err)
+		default:
+			s.logger.Printf("deferred %s failed again: %v", d.Item.Title, err)
+			s.deferItem(ctx, d.Item, d.Push, err)
+			continue
+		}
+		if err := s.store.DeleteDeferred(ctx, d.Item.GUID); err != nil {
+			s.logger.Printf("%v", err)
+		}
+	}
+}
+
+func (s *Service) countDeferred(ctx context.Context) {
+	n, err := s.store.CountDeferred(ctx)
+	if err != nil {
+		s.logger.Printf("count deferred items failed: %v", err)
+		return
+	}
+	deferredItems.Set(float64(n))
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"aiweb3news/internal/analysis"
+	"aiweb3news/internal/config"
+	"aiweb3news/internal/embedding"
+	"aiweb3news/internal/storage"
+)
+
+// maxRelated caps related items and semantic search results.
+const maxRelated = 50
+
+// newEmbedder picks the embedding provider from the config. Without an API
+// key "auto" falls back to local hashed vectors.
+func newEmbedder(cfg config.Config, llm *analysis.Client) embedding.Embedder {
+	// Stub vectors are only good for offline tests; keep them out of "auto".
+	remote := llm != nil && llm.Ready() && llm.Provider() == analysis.ProviderOpenAI
+	switch cfg.EmbeddingProvider {
+	case "openai":
+		if llm != nil && llm.Ready() {
+			return embedding.NewRemote(llm, cfg.EmbeddingModel)
+		}
+	case "local":
+		return embedding.Local{}
+	case "auto", "":
+		if remote {
+			return embedding.NewRemote(llm, cfg.EmbeddingModel)
+		}
+	}
+	return embedding.Local{}
+}
+
+// loadIndex rebuilds the in-memory vector index from the database, keeping
+// the items published within EMBEDDING_INDEX_DAYS. A failed load keeps the
+// current index.
+func (s *Service) loadIndex(ctx context.Context) {
+	fresh := embedding.NewIndex()
+	mark := s.index.Mark()
+	n, err := s.store.LoadEmbeddings(ctx, s.embedder.Name(), time.Now().Add(-s.cfg.EmbeddingIndexMaxAge), fresh.Add)
+	if err != nil {
+		s.logger.Printf("load embeddings failed: %v", err)
+		return
+	}
+	s.index.Replace(fresh, mark)
+	s.logger.Printf("loaded %d %s embeddings", n, s.embedder.Name())
+}
+
+// refreshIndex reloads the vector index every interval until ctx is done, so
+// vectors backfilled by the embed command are searched and old or deleted
+// items drop out.
+func (s *Service) refreshIndex(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.loadIndex(ctx)
+		}
+	}
+}
+
+// embedItem stores and indexes the vector of an item. Unchanged content is
+// not embedded again. It returns the indexed vector, nil on failure.
+func (s *Service) embedItem(ctx context.Context, itemID int64, guid, title, summary string) []float32 {
+	text := embedding.Text(title, summary)
+	sum := sha256.Sum256([]byte(text))
+	hash := hex.EncodeToString(sum[:])
+	if stored, err := s.store.EmbeddingHash(ctx, itemID, s.embedder.Name()); err == nil && stored == hash {
+		if vec, ok := s.index.Get(itemID); ok {
+			return vec
+		}
+	}
+
+	if _, remote := s.embedder.(*embedding.Remote); remote && s.overBudget(ctx) {
+		return nil
+	}
+	vectors, meta, err := s.embedder.Embed(ctx, []string{text})
+	s.recordCall(ctx, guid, meta, err)
+	if err != nil {
+		s.logger.Printf("embedding %s failed: %v", title, err)
+		return nil
+	}
+	if err := s.store.SaveEmbedding(ctx, itemID, s.embedder.Name(), hash, vectors[0]); err != nil {
+		s.logger.Printf("save embedding for %s failed: %v", title, err)
+		return nil
+	}
+	s.index.Add(itemID, vectors[0])
+	vec, _ := s.index.Get(itemID)
+	return vec
+}
+
+// BackfillEmbeddings embeds stored items that have no vector from the current
+// embedder, batch items at a time. It returns how many items were embedded.
+func (s *Service) BackfillEmbeddings(ctx context.Context, batch int) (int, error) {
+	var (
+		total  int
+		lastID int64
+	)
+	for {
+		items, err := s.store.ItemsWithoutEmbedding(ctx, s.embedder.Name(), lastID, batch)
+		if err != nil {
+			return total, err
+		}
+		if len(items) == 0 {
+			return total, nil
+		}
+		texts := make([]string, len(items))
+		for i, item := range items {
+			texts[i] = embedding.Text(item.Title, item.Summary)
+		}
+		vectors, meta, err := s.embedder.Embed(ctx, texts)
+		s.recordCall(ctx, "", meta, err)
+		if err != nil {
+			return total, fmt.Errorf("embed batch after %d: %w", lastID, err)
+		}
+		for i, item := range items {
+			sum := sha256.Sum256([]byte(texts[i]))
+			if err := s.store.SaveEmbedding(ctx, item.ID, s.embedder.Name(), hex.EncodeToString(sum[:]), vectors[i]); err != nil {
+				return total, err
+			}
+			s.index.Add(item.ID, vectors[i])
+			total++
+			lastID = item.ID
+		}
+		s.logger.Printf("embedded %d items", total)
+	}
+}
+
+// scoredItem is an item with its similarity to a query.
+type scoredItem struct {
+	Score float64            `json:"score"`
+	Item  storage.StoredItem `json:"item"`
+}
+
+// resolveHits loads the items behind index hits, dropping any that no longer exist.
+func (s *Service) resolveHits(ctx context.Context, hits []embedding.Hit, lang string) ([]scoredItem, error) {
+	out := make([]scoredItem, 0, len(hits))
+	for _, h := range hits {
+		item, err := s.store.GetItem(ctx, h.ItemID)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, scoredItem{Score: h.Score, Item: localize(item, lang)})
+	}
+	return out, nil
+}
+
+// relatedHandler returns the items semantically closest to an item.
+func (s *Service) relatedHandler(w http.ResponseWriter, r *http.Request, itemID int64) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	limit, minScore, ok := parseSearchParams(w, r)
+	if !ok {
+		return
+	}
+	lang, ok := parseLang(w, r)
+	if !ok {
+		return
+	}
+	vec, found := s.index.Get(itemID)
+	if !found {
+		item, err := s.store.GetItem(r.Context(), itemID)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "item not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			s.logger.Printf("get item %d failed: %v", itemID, err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		if vec = s.embedItem(r.Context(), itemID, item.GUID, item.Title, item.Summary); vec == nil {
+			http.Error(w, "embedding unavailable", http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	related, err := s.resolveHits(r.Context(), s.index.Search(vec, limit, itemID, minScore), lang)
+	if err != nil {
+		s.logger.Printf("related items for %d failed: %v", itemID, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{"item_id": itemID, "related": related})
+}
+
+// searchHandler runs a semantic search for ?q=.
+func (s *Service) searchHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "missing q", http.StatusBadRequest)
+		return
+	}
+	limit, minScore, ok := parseSearchParams(w, r)
+	if !ok {
+		return
+	}
+	lang, ok := parseLang(w, r)
+	if !ok {
+		return
+	}
+	results, err := s.semanticSearch(r.Context(), query, limit, minScore, lang)
+	if err != nil {
+		s.logger.Printf("semantic search failed: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{"query": query, "count": len(results), "results": results})
+}
+
+func (s *Service) semanticSearch(ctx context.Context, query string, limit int, minScore float64, lang string) ([]scoredItem, error) {
+	vectors, meta, err := s.embedder.Embed(ctx, []string{query})
+	s.recordCall(ctx, "", meta, err)
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	return s.resolveHits(ctx, s.index.Search(vectors[0], limit, 0, minScore), lang)
+}
+
+func parseSearchParams(w http.ResponseWriter, r *http.Request) (int, float64, bool) {
+	q := r.URL.Query()
+	limit := 10
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return 0, 0, false
+		}
+		limit = min(n, maxRelated)
+	}
+	var minScore float64
+	if v := q.Get("min_score"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < -1 || f > 1 {
+			http.Error(w, "invalid min_score", http.StatusBadRequest)
+			return 0, 0, false
+		}
+		minScore = f
+	}
+	return limit, minScore, true
+}
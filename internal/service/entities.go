@@ -0,0 +1,63 @@
+package service
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"aiweb3news/internal/storage"
+)
+
+// entitiesHandler lists normalized entities by how many items mention them.
+func (s *Service) entitiesHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	q := r.URL.Query()
+	filter := storage.EntityFilter{
+		Type:  strings.TrimSpace(q.Get("type")),
+		Query: strings.TrimSpace(q.Get("q")),
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = min(n, maxListLimit)
+	}
+	since, err := storage.ParseFilterTime(q.Get("since"))
+	if err != nil {
+		http.Error(w, "invalid since", http.StatusBadRequest)
+		return
+	}
+	filter.Since = since
+
+	counts, err := s.store.ListEntities(r.Context(), filter)
+	if err != nil {
+		s.logger.Printf("list entities failed: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, struct {
+		Count    int                   `json:"count"`
+		Entities []storage.EntityCount `json:"entities"`
+	}{
+		Count:    len(counts),
+		Entities: counts,
+	})
+}
+
+// itemEntitiesHandler returns the entities extracted from one item.
+func (s *Service) itemEntitiesHandler(w http.ResponseWriter, r *http.Request, itemID int64) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	entities, err := s.store.ItemEntities(r.Context(), itemID)
+	if err != nil {
+		s.logger.Printf("item entities failed for %d: %v", itemID, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{"item_id": itemID, "entities": entities})
+}
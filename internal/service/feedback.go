@@ -0,0 +1,181 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"aiweb3news/internal/analysis"
+	"aiweb3news/internal/eval"
+	"aiweb3news/internal/fewshot"
+	"aiweb3news/internal/storage"
+)
+
+// labelCategories are the categories analysts may assign to relevant items.
+var labelCategories = []string{
+	analysis.CategoryRegulation,
+	analysis.CategoryInstitutions,
+	analysis.CategoryInfrastructure,
+	analysis.CategoryRWAPayments,
+	analysis.CategoryFinancing,
+	analysis.CategoryEmerging,
+}
+
+// feedbackHandler records an analyst's label for an item (POST) or lists
+// the labels recorded so far (GET). Labels steer the classifier as few-shot
+// examples, so recording one takes the admin token; user names the analyst
+// but is not verified beyond that.
+func (s *Service) feedbackHandler(w http.ResponseWriter, r *http.Request, itemID int64) {
+	switch r.Method {
+	case http.MethodGet:
+		labels, err := s.store.ListFeedback(r.Context(), itemID)
+		if err != nil {
+			s.logger.Printf("list feedback for item %d failed: %v", itemID, err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, struct {
+			Count    int                `json:"count"`
+			Feedback []storage.Feedback `json:"feedback"`
+		}{
+			Count:    len(labels),
+			Feedback: labels,
+		})
+		return
+	case http.MethodPost:
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		User     string `json:"user"`
+		Relevant *bool  `json:"relevant"`
+		Category string `json:"category"`
+		Comment  string `json:"comment"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Relevant == nil || strings.TrimSpace(req.User) == "" {
+		http.Error(w, `body must be {"user":"...","relevant":true|false,"category":"...","comment":"..."}`, http.StatusBadRequest)
+		return
+	}
+	category := ""
+	if *req.Relevant && strings.TrimSpace(req.Category) != "" {
+		category = analysis.CanonicalCategory(req.Category)
+		if !slices.Contains(labelCategories, category) {
+			http.Error(w, "unknown category", http.StatusBadRequest)
+			return
+		}
+	}
+
+	item, err := s.store.GetItem(r.Context(), itemID)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "item not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.logger.Printf("get item %d failed: %v", itemID, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	fb := storage.Feedback{
+		ItemID:        itemID,
+		User:          strings.TrimSpace(req.User),
+		Relevant:      *req.Relevant,
+		Category:      category,
+		Comment:       strings.TrimSpace(req.Comment),
+		ModelRelevant: item.Relevant,
+		ModelCategory: item.Category,
+		Agrees:        agrees(*req.Relevant, category, item.Relevant, item.Category),
+	}
+	if fb.ID, err = s.store.SaveFeedback(r.Context(), fb); err != nil {
+		s.logger.Printf("save feedback for item %d failed: %v", itemID, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.examples.Put(fewshot.FromLabel(storage.LabeledItem{
+		ItemID:   itemID,
+		Title:    item.Title,
+		Summary:  item.Summary,
+		Relevant: fb.Relevant,
+		Category: fb.Category,
+		Comment:  fb.Comment,
+	}))
+	s.writeJSON(w, http.StatusCreated, fb)
+}
+
+// agrees reports whether the model answer matches a label. An unlabeled
+// category only grades relevance.
+func agrees(relevant bool, category string, modelRelevant bool, modelCategory string) bool {
+	if relevant != modelRelevant {
+		return false
+	}
+	return !relevant || category == "" || category == analysis.CanonicalCategory(modelCategory)
+}
+
+// feedbackAgreementHandler reports how often analysts agree with the model,
+// per ?period=week|month (default month) since ?since= (default 180 days).
+func (s *Service) feedbackAgreementHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	q := r.URL.Query()
+	period := q.Get("period")
+	switch period {
+	case "":
+		period = "month"
+	case "week", "month":
+	default:
+		http.Error(w, "period must be week or month", http.StatusBadRequest)
+		return
+	}
+	since, err := storage.ParseFilterTime(q.Get("since"))
+	if err != nil {
+		http.Error(w, "invalid since", http.StatusBadRequest)
+		return
+	}
+	if since.IsZero() {
+		since = time.Now().AddDate(0, 0, -180)
+	}
+
+	periods, err := s.store.FeedbackAgreement(r.Context(), period, since)
+	if err != nil {
+		s.logger.Printf("feedback agreement failed: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, struct {
+		Period  string                    `json:"period"`
+		Periods []storage.AgreementPeriod `json:"periods"`
+	}{
+		Period:  period,
+		Periods: periods,
+	})
+}
+
+// feedbackDatasetHandler streams the latest label of every labeled item as
+// the JSONL golden dataset read by the eval command.
+func (s *Service) feedbackDatasetHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	labeled, err := s.store.LabeledItems(r.Context(), 0)
+	if err != nil {
+		s.logger.Printf("load labeled items failed: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="golden.jsonl"`)
+	enc := json.NewEncoder(w)
+	for _, l := range labeled {
+		if err := enc.Encode(eval.LabeledCase(l)); err != nil {
+			s.logger.Printf("write dataset failed: %v", err)
+			return
+		}
+	}
+}
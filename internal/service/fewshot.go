@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"aiweb3news/internal/analysis"
+	"aiweb3news/internal/fewshot"
+)
+
+// exampleLoadTimeout bounds loading the pool, which does not use the context
+// of the item that triggered it.
+const exampleLoadTimeout = 30 * time.Second
+
+// loadExamples fills the few-shot pool from the stored labels unless that
+// already succeeded. A failed load is retried on the next call.
+func (s *Service) loadExamples() {
+	s.examplesMu.Lock()
+	defer s.examplesMu.Unlock()
+	if s.examplesLoaded {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), exampleLoadTimeout)
+	defer cancel()
+	labeled, err := s.store.LabeledItems(ctx, 0)
+	if err != nil {
+		s.logger.Printf("load few-shot examples failed: %v", err)
+		return
+	}
+	for _, l := range labeled {
+		s.examples.Put(fewshot.FromLabel(l))
+	}
+	s.examplesLoaded = true
+	s.logger.Printf("loaded %d few-shot examples", len(labeled))
+}
+
+// fewShot picks the labeled items shown to the classifier for an item; itemID
+// is the stored item being re-analyzed, 0 for a new one. The pool is loaded
+// on first use so every command running the pipeline gets it.
+func (s *Service) fewShot(itemID int64, title, summary string) []analysis.Example {
+	if !s.cfg.FewShotEnabled {
+		return nil
+	}
+	s.loadExamples()
+	return s.examples.Similar(itemID, title, summary, s.cfg.FewShotExamples, s.cfg.FewShotMaxChars)
+}
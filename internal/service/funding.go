@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"aiweb3news/internal/analysis"
+	"aiweb3news/internal/entity"
+	"aiweb3news/internal/rss"
+	"aiweb3news/internal/storage"
+)
+
+// trackFunding extracts the financing details of a financing item and files
+// them under a deduplicated funding round. Failures are logged only: the
+// item itself is already stored and pushed independently.
+func (s *Service) trackFunding(ctx context.Context, itemID int64, item rss.Item) {
+	if s.llm == nil || !s.llm.Ready() || s.overBudget(ctx) {
+		return
+	}
+	funding, err := s.llm.ExtractFunding(ctx, itemContext(item))
+	s.recordCall(ctx, item.GUID, funding.Meta, err)
+	if err != nil {
+		s.logger.Printf("funding extraction failed for %s: %v", item.Title, err)
+		return
+	}
+	if !funding.IsFunding || strings.TrimSpace(funding.Project) == "" {
+		return
+	}
+
+	round := storage.FundingRound{
+		Project:       s.entities.Canonical(analysis.EntityOrganization, funding.Project),
+		AmountUSD:     max(funding.AmountUSD, 0),
+		Round:         entity.NormalizeRound(funding.Round),
+		Sector:        strings.TrimSpace(funding.Sector),
+		AnnouncedOn:   item.PublishedAt,
+		LeadInvestors: s.canonicalOrgs(funding.LeadInvestors),
+		Investors:     s.canonicalOrgs(funding.Investors),
+	}
+	if d, err := time.Parse("2006-01-02", funding.Date); err == nil {
+		round.AnnouncedOn = d
+	}
+	if round.AnnouncedOn.IsZero() {
+		round.AnnouncedOn = time.Now()
+	}
+	if _, err := s.store.SaveFundingRound(ctx, itemID, round); err != nil {
+		s.logger.Printf("save funding round failed for %s: %v", item.Title, err)
+	}
+}
+
+func (s *Service) canonicalOrgs(names []string) []string {
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			out = append(out, s.entities.Canonical(analysis.EntityOrganization, name))
+		}
+	}
+	return out
+}
+
+// fundingHandler lists tracked funding rounds.
+func (s *Service) fundingHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	filter, ok := parseFundingFilter(w, r)
+	if !ok {
+		return
+	}
+	rounds, err := s.store.ListFundingRounds(r.Context(), filter)
+	if err != nil {
+		s.logger.Printf("list funding rounds failed: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, struct {
+		Count  int                    `json:"count"`
+		Rounds []storage.FundingRound `json:"rounds"`
+	}{
+		Count:  len(rounds),
+		Rounds: rounds,
+	})
+}
+
+// fundingStatsHandler aggregates funding rounds by sector, investor or month.
+func (s *Service) fundingStatsHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	by := r.URL.Query().Get("by")
+	switch by {
+	case "":
+		by = "sector"
+	case "sector", "investor", "month":
+	default:
+		http.Error(w, "by must be sector, investor or month", http.StatusBadRequest)
+		return
+	}
+	filter, ok := parseFundingFilter(w, r)
+	if !ok {
+		return
+	}
+	stats, err := s.store.AggregateFunding(r.Context(), filter, by)
+	if err != nil {
+		s.logger.Printf("aggregate funding failed: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, struct {
+		By      string                     `json:"by"`
+		Buckets []storage.FundingAggregate `json:"buckets"`
+	}{
+		By:      by,
+		Buckets: stats,
+	})
+}
+
+func parseFundingFilter(w http.ResponseWriter, r *http.Request) (storage.FundingFilter, bool) {
+	q := r.URL.Query()
+	filter := storage.FundingFilter{
+		Sector:   strings.TrimSpace(q.Get("sector")),
+		Investor: strings.TrimSpace(q.Get("investor")),
+		Round:    entity.NormalizeRound(q.Get("round")),
+	}
+	var err error
+	if filter.Since, err = storage.ParseFilterTime(q.Get("since")); err != nil {
+		http.Error(w, "invalid since", http.StatusBadRequest)
+		return filter, false
+	}
+	if filter.Until, err = storage.ParseFilterTime(q.Get("until")); err != nil {
+		http.Error(w, "invalid until", http.StatusBadRequest)
+		return filter, false
+	}
+	if v := q.Get("min_amount"); v != "" {
+		if filter.MinAmount, err = strconv.ParseFloat(v, 64); err != nil || filter.MinAmount < 0 {
+			http.Error(w, "invalid min_amount", http.StatusBadRequest)
+			return filter, false
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return filter, false
+		}
+		filter.Limit = min(n, maxListLimit)
+	}
+	return filter, true
+}
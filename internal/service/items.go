@@ -0,0 +1,150 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"aiweb3news/internal/export"
+	"aiweb3news/internal/notify"
+	"aiweb3news/internal/storage"
+)
+
+const maxListLimit = 500
+
+// listItemsHandler serves GET /api/v1/items with the filters understood by storage.ParseItemFilter.
+func (s *Service) listItemsHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	filter, err := storage.ParseItemFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	lang, ok := parseLang(w, r)
+	if !ok {
+		return
+	}
+	if filter.Limit == 0 {
+		filter.Limit = s.cfg.MaxItems
+	}
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+
+	items, err := s.store.ListItems(r.Context(), filter)
+	if err != nil {
+		s.logger.Printf("list items failed: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	localizeAll(items, lang)
+	s.writeJSON(w, http.StatusOK, struct {
+		Count int                  `json:"count"`
+		Items []storage.StoredItem `json:"items"`
+	}{
+		Count: len(items),
+		Items: items,
+	})
+}
+
+// exportHandler streams every item matching the list filters as CSV, JSONL or XLSX.
+func (s *Service) exportHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	format, err := export.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := storage.ParseItemFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	lang, ok := parseLang(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="news-%s.%s"`, time.Now().Format("20060102"), format))
+	ew, err := export.NewWriter(format, w)
+	if err != nil {
+		s.logger.Printf("start export failed: %v", err)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	count := 0
+	err = s.store.StreamItems(r.Context(), filter, func(item storage.StoredItem) error {
+		if err := ew.Write(localize(item, lang)); err != nil {
+			return err
+		}
+		count++
+		if flusher != nil && count%500 == 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		// Headers are already sent; the truncated body is the only signal left.
+		s.logger.Printf("export failed after %d rows: %v", count, err)
+		return
+	}
+	if err := ew.Close(); err != nil {
+		s.logger.Printf("finish export failed: %v", err)
+		return
+	}
+	s.logger.Printf("exported %d items as %s", count, format)
+}
+
+// reviewHandler resolves an item held for human review. "approve" pushes a
+// relevant item the way the pipeline would have; "dismiss" only clears the flag.
+func (s *Service) reviewHandler(w http.ResponseWriter, r *http.Request, itemID int64) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	var req struct {
+		Action string `json:"action"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.Action != "approve" && req.Action != "dismiss") {
+		http.Error(w, `body must be {"action":"approve"|"dismiss"}`, http.StatusBadRequest)
+		return
+	}
+
+	item, err := s.store.GetItem(r.Context(), itemID)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "item not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.logger.Printf("get item %d failed: %v", itemID, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if err := s.store.ClearReview(r.Context(), itemID); err != nil {
+		s.logger.Printf("clear review for item %d failed: %v", itemID, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	notified := false
+	if req.Action == "approve" && item.NeedsReview && item.Relevant {
+		notified = s.pushItem(r.Context(), item.StoryID, notify.Message{
+			Title:      item.Title,
+			Link:       item.Link,
+			Category:   item.Category,
+			Reason:     item.Reason,
+			Importance: item.Importance,
+			SummaryZH:  item.SummaryZH,
+			TitleEN:    item.TitleEN,
+			SummaryEN:  item.SummaryEN,
+		})
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{"id": itemID, "action": req.Action, "notified": notified})
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"aiweb3news/internal/analysis"
+	"aiweb3news/internal/prefilter"
+	"aiweb3news/internal/rss"
+	"aiweb3news/internal/storage"
+)
+
+// Model and prompt version recorded for analyses decided by the prefilter.
+const (
+	ruleModel         = "rules"
+	rulePromptVersion = "prefilter"
+)
+
+// prefilter scores an item with the rules and reports whether the verdict
+// replaces the model call, which only happens in enforce mode.
+func (s *Service) prefilter(item rss.Item) (prefilter.Verdict, bool) {
+	if s.rules == nil {
+		return prefilter.Verdict{}, false
+	}
+	v := s.rules.Evaluate(item.Title, item.Description)
+	return v, s.cfg.PrefilterMode == "enforce" && v.Decision != prefilter.Pass
+}
+
+// ruleResult turns a rule decision into an analysis result so it is stored
+// and audited like a model judgement.
+func ruleResult(v prefilter.Verdict) analysis.Result {
+	names := make([]string, 0, len(v.Hits))
+	for _, h := range v.Hits {
+		names = append(names, h.Rule)
+	}
+	result := analysis.Result{
+		Relevant:      v.Decision == prefilter.Accept,
+		Reason:        "规则预筛: " + strings.Join(names, ", "),
+		Confidence:    1,
+		HasConfidence: true,
+		Meta: analysis.Meta{
+			Purpose:       analysis.PurposeClassification,
+			Model:         ruleModel,
+			PromptVersion: rulePromptVersion,
+		},
+	}
+	if result.Relevant {
+		result.Category = v.Category
+	}
+	return result
+}
+
+// prefilterReportHandler compares rule decisions with the model over items
+// published since ?since= (default 30 days).
+func (s *Service) prefilterReportHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	since, err := storage.ParseFilterTime(r.URL.Query().Get("since"))
+	if err != nil {
+		http.Error(w, "invalid since", http.StatusBadRequest)
+		return
+	}
+	if since.IsZero() {
+		since = time.Now().AddDate(0, 0, -30)
+	}
+	report, err := s.store.PrefilterReport(r.Context(), since)
+	if err != nil {
+		s.logger.Printf("prefilter report failed: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, struct {
+		Mode string `json:"mode"`
+		storage.PrefilterReport
+	}{
+		Mode:            s.cfg.PrefilterMode,
+		PrefilterReport: report,
+	})
+}
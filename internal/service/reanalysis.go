@@ -0,0 +1,488 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"aiweb3news/internal/analysis"
+	"aiweb3news/internal/rss"
+	"aiweb3news/internal/storage"
+)
+
+const (
+	// reanalysisBatch is how many items a job loads per query.
+	reanalysisBatch = 50
+	// reanalysisHeartbeat is how often a runner confirms it holds its job;
+	// a job without a heartbeat for reanalysisStale is considered abandoned.
+	reanalysisHeartbeat = 30 * time.Second
+	reanalysisStale     = 2 * time.Minute
+	// reanalysisBreakerWait bounds how long a job waits for an open circuit
+	// breaker before trying the same item again.
+	reanalysisBreakerWaitMin = 5 * time.Second
+	reanalysisBreakerWaitMax = 5 * time.Minute
+)
+
+// ReanalysisRequest describes a re-analysis job. Filter uses the query
+// parameters of GET /api/v1/items; empty Model and PromptVersion keep the
+// configured ones. An explicit Model replaces the primary model; with an
+// ensemble configured it classifies alone.
+type ReanalysisRequest struct {
+	Filter        map[string]string `json:"filter"`
+	Model         string            `json:"model"`
+	PromptVersion string            `json:"prompt_version"`
+	// Notify pushes items that become relevant; off by default so a rerun
+	// over history does not flood the channels.
+	Notify        bool `json:"notify"`
+	RatePerMinute int  `json:"rate_per_minute"`
+	// BypassCache re-asks the model even when the analysis cache holds an
+	// answer for the same content, model and prompt version.
+	BypassCache bool `json:"bypass_cache"`
+}
+
+// CreateReanalysis validates req and stores a new running job. Callers run
+// it with RunReanalysis.
+func (s *Service) CreateReanalysis(ctx context.Context, req ReanalysisRequest) (storage.ReanalysisJob, error) {
+	if s.llm == nil {
+		return storage.ReanalysisJob{}, errors.New("no model client configured")
+	}
+	values := url.Values{}
+	for k, v := range req.Filter {
+		if k == "limit" || k == "offset" {
+			return storage.ReanalysisJob{}, fmt.Errorf("filter %q is not supported", k)
+		}
+		values.Set(k, v)
+	}
+	filter, err := storage.ParseItemFilter(values)
+	if err != nil {
+		return storage.ReanalysisJob{}, err
+	}
+	client, err := s.llm.WithModel(req.Model, req.PromptVersion)
+	if err != nil {
+		return storage.ReanalysisJob{}, err
+	}
+	if req.RatePerMinute < 0 {
+		return storage.ReanalysisJob{}, errors.New("rate_per_minute must not be negative")
+	}
+	total, err := s.store.CountItems(ctx, filter)
+	if err != nil {
+		return storage.ReanalysisJob{}, err
+	}
+
+	job := storage.ReanalysisJob{
+		Filter:        values.Encode(),
+		Model:         req.Model,
+		PromptVersion: client.PromptVersion(),
+		Notify:        req.Notify,
+		RatePerMinute: req.RatePerMinute,
+		BypassCache:   req.BypassCache,
+		Total:         int(total),
+	}
+	if job.ID, err = s.store.CreateReanalysisJob(ctx, job); err != nil {
+		return storage.ReanalysisJob{}, err
+	}
+	return s.store.GetReanalysisJob(ctx, job.ID)
+}
+
+// RunReanalysis processes a running job until it finishes, is paused or ctx
+// is cancelled; cancellation pauses the job so it can be resumed later. The
+// job status is checked before every item, so a pause issued from another
+// process takes effect after the current item. A job is claimed in the
+// database first, so the server and the CLI never run it at the same time.
+func (s *Service) RunReanalysis(ctx context.Context, id int64) error {
+	owner := runnerID()
+	claimed, err := s.store.ClaimReanalysisJob(ctx, id, owner, reanalysisStale)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		s.logger.Printf("reanalysis job %d is not running or is run elsewhere", id)
+		return nil
+	}
+	defer func() {
+		if err := s.store.ReleaseReanalysisJob(context.Background(), id, owner); err != nil {
+			s.logger.Printf("%v", err)
+		}
+	}()
+	beatCtx, stopBeat := context.WithCancel(ctx)
+	defer stopBeat()
+	go s.heartbeatReanalysis(beatCtx, id, owner)
+
+	job, err := s.store.GetReanalysisJob(ctx, id)
+	if err != nil {
+		return err
+	}
+	values, err := url.ParseQuery(job.Filter)
+	if err != nil {
+		return s.failReanalysis(id, err)
+	}
+	filter, err := storage.ParseItemFilter(values)
+	if err != nil {
+		return s.failReanalysis(id, err)
+	}
+	client, err := s.analyzerFor(job.Model, job.PromptVersion)
+	if err != nil {
+		return s.failReanalysis(id, err)
+	}
+	if job.BypassCache {
+		ctx = analysis.BypassCache(ctx)
+	}
+	var interval time.Duration
+	if job.RatePerMinute > 0 {
+		interval = time.Minute / time.Duration(job.RatePerMinute)
+	}
+
+	model := job.Model
+	if model == "" {
+		model = "configured classifier"
+	}
+	s.logger.Printf("reanalysis job %d: %s with %s/%s from item %d (%d/%d done)", id, job.Filter, model, job.PromptVersion, job.LastItemID, job.Processed, job.Total)
+	cursor := job.LastItemID
+	for {
+		items, err := s.store.ItemsAfter(ctx, filter, cursor, reanalysisBatch)
+		if err != nil {
+			return s.stopReanalysis(ctx, id, err)
+		}
+		if len(items) == 0 {
+			_, err := s.store.TransitionReanalysisJob(ctx, id, storage.JobDone, "", storage.JobRunning)
+			s.logger.Printf("reanalysis job %d finished", id)
+			return err
+		}
+		for i := 0; i < len(items); i++ {
+			item := items[i]
+			current, err := s.store.GetReanalysisJob(ctx, id)
+			if err != nil {
+				return s.stopReanalysis(ctx, id, err)
+			}
+			if current.Status != storage.JobRunning {
+				s.logger.Printf("reanalysis job %d %s at item %d", id, current.Status, cursor)
+				return nil
+			}
+			if s.overBudget(ctx) {
+				if _, err := s.store.TransitionReanalysisJob(ctx, id, storage.JobPaused, "daily LLM budget exhausted", storage.JobRunning); err != nil {
+					return s.stopReanalysis(ctx, id, err)
+				}
+				s.logger.Printf("reanalysis job %d paused at item %d: daily LLM budget exhausted", id, cursor)
+				return nil
+			}
+
+			flipped, err := s.reanalyzeItem(ctx, id, client, item, job.Notify)
+			if ctx.Err() != nil {
+				return s.stopReanalysis(ctx, id, ctx.Err())
+			}
+			if errors.Is(err, analysis.ErrBreakerOpen) {
+				// Keep the cursor: the item was not analyzed, and an outage
+				// must not skip the rest of the job.
+				wait := breakerWait(client)
+				s.logger.Printf("reanalysis job %d waiting %s at item %d: %v", id, wait.Round(time.Second), item.ID, err)
+				select {
+				case <-ctx.Done():
+					return s.stopReanalysis(ctx, id, ctx.Err())
+				case <-time.After(wait):
+				}
+				i--
+				continue
+			}
+			if err != nil {
+				s.logger.Printf("reanalysis job %d: item %d failed: %v", id, item.ID, err)
+			}
+			held, err := s.store.AdvanceReanalysisJob(ctx, id, owner, item.ID, err != nil, flipped)
+			if err != nil {
+				return s.stopReanalysis(ctx, id, err)
+			}
+			if !held {
+				s.logger.Printf("reanalysis job %d was taken over by another runner", id)
+				return nil
+			}
+			cursor = item.ID
+			if current.Processed%20 == 19 {
+				s.logger.Printf("reanalysis job %d: %d/%d processed", id, current.Processed+1, current.Total)
+			}
+
+			if interval > 0 {
+				select {
+				case <-ctx.Done():
+					return s.stopReanalysis(ctx, id, ctx.Err())
+				case <-time.After(interval):
+				}
+			}
+		}
+	}
+}
+
+// reanalyzeItem runs one stored item through the pipeline again and records
+// a flip when its relevance changed. With notify only items that become
+// relevant are pushed.
+func (s *Service) reanalyzeItem(ctx context.Context, jobID int64, client analysis.Analyzer, item storage.StoredItem, notify bool) (bool, error) {
+	result, err := s.ingest(ctx, client, rss.Item{
+		GUID:        item.GUID,
+		Title:       item.Title,
+		Link:        item.Link,
+		PublishedAt: item.PublishedAt,
+		Description: item.Summary,
+	}, notify, &item)
+	if err != nil || result.Relevant == item.Relevant {
+		return false, err
+	}
+	return true, s.store.SaveReanalysisFlip(ctx, jobID, storage.ReanalysisFlip{
+		ItemID:      item.ID,
+		Title:       item.Title,
+		WasRelevant: item.Relevant,
+		NowRelevant: result.Relevant,
+		WasCategory: item.Category,
+		NowCategory: result.Category,
+		Reason:      result.Reason,
+	})
+}
+
+// breakerWait is how long to wait before retrying an analyzer whose circuit
+// breakers are open: until the first of them lets a probe through.
+func breakerWait(a analysis.Analyzer) time.Duration {
+	wait := reanalysisBreakerWaitMax
+	if r, ok := a.(analysis.BreakerReporter); ok {
+		for _, b := range r.BreakerStates() {
+			if b.OpenUntil != nil {
+				wait = min(wait, time.Until(*b.OpenUntil))
+			}
+		}
+	}
+	return max(wait, reanalysisBreakerWaitMin)
+}
+
+// analyzerFor builds the analyzer of a job.
+func (s *Service) analyzerFor(model, promptVersion string) (analysis.Analyzer, error) {
+	if s.analyzers != nil {
+		return s.analyzers(model, promptVersion)
+	}
+	client, err := s.llm.WithModel(model, promptVersion)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// runnerID identifies one run of a job across processes.
+func runnerID() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d:%08x", host, os.Getpid(), rand.Uint32())
+}
+
+// heartbeatReanalysis keeps the claim on a job fresh while it runs, including
+// through long rate-limit waits.
+func (s *Service) heartbeatReanalysis(ctx context.Context, id int64, owner string) {
+	ticker := time.NewTicker(reanalysisHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.store.HeartbeatReanalysisJob(ctx, id, owner); err != nil && ctx.Err() == nil {
+				s.logger.Printf("%v", err)
+			}
+		}
+	}
+}
+
+// recoverReanalysis pauses jobs left running by a runner that died, so they
+// show up as resumable instead of running forever.
+func (s *Service) recoverReanalysis(ctx context.Context) {
+	n, err := s.store.PauseAbandonedReanalysisJobs(ctx, reanalysisStale)
+	if err != nil {
+		s.logger.Printf("%v", err)
+		return
+	}
+	if n > 0 {
+		s.logger.Printf("paused %d abandoned reanalysis jobs", n)
+	}
+}
+
+// stopReanalysis pauses the job when ctx was cancelled and fails it otherwise.
+func (s *Service) stopReanalysis(ctx context.Context, id int64, cause error) error {
+	if ctx.Err() != nil {
+		if _, err := s.store.TransitionReanalysisJob(context.Background(), id, storage.JobPaused, "", storage.JobRunning); err != nil {
+			s.logger.Printf("pause reanalysis job %d failed: %v", id, err)
+		}
+		s.logger.Printf("reanalysis job %d paused: %v", id, cause)
+		return cause
+	}
+	return s.failReanalysis(id, cause)
+}
+
+func (s *Service) failReanalysis(id int64, cause error) error {
+	if _, err := s.store.TransitionReanalysisJob(context.Background(), id, storage.JobFailed, cause.Error(), storage.JobRunning); err != nil {
+		s.logger.Printf("fail reanalysis job %d failed: %v", id, err)
+	}
+	return fmt.Errorf("reanalysis job %d: %w", id, cause)
+}
+
+// ResumeReanalysis marks a paused or failed job running again. It reports
+// false when the job was in another state. Abandoned running jobs are paused
+// first so they can be resumed too.
+func (s *Service) ResumeReanalysis(ctx context.Context, id int64) (bool, error) {
+	s.recoverReanalysis(ctx)
+	return s.store.TransitionReanalysisJob(ctx, id, storage.JobRunning, "", storage.JobPaused, storage.JobFailed)
+}
+
+// startReanalysis runs a job in the background for the lifetime of the server.
+func (s *Service) startReanalysis(id int64) {
+	go func() {
+		if err := s.RunReanalysis(s.runCtx, id); err != nil {
+			s.logger.Printf("%v", err)
+		}
+	}()
+}
+
+// reanalysisHandler lists jobs (GET) or creates and starts one (POST).
+func (s *Service) reanalysisHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		jobs, err := s.store.ListReanalysisJobs(r.Context(), 50)
+		if err != nil {
+			s.logger.Printf("list reanalysis jobs failed: %v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, struct {
+			Count int                     `json:"count"`
+			Jobs  []storage.ReanalysisJob `json:"jobs"`
+		}{
+			Count: len(jobs),
+			Jobs:  jobs,
+		})
+	case http.MethodPost:
+		var req ReanalysisRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json body", http.StatusBadRequest)
+			return
+		}
+		job, err := s.CreateReanalysis(r.Context(), req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.startReanalysis(job.ID)
+		s.writeJSON(w, http.StatusCreated, job)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// reanalysisRoutes dispatches /api/v1/admin/reanalysis/{id}[/pause|/resume|/diff].
+func (s *Service) reanalysisRoutes(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/admin/reanalysis/"), "/"), "/")
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid job id", http.StatusBadRequest)
+		return
+	}
+	job, err := s.store.GetReanalysisJob(r.Context(), id)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "job not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.logger.Printf("get reanalysis job %d failed: %v", id, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		if allowMethod(w, r, http.MethodGet) {
+			s.writeJSON(w, http.StatusOK, job)
+		}
+	case len(parts) == 2 && parts[1] == "pause":
+		if !allowMethod(w, r, http.MethodPost) {
+			return
+		}
+		s.transitionReanalysis(w, r, id, func() (bool, error) {
+			return s.store.TransitionReanalysisJob(r.Context(), id, storage.JobPaused, "", storage.JobRunning)
+		})
+	case len(parts) == 2 && parts[1] == "resume":
+		if !allowMethod(w, r, http.MethodPost) {
+			return
+		}
+		s.transitionReanalysis(w, r, id, func() (bool, error) {
+			ok, err := s.ResumeReanalysis(r.Context(), id)
+			if ok {
+				s.startReanalysis(id)
+			}
+			return ok, err
+		})
+	case len(parts) == 2 && parts[1] == "diff":
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		flips, err := s.store.ListReanalysisFlips(r.Context(), id)
+		if err != nil {
+			s.logger.Printf("list reanalysis flips for job %d failed: %v", id, err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, reanalysisDiff(job, flips))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Service) transitionReanalysis(w http.ResponseWriter, r *http.Request, id int64, transition func() (bool, error)) {
+	ok, err := transition()
+	if err != nil {
+		s.logger.Printf("update reanalysis job %d failed: %v", id, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "job is not in a state that allows this", http.StatusConflict)
+		return
+	}
+	job, err := s.store.GetReanalysisJob(r.Context(), id)
+	if err != nil {
+		s.logger.Printf("get reanalysis job %d failed: %v", id, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, job)
+}
+
+// ReanalysisDiff reports the items whose relevance flipped during a job.
+type ReanalysisDiff struct {
+	Job         storage.ReanalysisJob    `json:"job"`
+	NowRelevant []storage.ReanalysisFlip `json:"now_relevant"`
+	NoLonger    []storage.ReanalysisFlip `json:"no_longer_relevant"`
+}
+
+func reanalysisDiff(job storage.ReanalysisJob, flips []storage.ReanalysisFlip) ReanalysisDiff {
+	diff := ReanalysisDiff{Job: job, NowRelevant: []storage.ReanalysisFlip{}, NoLonger: []storage.ReanalysisFlip{}}
+	for _, f := range flips {
+		if f.NowRelevant {
+			diff.NowRelevant = append(diff.NowRelevant, f)
+		} else {
+			diff.NoLonger = append(diff.NoLonger, f)
+		}
+	}
+	return diff
+}
+
+// ReanalysisReport loads the diff report of a job.
+func (s *Service) ReanalysisReport(ctx context.Context, id int64) (ReanalysisDiff, error) {
+	job, err := s.store.GetReanalysisJob(ctx, id)
+	if err != nil {
+		return ReanalysisDiff{}, err
+	}
+	flips, err := s.store.ListReanalysisFlips(ctx, id)
+	if err != nil {
+		return ReanalysisDiff{}, err
+	}
+	return reanalysisDiff(job, flips), nil
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"aiweb3news/internal/analysis"
+	"aiweb3news/internal/rss"
+	"aiweb3news/internal/storage"
+)
+
+var (
+	instruments = []string{analysis.InstrumentLaw, analysis.InstrumentGuidance, analysis.InstrumentLicense,
+		analysis.InstrumentConsultation, analysis.InstrumentEnforcement}
+	stages = []string{analysis.StageProposed, analysis.StagePassed, analysis.StageEffective}
+)
+
+// feedSize is how many events a jurisdiction RSS feed carries.
+const feedSize = 50
+
+// trackRegulation extracts the regulatory action of a regulation item and
+// stores it for the jurisdiction timeline. Failures are only logged.
+func (s *Service) trackRegulation(ctx context.Context, itemID int64, item rss.Item) {
+	if s.llm == nil || !s.llm.Ready() || s.overBudget(ctx) {
+		return
+	}
+	reg, err := s.llm.ExtractRegulation(ctx, itemContext(item))
+	s.recordCall(ctx, item.GUID, reg.Meta, err)
+	if err != nil {
+		s.logger.Printf("regulation extraction failed for %s: %v", item.Title, err)
+		return
+	}
+	if !reg.IsRegulatory {
+		return
+	}
+
+	ev := storage.RegulatoryEvent{
+		ItemID:       itemID,
+		Jurisdiction: s.jurisdiction(reg.Jurisdiction),
+		Regulator:    s.entities.Canonical(analysis.EntityOrganization, strings.TrimSpace(reg.Regulator)),
+		Instrument:   oneOf(reg.Instrument, instruments),
+		Stage:        oneOf(reg.Stage, stages),
+		Summary:      strings.TrimSpace(reg.Summary),
+	}
+	if d, err := time.Parse("2006-01-02", reg.EffectiveDate); err == nil {
+		ev.EffectiveDate = &d
+	}
+	if err := s.store.SaveRegulatoryEvent(ctx, ev); err != nil {
+		s.logger.Printf("save regulatory event failed for %s: %v", item.Title, err)
+	}
+}
+
+// jurisdiction maps a model supplied jurisdiction to its canonical code.
+// Unknown or missing values are filed under OTHER.
+func (s *Service) jurisdiction(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "OTHER"
+	}
+	return strings.ToUpper(s.entities.Canonical(analysis.EntityJurisdiction, name))
+}
+
+// oneOf lower-cases v and returns it when allowed, otherwise "".
+func oneOf(v string, allowed []string) string {
+	v = strings.ToLower(strings.TrimSpace(v))
+	for _, a := range allowed {
+		if v == a {
+			return v
+		}
+	}
+	return ""
+}
+
+// regulationHandler returns the regulatory timeline.
+func (s *Service) regulationHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	filter, ok := parseRegulatoryFilter(w, r)
+	if !ok {
+		return
+	}
+	events, err := s.store.ListRegulatoryEvents(r.Context(), filter)
+	if err != nil {
+		s.logger.Printf("list regulatory events failed: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, struct {
+		Count  int                       `json:"count"`
+		Events []storage.RegulatoryEvent `json:"events"`
+	}{
+		Count:  len(events),
+		Events: events,
+	})
+}
+
+// regulationRoutes dispatches /api/v1/regulation/... requests.
+func (s *Service) regulationRoutes(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/regulation/"), "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "jurisdictions":
+		s.jurisdictionsHandler(w, r)
+	case len(parts) == 2 && parts[0] == "feed" && parts[1] != "":
+		s.regulationFeedHandler(w, r, strings.ToUpper(strings.TrimSuffix(parts[1], ".xml")))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Service) jurisdictionsHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	counts, err := s.store.ListJurisdictions(r.Context())
+	if err != nil {
+		s.logger.Printf("list jurisdictions failed: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{"jurisdictions": counts})
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        string   `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Description string   `xml:"description"`
+	Categories  []string `xml:"category"`
+}
+
+// regulationFeedHandler serves the regulatory timeline of one jurisdiction as RSS 2.0.
+func (s *Service) regulationFeedHandler(w http.ResponseWriter, r *http.Request, jurisdiction string) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	events, err := s.store.ListRegulatoryEvents(r.Context(), storage.RegulatoryFilter{Jurisdiction: jurisdiction, Limit: feedSize})
+	if err != nil {
+		s.logger.Printf("regulation feed failed for %s: %v", jurisdiction, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	feed := rssFeed{Version: "2.0", Channel: rssChannel{
+		Title:       fmt.Sprintf("%s 监管动态", jurisdiction),
+		Link:        "http://" + r.Host + "/api/v1/regulation?jurisdiction=" + jurisdiction,
+		Description: fmt.Sprintf("%s 司法辖区的加密资产监管与政策事件", jurisdiction),
+		Items:       make([]rssItem, 0, len(events)),
+	}}
+	for _, ev := range events {
+		desc := ev.Summary
+		if ev.Regulator != "" {
+			desc = ev.Regulator + "：" + desc
+		}
+		if ev.EffectiveDate != nil {
+			desc += "（生效日期 " + ev.EffectiveDate.Format("2006-01-02") + "）"
+		}
+		item := rssItem{
+			Title:       ev.Title,
+			Link:        ev.Link,
+			GUID:        strconv.FormatInt(ev.ItemID, 10),
+			PubDate:     ev.PublishedAt.Format(time.RFC1123Z),
+			Description: desc,
+		}
+		for _, c := range []string{ev.Instrument, ev.Stage} {
+			if c != "" {
+				item.Categories = append(item.Categories, c)
+			}
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	_, _ = w.Write([]byte(xml.Header))
+	if err := xml.NewEncoder(w).Encode(feed); err != nil {
+		s.logger.Printf("write regulation feed failed: %v", err)
+	}
+}
+
+func parseRegulatoryFilter(w http.ResponseWriter, r *http.Request) (storage.RegulatoryFilter, bool) {
+	q := r.URL.Query()
+	filter := storage.RegulatoryFilter{
+		Jurisdiction: strings.ToUpper(strings.TrimSpace(q.Get("jurisdiction"))),
+		Regulator:    strings.TrimSpace(q.Get("regulator")),
+		Instrument:   strings.ToLower(strings.TrimSpace(q.Get("instrument"))),
+		Stage:        strings.ToLower(strings.TrimSpace(q.Get("stage"))),
+	}
+	if filter.Instrument != "" && oneOf(filter.Instrument, instruments) == "" {
+		http.Error(w, "invalid instrument", http.StatusBadRequest)
+		return filter, false
+	}
+	if filter.Stage != "" && oneOf(filter.Stage, stages) == "" {
+		http.Error(w, "invalid stage", http.StatusBadRequest)
+		return filter, false
+	}
+	var err error
+	if filter.Since, err = storage.ParseFilterTime(q.Get("since")); err != nil {
+		http.Error(w, "invalid since", http.StatusBadRequest)
+		return filter, false
+	}
+	if filter.Until, err = storage.ParseFilterTime(q.Get("until")); err != nil {
+		http.Error(w, "invalid until", http.StatusBadRequest)
+		return filter, false
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return filter, false
+		}
+		filter.Limit = min(n, maxListLimit)
+	}
+	return filter, true
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// retentionHandler runs the retention job on demand. Pass dry_run=true to get
+// a report of what would be touched without modifying anything.
+func (s *Service) retentionHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	report, err := s.retention.Run(r.Context(), dryRun)
+	if err != nil {
+		s.logger.Printf("retention run failed: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"aiweb3news/internal/analysis"
+	"aiweb3news/internal/rss"
+	"aiweb3news/internal/storage"
+)
+
+// reportTopIncidents is how many of the largest incidents a monthly report lists.
+const reportTopIncidents = 10
+
+// trackSecurity extracts the details of a flagged security incident. It runs
+// regardless of relevance: incidents are tracked for risk review, not pushed.
+func (s *Service) trackSecurity(ctx context.Context, itemID int64, item rss.Item) {
+	if s.llm == nil || !s.llm.Ready() || s.overBudget(ctx) {
+		return
+	}
+	sec, err := s.llm.ExtractSecurity(ctx, itemContext(item))
+	s.recordCall(ctx, item.GUID, sec.Meta, err)
+	if err != nil {
+		s.logger.Printf("security extraction failed for %s: %v", item.Title, err)
+		return
+	}
+	if !sec.IsIncident || strings.TrimSpace(sec.Protocol) == "" {
+		return
+	}
+
+	inc := storage.SecurityIncident{
+		Protocol:     s.entities.Canonical(analysis.EntityProtocol, strings.TrimSpace(sec.Protocol)),
+		Chain:        s.entities.Canonical(analysis.EntityChain, strings.TrimSpace(sec.Chain)),
+		LossUSD:      max(sec.LossUSD, 0),
+		AttackVector: oneOf(sec.AttackVector, analysis.AttackVectors),
+		RecoveredUSD: max(sec.RecoveredUSD, 0),
+		OccurredOn:   item.PublishedAt,
+		Summary:      strings.TrimSpace(sec.Summary),
+	}
+	if inc.AttackVector == "" {
+		inc.AttackVector = analysis.VectorOther
+	}
+	if d, err := time.Parse("2006-01-02", sec.Date); err == nil {
+		inc.OccurredOn = d
+	}
+	if inc.OccurredOn.IsZero() {
+		inc.OccurredOn = time.Now()
+	}
+	if _, err := s.store.SaveSecurityIncident(ctx, itemID, inc); err != nil {
+		s.logger.Printf("save security incident failed for %s: %v", item.Title, err)
+	}
+}
+
+// securityIncidentsHandler lists security incidents, largest loss first.
+func (s *Service) securityIncidentsHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	q := r.URL.Query()
+	filter := storage.SecurityFilter{
+		Chain:        strings.TrimSpace(q.Get("chain")),
+		AttackVector: strings.TrimSpace(q.Get("attack_vector")),
+		Protocol:     strings.TrimSpace(q.Get("protocol")),
+	}
+	if filter.AttackVector != "" && oneOf(filter.AttackVector, analysis.AttackVectors) == "" {
+		http.Error(w, "invalid attack_vector", http.StatusBadRequest)
+		return
+	}
+	switch q.Get("sort") {
+	case "", "loss":
+	case "date":
+		filter.SortByDate = true
+	default:
+		http.Error(w, "sort must be loss or date", http.StatusBadRequest)
+		return
+	}
+	var err error
+	if filter.Since, err = storage.ParseFilterTime(q.Get("since")); err != nil {
+		http.Error(w, "invalid since", http.StatusBadRequest)
+		return
+	}
+	if filter.Until, err = storage.ParseFilterTime(q.Get("until")); err != nil {
+		http.Error(w, "invalid until", http.StatusBadRequest)
+		return
+	}
+	if v := q.Get("min_loss"); v != "" {
+		if filter.MinLoss, err = strconv.ParseFloat(v, 64); err != nil || filter.MinLoss < 0 {
+			http.Error(w, "invalid min_loss", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = min(n, maxListLimit)
+	}
+
+	incidents, err := s.store.ListSecurityIncidents(r.Context(), filter)
+	if err != nil {
+		s.logger.Printf("list security incidents failed: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, struct {
+		Count     int                        `json:"count"`
+		Incidents []storage.SecurityIncident `json:"incidents"`
+	}{
+		Count:     len(incidents),
+		Incidents: incidents,
+	})
+}
+
+// securityReportHandler returns the monthly summary for ?month=YYYY-MM,
+// defaulting to the previous calendar month.
+func (s *Service) securityReportHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+	if v := r.URL.Query().Get("month"); v != "" {
+		t, err := time.Parse("2006-01", v)
+		if err != nil {
+			http.Error(w, "month must be YYYY-MM", http.StatusBadRequest)
+			return
+		}
+		month = t
+	}
+	report, err := s.store.SecurityMonthlyReport(r.Context(), month, reportTopIncidents)
+	if err != nil {
+		s.logger.Printf("security report failed: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, report)
+}
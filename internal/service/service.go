@@ -1,47 +1,128 @@
 package service
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"aiweb3news/internal/analysis"
 	"aiweb3news/internal/config"
+	"aiweb3news/internal/embedding"
+	"aiweb3news/internal/entity"
+	"aiweb3news/internal/fewshot"
+	"aiweb3news/internal/metrics"
+	"aiweb3news/internal/notify"
+	"aiweb3news/internal/prefilter"
+	"aiweb3news/internal/retention"
 	"aiweb3news/internal/rss"
 	"aiweb3news/internal/storage"
+	"aiweb3news/internal/usage"
+	"aiweb3news/internal/watchlist"
 )
 
-const wecomWebhookURL = "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=74cb55e7-0430-400a-b3e2-2e8d05d8cb06"
+// AnalyzerFactory builds the classification analyzer for a model and prompt
+// version with the same fallback, ensemble and breaker wrappers as the
+// pipeline's; empty arguments keep the configured values.
+type AnalyzerFactory func(model, promptVersion string) (analysis.Analyzer, error)
 
 // Service ties together RSS polling and AI analysis.
 type Service struct {
-	fetcher  *rss.Fetcher
-	analyzer analysis.Analyzer
-	store    *storage.Store
-	logger   *log.Logger
-	cfg      config.Config
+	fetcher   *rss.Fetcher
+	analyzer  analysis.Analyzer
+	analyzers AnalyzerFactory
+	store     *storage.Store
+	llm       *analysis.Client
+	notifier  *notify.Notifier
+	entities  *entity.Dictionary
+	rules     *prefilter.Engine
+	prices    usage.Prices
+	budget    *usage.Budget
+	embedder  embedding.Embedder
+	index     *embedding.Index
+	examples  *fewshot.Pool
+	logger    *log.Logger
+	cfg       config.Config
+
+	// examplesMu serializes loading the few-shot pool; examplesLoaded is set
+	// once a load succeeded.
+	examplesMu     sync.Mutex
+	examplesLoaded bool
+
+	retention *retention.Job
+	// runCtx outlives requests; background jobs started over HTTP use it.
+	runCtx context.Context
+
+	budgetNoticed atomic.Bool
+	// unpriced holds the models already logged as missing from the price table.
+	unpriced sync.Map
+	// watch matches items against the watchlist; CRUD calls rebuild it.
+	watch atomic.Pointer[watchlist.Matcher]
 }
 
 // NewService creates a Service instance.
-func NewService(fetcher *rss.Fetcher, analyzer analysis.Analyzer, store *storage.Store, logger *log.Logger, cfg config.Config) *Service {
+func NewService(fetcher *rss.Fetcher, analyzer analysis.Analyzer, analyzers AnalyzerFactory, llm *analysis.Client, store *storage.Store, notifier *notify.Notifier, entities *entity.Dictionary, rules *prefilter.Engine, prices usage.Prices, logger *log.Logger, cfg config.Config) *Service {
+	llmBudgetLimit.Set(cfg.LLMDailyBudget)
 	return &Service{
-		fetcher:  fetcher,
-		analyzer: analyzer,
-		store:    store,
-		logger:   logger,
-		cfg:      cfg,
+		fetcher:   fetcher,
+		analyzer:  analyzer,
+		analyzers: analyzers,
+		store:     store,
+		llm:       llm,
+		notifier:  notifier,
+		entities:  entities,
+		rules:     rules,
+		prices:    prices,
+		budget:    usage.NewBudget(cfg.LLMDailyBudget),
+		embedder:  newEmbedder(cfg, llm),
+		index:     embedding.NewIndex(),
+		examples:  fewshot.NewPool(),
+		logger:    logger,
+		cfg:       cfg,
+
+		retention: retention.NewJob(store, retention.PolicyFromConfig(cfg), logger),
+		runCtx:    context.Background(),
 	}
 }
 
 // Run starts the HTTP server and the polling loop.
 func (s *Service) Run(ctx context.Context) error {
+	s.runCtx = ctx
 	mux := http.NewServeMux()
 	mux.HandleFunc("/healthz", s.healthHandler)
+	mux.Handle("/metrics", metrics.Handler())
 	mux.HandleFunc("/items", s.itemsHandler)
+	mux.HandleFunc("/api/v1/items", s.listItemsHandler)
+	mux.HandleFunc("/api/v1/items/", s.itemRoutes)
+	mux.HandleFunc("/api/v1/export", s.exportHandler)
+	mux.HandleFunc("/api/v1/entities", s.entitiesHandler)
+	mux.HandleFunc("/api/v1/funding", s.fundingHandler)
+	mux.HandleFunc("/api/v1/funding/stats", s.fundingStatsHandler)
+	mux.HandleFunc("/api/v1/regulation", s.regulationHandler)
+	mux.HandleFunc("/api/v1/regulation/", s.regulationRoutes)
+	mux.HandleFunc("/api/v1/security/incidents", s.securityIncidentsHandler)
+	mux.HandleFunc("/api/v1/security/report", s.securityReportHandler)
+	mux.HandleFunc("/api/v1/search", s.searchHandler)
+	mux.HandleFunc("/api/v1/ask", s.askHandler)
+	mux.HandleFunc("/api/v1/stories", s.storiesHandler)
+	mux.HandleFunc("/api/v1/stories/", s.storyHandler)
+	mux.HandleFunc("/api/v1/trends", s.trendsHandler)
+	mux.HandleFunc("/api/v1/feedback/agreement", s.feedbackAgreementHandler)
+	mux.HandleFunc("/api/v1/feedback/dataset", s.feedbackDatasetHandler)
+	mux.HandleFunc("/api/v1/prefilter/report", s.prefilterReportHandler)
+	mux.HandleFunc("/api/v1/admin/retention", s.requireAdmin(s.retentionHandler))
+	mux.HandleFunc("/api/v1/admin/items/", s.requireAdmin(s.adminItemRoutes))
+	mux.HandleFunc("/api/v1/admin/llm-calls", s.requireAdmin(s.llmCallsByGUIDHandler))
+	mux.HandleFunc("/api/v1/admin/usage", s.requireAdmin(s.usageHandler))
+	mux.HandleFunc("/api/v1/admin/reanalysis", s.requireAdmin(s.reanalysisHandler))
+	mux.HandleFunc("/api/v1/admin/reanalysis/", s.requireAdmin(s.reanalysisRoutes))
+	mux.HandleFunc("/api/v1/admin/watchlist", s.requireAdmin(s.watchlistHandler))
+	mux.HandleFunc("/api/v1/admin/watchlist/", s.requireAdmin(s.watchlistEntryHandler))
 
 	srv := &http.Server{
 		Addr:    s.cfg.BindAddr,
@@ -62,6 +143,16 @@ func (s *Service) Run(ctx context.Context) error {
 		}
 	}()
 
+	s.loadIndex(ctx)
+	go s.refreshIndex(ctx, s.cfg.EmbeddingIndexRefresh)
+	s.recoverReanalysis(ctx)
+	if s.retention.Policy().Enabled() {
+		go s.retention.Schedule(ctx, s.cfg.RetentionInterval, s.cfg.RetentionDryRun)
+	}
+	if s.cfg.TrendAlerts {
+		go s.scheduleTrendAlerts(ctx, s.cfg.TrendInterval)
+	}
+
 	// Kick off an initial fetch.
 	s.pollOnce(ctx)
 
@@ -81,6 +172,9 @@ func (s *Service) Run(ctx context.Context) error {
 
 func (s *Service) pollOnce(ctx context.Context) {
 	s.logger.Println("polling once")
+	s.recoverReanalysis(ctx)
+	s.drainDeferred(ctx)
+	s.retryWatchAlerts(ctx)
 	items, err := s.fetcher.Fetch(ctx)
 	if err != nil {
 		s.logger.Printf("failed to fetch feed: %v", err)
@@ -97,40 +191,191 @@ func (s *Service) pollOnce(ctx context.Context) {
 			continue
 		}
 
-		result, err := s.analyzer.Evaluate(ctx, analysis.ItemContext{
-			Title:       item.Title,
-			Link:        item.Link,
-			PublishedAt: item.PublishedAt,
-			Summary:     item.Description,
-		})
-		if err != nil {
-			s.logger.Printf("analysis error for %s: %v", item.Title, err)
-			continue
+		if err := s.Ingest(ctx, item, true); err != nil {
+			if errors.Is(err, errAnalysis) {
+				s.deferItem(ctx, item, true, err)
+				continue
+			}
+			s.logger.Printf("ingest failed for %s: %v", item.Title, err)
 		}
+	}
+	s.countDeferred(ctx)
+}
 
-		if err := s.store.SaveAnalysis(ctx, item, result); err != nil {
-			s.logger.Printf("store analysis failed for %s: %v", item.Title, err)
-			continue
+// errAnalysis marks ingest errors from the model call, as opposed to
+// storage errors; polling defers such items instead of dropping them.
+var errAnalysis = errors.New("analysis")
+
+// Ingest runs a single item through the analysis pipeline: evaluate, store,
+// cluster into stories and, when push is set, notify relevant results that
+// open a new story. Polling and backfill imports share it so historical
+// items are treated like live ones.
+func (s *Service) Ingest(ctx context.Context, item rss.Item, push bool) error {
+	_, err := s.ingest(ctx, s.analyzer, item, push, nil)
+	return err
+}
+
+// ingest is Ingest with an explicit analyzer; it returns the stored result.
+// prev is the stored item when re-analyzing: then only items that become
+// relevant are pushed, and the structured extractions are kept unless the
+// verdict changed.
+func (s *Service) ingest(ctx context.Context, analyzer analysis.Analyzer, item rss.Item, push bool, prev *storage.StoredItem) (analysis.Result, error) {
+	if push && prev == nil {
+		// Queue title and summary matches before the analysis, so a failed
+		// classification cannot lose a watchlist alert.
+		s.queueWatchAlerts(ctx, item, s.watchMatcher(ctx).Match(item.Title, item.Description, nil))
+	}
+	verdict, decided := s.prefilter(item)
+	var result analysis.Result
+	if decided {
+		result = ruleResult(verdict)
+	} else {
+		req := itemContext(item)
+		var knownID int64
+		if prev != nil {
+			knownID = prev.ID
+		} else {
+			knownID = s.storedItemID(ctx, item.GUID)
+		}
+		req.Examples = s.fewShot(knownID, item.Title, item.Description)
+		var err error
+		result, err = analyzer.Evaluate(ctx, req)
+		for _, a := range result.Attempts {
+			s.recordCall(ctx, item.GUID, a.Meta, a.Err)
 		}
+		s.recordCall(ctx, item.GUID, result.Meta, err)
+		if err != nil {
+			return result, fmt.Errorf("%w: %w", errAnalysis, err)
+		}
+	}
 
-		if result.Relevant {
-			s.notifyWebhook(ctx, item, result)
+	result.Entities = s.entities.Normalize(result.Entities)
+	needsReview := s.needsReview(result)
+	itemID, err := s.store.SaveAnalysis(ctx, item, result, needsReview)
+	if err != nil {
+		return result, fmt.Errorf("store analysis: %w", err)
+	}
+	if len(result.Votes) > 0 {
+		if err := s.store.SaveVotes(ctx, itemID, result.Votes); err != nil {
+			s.logger.Printf("save votes failed for %s: %v", item.Title, err)
+		}
+	}
+	if s.rules != nil {
+		if err := s.store.SaveRuleVerdict(ctx, itemID, verdict, decided); err != nil {
+			s.logger.Printf("save rule verdict failed for %s: %v", item.Title, err)
 		}
 	}
+	vec := s.embedItem(ctx, itemID, item.GUID, item.Title, item.Description)
+	storyID := s.clusterItem(ctx, itemID, item, vec)
+	// A re-analysis with the same verdict would only repeat the extractions.
+	extract := prev == nil || prev.Relevant != result.Relevant || prev.Category != result.Category
+	switch {
+	case !extract, !result.Relevant:
+	case analysis.IsFinancing(result.Category):
+		s.trackFunding(ctx, itemID, item)
+	case analysis.IsRegulation(result.Category):
+		s.trackRegulation(ctx, itemID, item)
+	}
+	if extract && result.SecurityIncident {
+		s.trackSecurity(ctx, itemID, item)
+	}
+	if prev != nil {
+		push = push && result.Relevant && !prev.Relevant
+	}
+
+	var summary analysis.Summary
+	if result.Relevant {
+		summary = s.summarize(ctx, itemID, item)
+	}
+	// Watchlist alerts go out even for irrelevant items and items held for review.
+	alerted := s.watchItem(ctx, itemID, item, result, summary, push)
+
+	if needsReview {
+		s.logger.Printf("holding %s for review (confidence %.2f, disagreement %t)", item.Title, result.Confidence, result.Disagreement)
+		return result, nil
+	}
+	if push && result.Relevant {
+		s.pushItem(ctx, storyID, notify.Message{
+			Title:      item.Title,
+			Link:       item.Link,
+			Category:   result.Category,
+			Reason:     result.Reason,
+			Importance: result.Importance,
+			SummaryZH:  summary.SummaryZH,
+			TitleEN:    summary.TitleEN,
+			SummaryEN:  summary.SummaryEN,
+		}, alerted...)
+	}
+	return result, nil
+}
+
+// storedItemID returns the id of an item that is already stored, e.g. one
+// being re-analyzed, and 0 otherwise.
+func (s *Service) storedItemID(ctx context.Context, guid string) int64 {
+	if !s.cfg.FewShotEnabled {
+		return 0
+	}
+	id, err := s.store.ItemID(ctx, guid)
+	if err != nil {
+		s.logger.Printf("look up item %s failed: %v", guid, err)
+	}
+	return id
 }
 
+func itemContext(item rss.Item) analysis.ItemContext {
+	return analysis.ItemContext{
+		Title:       item.Title,
+		Link:        item.Link,
+		PublishedAt: item.PublishedAt,
+		Summary:     item.Description,
+	}
+}
+
+// needsReview holds back results the model itself is unsure about, and
+// ensemble results the members disagreed on when
+// ENSEMBLE_REVIEW_DISAGREEMENTS is set. Answers without a confidence, e.g.
+// from prompt versions before v4, are not held back on confidence;
+// REVIEW_CONFIDENCE_BELOW=0 turns confidence reviews off.
+func (s *Service) needsReview(result analysis.Result) bool {
+	if result.Disagreement && s.cfg.EnsembleReviewDisagreements {
+		return true
+	}
+	return result.HasConfidence && result.Confidence < s.cfg.ReviewConfidenceBelow
+}
+
+// healthHandler reports liveness. With circuit breakers configured it also
+// lists their states; any breaker that is not closed makes the status
+// "degraded" while the response stays 200, since polling and the API keep
+// working and skipped items are deferred.
 func (s *Service) healthHandler(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write([]byte("ok"))
+	reporter, ok := s.analyzer.(analysis.BreakerReporter)
+	if !ok {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+		return
+	}
+	breakers := reporter.BreakerStates()
+	status := "ok"
+	for _, b := range breakers {
+		if b.State != analysis.BreakerClosed {
+			status = "degraded"
+		}
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{"status": status, "llm_breakers": breakers})
 }
 
 func (s *Service) itemsHandler(w http.ResponseWriter, r *http.Request) {
+	lang, ok := parseLang(w, r)
+	if !ok {
+		return
+	}
 	items, err := s.store.ListRelevant(r.Context(), s.cfg.MaxItems)
 	if err != nil {
 		s.logger.Printf("list relevant failed: %v", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
+	localizeAll(items, lang)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(struct {
@@ -143,36 +388,3 @@ func (s *Service) itemsHandler(w http.ResponseWriter, r *http.Request) {
 		s.logger.Printf("write items response failed: %v", err)
 	}
 }
-
-func (s *Service) notifyWebhook(ctx context.Context, item rss.Item, result analysis.Result) {
-	payload := map[string]any{
-		"msgtype": "text",
-		"text": map[string]string{
-			"content": fmt.Sprintf("%s\n分类: %s\nAI分析: %s\n链接: %s", item.Title, result.Category, result.Reason, item.Link),
-		},
-	}
-
-	body, err := json.Marshal(payload)
-	if err != nil {
-		s.logger.Printf("marshal webhook payload failed: %v", err)
-		return
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wecomWebhookURL, bytes.NewReader(body))
-	if err != nil {
-		s.logger.Printf("build webhook request failed: %v", err)
-		return
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		s.logger.Printf("send webhook failed: %v", err)
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 300 {
-		s.logger.Printf("webhook returned non-2xx status: %s", resp.Status)
-	}
-}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"aiweb3news/internal/cluster"
+	"aiweb3news/internal/embedding"
+	"aiweb3news/internal/notify"
+	"aiweb3news/internal/rss"
+	"aiweb3news/internal/storage"
+)
+
+// clusterItem files a stored item under the story of the closest recent item
+// reporting the same event, or starts a new story. It returns the story id,
+// 0 when clustering is disabled or failed.
+func (s *Service) clusterItem(ctx context.Context, itemID int64, item rss.Item, vec []float32) int64 {
+	if !s.cfg.ClusterEnabled {
+		return 0
+	}
+	fp := cluster.Fingerprint(item.Title, item.Description)
+	around := item.PublishedAt
+	if around.IsZero() {
+		around = time.Now()
+	}
+	candidates, err := s.store.StoryCandidates(ctx, around, s.cfg.ClusterWindow, itemID)
+	if err != nil {
+		s.logger.Printf("clustering %s failed: %v", item.Title, err)
+		return 0
+	}
+
+	matcher := s.matcher()
+	if matcher.MinEmbeddingSimilarity > 0 && vec != nil {
+		for i := range candidates {
+			candidates[i].Vector, _ = s.index.Get(candidates[i].ItemID)
+		}
+	}
+
+	var storyID int64
+	if match, ok := matcher.Best(item.Title, fp, vec, candidates); ok {
+		storyID = match.StoryID
+	}
+	storyID, err = s.store.AssignStory(ctx, itemID, fp, storyID)
+	if err != nil {
+		s.logger.Printf("clustering %s failed: %v", item.Title, err)
+		return 0
+	}
+	return storyID
+}
+
+func (s *Service) matcher() cluster.Matcher {
+	m := cluster.Matcher{MaxDistance: s.cfg.ClusterMaxDistance, MinTitleSimilarity: s.cfg.ClusterTitleSimilarity}
+	// Local hashed vectors only restate the lexical overlap SimHash already
+	// measures, so only provider embeddings take part in matching.
+	if _, local := s.embedder.(embedding.Local); !local {
+		m.MinEmbeddingSimilarity = s.cfg.ClusterEmbeddingSimilarity
+	}
+	return m
+}
+
+// pushItem notifies msg unless an earlier item of the same story was already
+// pushed, in which case the item only stays attached to the story as an
+// update. skip names destinations that already received the item as a
+// watchlist alert. It reports whether a notification was sent.
+func (s *Service) pushItem(ctx context.Context, storyID int64, msg notify.Message, skip ...string) bool {
+	if storyID > 0 {
+		first, err := s.store.ClaimStoryNotification(ctx, storyID)
+		switch {
+		case err != nil:
+			// Prefer a possible duplicate over a lost notification.
+			s.logger.Printf("story %d: %v", storyID, err)
+		case !first:
+			s.logger.Printf("appended %s to story %d as an update", msg.Title, storyID)
+			return false
+		}
+	}
+	s.notifier.Notify(ctx, msg, skip...)
+	return true
+}
+
+// storiesHandler lists story clusters, most recently updated first.
+func (s *Service) storiesHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	q := r.URL.Query()
+	var (
+		filter storage.StoryFilter
+		err    error
+	)
+	if filter.Since, err = storage.ParseFilterTime(q.Get("since")); err != nil {
+		http.Error(w, "invalid since", http.StatusBadRequest)
+		return
+	}
+	for name, dst := range map[string]*int{"min_items": &filter.MinItems, "limit": &filter.Limit} {
+		if v := q.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				http.Error(w, "invalid "+name, http.StatusBadRequest)
+				return
+			}
+			*dst = n
+		}
+	}
+	filter.Limit = min(filter.Limit, maxListLimit)
+
+	stories, err := s.store.ListStories(r.Context(), filter)
+	if err != nil {
+		s.logger.Printf("list stories failed: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, struct {
+		Count   int             `json:"count"`
+		Stories []storage.Story `json:"stories"`
+	}{
+		Count:   len(stories),
+		Stories: stories,
+	})
+}
+
+// storyHandler returns one story with its items in publication order, the
+// canonical item first.
+func (s *Service) storyHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	lang, ok := parseLang(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/stories/"), "/"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid story id", http.StatusBadRequest)
+		return
+	}
+	story, err := s.store.GetStory(r.Context(), id)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "story not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.logger.Printf("get story %d failed: %v", id, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	items, err := s.store.ListItems(r.Context(), storage.ItemFilter{StoryID: id})
+	if err != nil {
+		s.logger.Printf("list story %d items failed: %v", id, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	slices.Reverse(items)
+	localizeAll(items, lang)
+	s.writeJSON(w, http.StatusOK, struct {
+		Story storage.Story        `json:"story"`
+		Items []storage.StoredItem `json:"items"`
+	}{
+		Story: story,
+		Items: items,
+	})
+}
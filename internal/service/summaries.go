@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"aiweb3news/internal/analysis"
+	"aiweb3news/internal/rss"
+	"aiweb3news/internal/storage"
+)
+
+// summarize generates the bilingual summaries of an item. Items whose title,
+// description and summary prompt are unchanged since the last run reuse the
+// stored summaries instead of being translated again.
+func (s *Service) summarize(ctx context.Context, itemID int64, item rss.Item) analysis.Summary {
+	if !s.cfg.SummaryEnabled || s.llm == nil || !s.llm.Ready() || s.overBudget(ctx) {
+		return analysis.Summary{}
+	}
+	sum := sha256.Sum256([]byte(analysis.SummaryPromptVersion + "\n" + item.Title + "\n" + item.Description))
+	hash := hex.EncodeToString(sum[:])
+	if stored, err := s.store.SummaryHash(ctx, itemID); err == nil && stored == hash {
+		if existing, err := s.store.GetItem(ctx, itemID); err == nil {
+			return analysis.Summary{SummaryZH: existing.SummaryZH, TitleEN: existing.TitleEN, SummaryEN: existing.SummaryEN}
+		}
+	}
+
+	out, err := s.llm.Summarize(ctx, itemContext(item))
+	s.recordCall(ctx, item.GUID, out.Meta, err)
+	if err != nil {
+		s.logger.Printf("summarize %s failed: %v", item.Title, err)
+		return analysis.Summary{}
+	}
+	out.SummaryZH = strings.TrimSpace(out.SummaryZH)
+	out.TitleEN = strings.TrimSpace(out.TitleEN)
+	out.SummaryEN = strings.TrimSpace(out.SummaryEN)
+	if err := s.store.SaveSummaries(ctx, itemID, hash, out.SummaryZH, out.TitleEN, out.SummaryEN); err != nil {
+		s.logger.Printf("save summaries for %s failed: %v", item.Title, err)
+	}
+	return out
+}
+
+// parseLang reads ?lang=; "" keeps the stored fields as they are.
+func parseLang(w http.ResponseWriter, r *http.Request) (string, bool) {
+	switch lang := r.URL.Query().Get("lang"); lang {
+	case "", "zh", "en":
+		return lang, true
+	default:
+		http.Error(w, "lang must be zh or en", http.StatusBadRequest)
+		return "", false
+	}
+}
+
+// localize fills title and summary with the generated text for lang, keeping
+// the original wherever no generated text exists.
+func localize(item storage.StoredItem, lang string) storage.StoredItem {
+	switch lang {
+	case "en":
+		if item.TitleEN != "" {
+			item.Title = item.TitleEN
+		}
+		if item.SummaryEN != "" {
+			item.Summary = item.SummaryEN
+		}
+	case "zh":
+		if item.SummaryZH != "" {
+			item.Summary = item.SummaryZH
+		}
+	}
+	return item
+}
+
+func localizeAll(items []storage.StoredItem, lang string) {
+	for i := range items {
+		items[i] = localize(items[i], lang)
+	}
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"aiweb3news/internal/trends"
+)
+
+// trendAlertItems is how many contributing items a trend alert lists.
+const trendAlertItems = 3
+
+// trendParams returns the detection parameters configured for the service.
+func (s *Service) trendParams() trends.Params {
+	return trends.Params{
+		Window:          s.cfg.TrendWindow,
+		BaselineWindows: s.cfg.TrendBaselineWindows,
+		MinCount:        s.cfg.TrendMinCount,
+		MinRatio:        s.cfg.TrendMinRatio,
+		MinZScore:       s.cfg.TrendMinZScore,
+	}
+}
+
+// detectTrends loads the mentions covered by p and runs spike detection.
+// kind is "" for everything, "tag", "entity" for every entity type, or one
+// entity type.
+func (s *Service) detectTrends(ctx context.Context, p trends.Params, kind string, topItems int) ([]trends.Trend, error) {
+	now := time.Now()
+	since := p.Since(now)
+	var mentions []trends.Mention
+	if kind == "" || kind == "tag" {
+		tags, err := s.store.TagMentions(ctx, since)
+		if err != nil {
+			return nil, err
+		}
+		mentions = append(mentions, tags...)
+	}
+	if kind != "tag" {
+		entities, err := s.store.EntityMentions(ctx, since)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range entities {
+			if kind == "" || kind == "entity" || kind == m.Kind {
+				mentions = append(mentions, m)
+			}
+		}
+	}
+	return trends.Detect(mentions, now, p, topItems), nil
+}
+
+// trendsHandler lists terms currently spiking above their baseline. Query
+// parameters override the configured detection thresholds.
+func (s *Service) trendsHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	q := r.URL.Query()
+	p := s.trendParams()
+	if v := q.Get("window_days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid window_days", http.StatusBadRequest)
+			return
+		}
+		p.Window = time.Duration(n) * 24 * time.Hour
+	}
+	for _, param := range []struct {
+		name string
+		dst  *int
+	}{{"baseline_windows", &p.BaselineWindows}, {"min_count", &p.MinCount}} {
+		if v := q.Get(param.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				http.Error(w, "invalid "+param.name, http.StatusBadRequest)
+				return
+			}
+			*param.dst = n
+		}
+	}
+	for _, param := range []struct {
+		name string
+		dst  *float64
+	}{{"min_ratio", &p.MinRatio}, {"min_z", &p.MinZScore}} {
+		if v := q.Get(param.name); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil || f < 0 {
+				http.Error(w, "invalid "+param.name, http.StatusBadRequest)
+				return
+			}
+			*param.dst = f
+		}
+	}
+	limit := 50
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxListLimit)
+	}
+
+	found, err := s.detectTrends(r.Context(), p, strings.TrimSpace(q.Get("kind")), trendAlertItems)
+	if err != nil {
+		s.logger.Printf("detect trends failed: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	s.writeJSON(w, http.StatusOK, struct {
+		WindowDays      int            `json:"window_days"`
+		BaselineWindows int            `json:"baseline_windows"`
+		Count           int            `json:"count"`
+		Trends          []trends.Trend `json:"trends"`
+	}{
+		WindowDays:      int(p.Window / (24 * time.Hour)),
+		BaselineWindows: p.BaselineWindows,
+		Count:           len(found),
+		Trends:          found,
+	})
+}
+
+// scheduleTrendAlerts checks for new trends every interval until ctx is done.
+func (s *Service) scheduleTrendAlerts(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.alertTrends(ctx)
+		}
+	}
+}
+
+// alertTrends pushes every detected trend that was not already alerted
+// within the current window.
+func (s *Service) alertTrends(ctx context.Context) {
+	p := s.trendParams()
+	found, err := s.detectTrends(ctx, p, "", trendAlertItems)
+	if err != nil {
+		s.logger.Printf("trend alerts: %v", err)
+		return
+	}
+	since := time.Now().Add(-p.Window)
+	for _, t := range found {
+		alerted, err := s.store.TrendAlertedSince(ctx, t.Kind, t.Term, since)
+		if err != nil {
+			s.logger.Printf("trend alerts: %v", err)
+			return
+		}
+		if alerted {
+			continue
+		}
+		zh, en := renderTrend(t)
+		s.notifier.Broadcast(ctx, zh, en)
+		if err := s.store.SaveTrendAlert(ctx, t); err != nil {
+			s.logger.Printf("trend alerts: %v", err)
+		}
+		s.logger.Printf("trend alert for %s %q: %d items (baseline %.2f)", t.Kind, t.Term, t.Count, t.Baseline)
+	}
+}
+
+func renderTrend(t trends.Trend) (string, string) {
+	var zh, en strings.Builder
+	fmt.Fprintf(&zh, "趋势提醒: %s (%s)\n本期 %d 条，基线均值 %.1f 条，增长 %.1f 倍", t.Term, t.Kind, t.Count, t.Baseline, t.Ratio)
+	fmt.Fprintf(&en, "Trending: %s (%s)\n%d items this window vs. baseline %.1f, %.1fx", t.Term, t.Kind, t.Count, t.Baseline, t.Ratio)
+	for i, it := range t.Items {
+		fmt.Fprintf(&zh, "\n%d. %s\n%s", i+1, it.Title, it.Link)
+		fmt.Fprintf(&en, "\n%d. %s\n%s", i+1, it.Title, it.Link)
+	}
+	return zh.String(), en.String()
+}
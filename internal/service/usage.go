@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"aiweb3news/internal/analysis"
+	"aiweb3news/internal/metrics"
+	"aiweb3news/internal/storage"
+	"aiweb3news/internal/usage"
+)
+
+var (
+	llmCallsTotal = metrics.NewCounter("aiweb3news_llm_calls_total",
+		"Model calls by model, purpose and status.", "model", "purpose", "status")
+	llmTokensTotal = metrics.NewCounter("aiweb3news_llm_tokens_total",
+		"Tokens used by model, purpose and type (prompt or completion).", "model", "purpose", "type")
+	llmCostTotal = metrics.NewCounter("aiweb3news_llm_cost_usd_total",
+		"Cost of model calls in USD by model and purpose.", "model", "purpose")
+	llmUnpricedCalls = metrics.NewCounter("aiweb3news_llm_unpriced_calls_total",
+		"Model calls priced at the default because the model is missing from the price table.", "model")
+	llmBudgetSpent = metrics.NewGauge("aiweb3news_llm_budget_spent_usd",
+		"Cost of today's model calls in USD (UTC day).")
+	llmBudgetLimit = metrics.NewGauge("aiweb3news_llm_budget_limit_usd",
+		"Daily model budget in USD; 0 means unlimited.")
+	llmBudgetExceeded = metrics.NewGauge("aiweb3news_llm_budget_exceeded",
+		"1 while non-critical model work is paused by the daily budget.")
+)
+
+// recordUsage prices a call, adds it to the daily totals and the budget and
+// returns its cost.
+func (s *Service) recordUsage(ctx context.Context, meta analysis.Meta, callErr error) float64 {
+	now := time.Now()
+	cost, known := s.prices.Cost(meta.Model, meta.PromptTokens, meta.CompletionTokens, usage.Price{
+		Prompt:     s.cfg.LLMDefaultPromptPrice,
+		Completion: s.cfg.LLMDefaultCompletionPrice,
+	})
+	if !known {
+		llmUnpricedCalls.Inc(meta.Model)
+		if _, logged := s.unpriced.LoadOrStore(meta.Model, true); !logged {
+			s.logger.Printf("model %q is missing from the price table, pricing it at $%g/$%g per million tokens; set LLM_PRICES_FILE", meta.Model, s.cfg.LLMDefaultPromptPrice, s.cfg.LLMDefaultCompletionPrice)
+		}
+	}
+	status := "ok"
+	if callErr != nil {
+		status = "error"
+	}
+	llmCallsTotal.Inc(meta.Model, meta.Purpose, status)
+	llmTokensTotal.Add(float64(meta.PromptTokens), meta.Model, meta.Purpose, "prompt")
+	llmTokensTotal.Add(float64(meta.CompletionTokens), meta.Model, meta.Purpose, "completion")
+	llmCostTotal.Add(cost, meta.Model, meta.Purpose)
+
+	s.budget.Add(now, cost)
+	llmBudgetSpent.Set(s.budget.Spent())
+	if err := s.store.AddUsage(ctx, storage.UsageCall{
+		Day:              usage.Day(now),
+		Model:            meta.Model,
+		Purpose:          meta.Purpose,
+		Failed:           callErr != nil,
+		PromptTokens:     meta.PromptTokens,
+		CompletionTokens: meta.CompletionTokens,
+		Cost:             cost,
+	}); err != nil {
+		s.logger.Printf("record usage failed: %v", err)
+	}
+	return cost
+}
+
+// overBudget reports whether today's spend reached LLM_DAILY_BUDGET_USD.
+// Classification keeps running past the budget; summaries, extractions,
+// remote embeddings, questions and re-analysis jobs check this first and skip.
+func (s *Service) overBudget(ctx context.Context) bool {
+	if s.budget.Limit() <= 0 {
+		return false
+	}
+	if !s.budget.Loaded() {
+		day := usage.Day(time.Now())
+		spent, err := s.store.UsageCost(ctx, day)
+		if err != nil {
+			s.logger.Printf("load today's usage failed: %v", err)
+		} else {
+			s.budget.Seed(day, spent)
+		}
+	}
+	exceeded := s.budget.Exceeded()
+	llmBudgetSpent.Set(s.budget.Spent())
+	if exceeded {
+		llmBudgetExceeded.Set(1)
+		if !s.budgetNoticed.Swap(true) {
+			s.logger.Printf("daily LLM budget of $%.2f exhausted ($%.2f spent), pausing non-critical model calls", s.budget.Limit(), s.budget.Spent())
+		}
+	} else {
+		llmBudgetExceeded.Set(0)
+		s.budgetNoticed.Store(false)
+	}
+	return exceeded
+}
+
+// usageHandler serves GET /api/v1/admin/usage?period=day|month&since=YYYY-MM-DD
+// with token and cost totals per model and purpose, plus today's budget.
+func (s *Service) usageHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	q := r.URL.Query()
+	period := q.Get("period")
+	if period == "" {
+		period = "day"
+	}
+	if period != "day" && period != "month" {
+		http.Error(w, "period must be day or month", http.StatusBadRequest)
+		return
+	}
+	now := time.Now().UTC()
+	since := now.AddDate(0, 0, -30)
+	if period == "month" {
+		since = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -11, 0)
+	}
+	if v := q.Get("since"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			http.Error(w, "since must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		since = t
+	}
+
+	totals, err := s.store.UsageTotals(r.Context(), period, since)
+	if err != nil {
+		s.logger.Printf("usage totals failed: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	type periodTotal struct {
+		Period           string  `json:"period"`
+		Calls            int64   `json:"calls"`
+		PromptTokens     int64   `json:"prompt_tokens"`
+		CompletionTokens int64   `json:"completion_tokens"`
+		Cost             float64 `json:"cost_usd"`
+	}
+	var periods []periodTotal
+	for _, t := range totals {
+		if len(periods) == 0 || periods[len(periods)-1].Period != t.Period {
+			periods = append(periods, periodTotal{Period: t.Period})
+		}
+		p := &periods[len(periods)-1]
+		p.Calls += t.Calls
+		p.PromptTokens += t.PromptTokens
+		p.CompletionTokens += t.CompletionTokens
+		p.Cost += t.Cost
+	}
+	today := usage.Day(now)
+	spent, err := s.store.UsageCost(r.Context(), today)
+	if err != nil {
+		s.logger.Printf("usage cost failed: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"period":  period,
+		"since":   since.Format("2006-01-02"),
+		"totals":  periods,
+		"details": totals,
+		"budget": map[string]any{
+			"day":       today,
+			"limit_usd": s.budget.Limit(),
+			"spent_usd": spent,
+			"exceeded":  s.overBudget(r.Context()),
+		},
+	})
+}
@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"aiweb3news/internal/analysis"
+	"aiweb3news/internal/metrics"
+	"aiweb3news/internal/notify"
+	"aiweb3news/internal/rss"
+	"aiweb3news/internal/storage"
+	"aiweb3news/internal/watchlist"
+)
+
+var (
+	watchlistMatches = metrics.NewCounter("aiweb3news_watchlist_matches_total",
+		"Items that matched a watchlist entry, by list.", "list")
+	watchlistAlerts = metrics.NewCounter("aiweb3news_watchlist_alerts_total",
+		"Watchlist alerts by destination (empty for all) and status.", "channel", "status")
+	pendingWatchAlerts = metrics.NewGauge("aiweb3news_watchlist_alerts_pending",
+		"Watchlist alerts waiting to be delivered.")
+)
+
+// watchMatcher returns the matcher for the enabled entries, loading it on
+// first use. A failed load keeps the previous matcher.
+func (s *Service) watchMatcher(ctx context.Context) *watchlist.Matcher {
+	if m := s.watch.Load(); m != nil {
+		return m
+	}
+	return s.reloadWatchlist(ctx)
+}
+
+// reloadWatchlist rebuilds the matcher after the entries changed.
+func (s *Service) reloadWatchlist(ctx context.Context) *watchlist.Matcher {
+	entries, err := s.store.ListWatchEntries(ctx, "")
+	if err != nil {
+		s.logger.Printf("load watchlist failed: %v", err)
+		if m := s.watch.Load(); m != nil {
+			return m
+		}
+		return watchlist.NewMatcher(nil)
+	}
+	m := watchlist.NewMatcher(entries)
+	s.watch.Store(m)
+	return m
+}
+
+const (
+	// watchAlertBatch bounds how many pending alerts one poll retries.
+	watchAlertBatch = 100
+	// maxWatchAlertAttempts gives up on alerts that keep failing, so a
+	// destination that is gone for good does not block the outbox.
+	maxWatchAlertAttempts = 5
+)
+
+// queueWatchAlerts adds alerts for matches to the outbox, where they wait
+// until a destination accepted them.
+func (s *Service) queueWatchAlerts(ctx context.Context, item rss.Item, matches []watchlist.Match) {
+	if len(matches) == 0 {
+		return
+	}
+	if err := s.store.QueueWatchAlerts(ctx, item, matches); err != nil {
+		s.logger.Printf("queue watchlist alerts for %s failed: %v", item.Title, err)
+	}
+}
+
+// watchItem records which watchlist entries an item mentions and, when push is
+// set, alerts their channels whatever the classifier decided. It returns the
+// destinations that received the alert, so the regular push can skip them.
+func (s *Service) watchItem(ctx context.Context, itemID int64, item rss.Item, result analysis.Result, summary analysis.Summary, push bool) []string {
+	matches := s.watchMatcher(ctx).Match(item.Title, item.Description, result.Entities)
+	if err := s.store.SaveWatchMatches(ctx, itemID, matches); err != nil {
+		s.logger.Printf("save watchlist matches failed for %s: %v", item.Title, err)
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+	for _, m := range matches {
+		watchlistMatches.Inc(m.List)
+	}
+	s.logger.Printf("watchlist matched %s: %d entries", item.Title, len(matches))
+	if !push {
+		return nil
+	}
+	s.queueWatchAlerts(ctx, item, matches)
+	alerts, err := s.store.PendingWatchAlerts(ctx, item.GUID, watchAlertBatch)
+	if err != nil {
+		s.logger.Printf("%v", err)
+		return nil
+	}
+	return s.sendWatchAlerts(ctx, notify.Message{
+		Title:      item.Title,
+		Link:       item.Link,
+		Category:   result.Category,
+		Reason:     result.Reason,
+		Importance: result.Importance,
+		SummaryZH:  summary.SummaryZH,
+		TitleEN:    summary.TitleEN,
+		SummaryEN:  summary.SummaryEN,
+	}, alerts)
+}
+
+// retryWatchAlerts sends the alerts still in the outbox: those of items whose
+// analysis failed or was deferred, and those a destination refused before.
+// Items stored since carry their analysis, the others go out as they are.
+func (s *Service) retryWatchAlerts(ctx context.Context) {
+	defer s.countWatchAlerts(ctx)
+	alerts, err := s.store.PendingWatchAlerts(ctx, "", watchAlertBatch)
+	if err != nil {
+		s.logger.Printf("%v", err)
+		return
+	}
+	if len(alerts) > 0 {
+		s.logger.Printf("retrying %d watchlist alerts", len(alerts))
+	}
+	var (
+		guids  []string
+		byGUID = map[string][]storage.WatchAlert{}
+	)
+	for _, a := range alerts {
+		if _, ok := byGUID[a.GUID]; !ok {
+			guids = append(guids, a.GUID)
+		}
+		byGUID[a.GUID] = append(byGUID[a.GUID], a)
+	}
+	for _, guid := range guids {
+		s.sendWatchAlerts(ctx, s.watchMessage(ctx, byGUID[guid][0]), byGUID[guid])
+	}
+}
+
+// watchMessage builds the alert for a queued item from its stored analysis,
+// or from the feed entry when the item was never stored.
+func (s *Service) watchMessage(ctx context.Context, a storage.WatchAlert) notify.Message {
+	msg := notify.Message{
+		Title:    a.Title,
+		Link:     a.Link,
+		Category: "待分析",
+		Reason:   "模型分析尚未完成，先发送关注提醒",
+	}
+	id, err := s.store.ItemID(ctx, a.GUID)
+	if err != nil || id == 0 {
+		return msg
+	}
+	item, err := s.store.GetItem(ctx, id)
+	if err != nil {
+		s.logger.Printf("load item %d for watchlist alert failed: %v", id, err)
+		return msg
+	}
+	return notify.Message{
+		Title:      item.Title,
+		Link:       item.Link,
+		Category:   item.Category,
+		Reason:     item.Reason,
+		Importance: item.Importance,
+		SummaryZH:  item.SummaryZH,
+		TitleEN:    item.TitleEN,
+		SummaryEN:  item.SummaryEN,
+	}
+}
+
+// sendWatchAlerts sends one alert per channel, carrying the reasons of the
+// entries routed there, and records which destinations received it; a retry
+// only goes to the destinations that have not. It returns the destinations
+// that have received an alert.
+func (s *Service) sendWatchAlerts(ctx context.Context, base notify.Message, alerts []storage.WatchAlert) []string {
+	var (
+		groups  []string
+		byGroup = map[string][]storage.WatchAlert{}
+		chans   = map[string]string{}
+	)
+	for _, a := range alerts {
+		// Alerts of a channel are sent together only when the same
+		// destinations are still missing them.
+		ch := s.watchChannel(a.Channel)
+		key := ch + "\x00" + strings.Join(a.Delivered, ",")
+		if _, ok := byGroup[key]; !ok {
+			groups = append(groups, key)
+			chans[key] = ch
+		}
+		byGroup[key] = append(byGroup[key], a)
+	}
+	var delivered []string
+	for _, key := range groups {
+		group := byGroup[key]
+		ch := chans[key]
+		msg := base
+		msg.WatchZH, msg.WatchEN = nil, nil
+		ids := make([]int64, 0, len(group))
+		giveUp := false
+		for _, a := range group {
+			msg.WatchZH = append(msg.WatchZH, a.ReasonZH)
+			msg.WatchEN = append(msg.WatchEN, a.ReasonEN)
+			ids = append(ids, a.ID)
+			giveUp = giveUp || a.Attempts+1 >= maxWatchAlertAttempts
+		}
+		sent, err := s.notifier.NotifyTo(ctx, ch, msg, group[0].Delivered...)
+		done := append(append([]string(nil), group[0].Delivered...), sent...)
+		delivered = append(delivered, done...)
+		switch {
+		case err == nil:
+			watchlistAlerts.Inc(ch, "ok")
+		case giveUp:
+			watchlistAlerts.Inc(ch, "error")
+			s.logger.Printf("giving up on watchlist alert for %s after %d attempts: %v", base.Title, maxWatchAlertAttempts, err)
+		default:
+			watchlistAlerts.Inc(ch, "error")
+			s.logger.Printf("watchlist alert for %s failed, will retry: %v", base.Title, err)
+		}
+		if err := s.store.FinishWatchAlerts(ctx, ids, done, err, maxWatchAlertAttempts); err != nil {
+			s.logger.Printf("%v", err)
+		}
+	}
+	return delivered
+}
+
+func (s *Service) countWatchAlerts(ctx context.Context) {
+	n, err := s.store.CountPendingWatchAlerts(ctx)
+	if err != nil {
+		s.logger.Printf("count pending watchlist alerts failed: %v", err)
+		return
+	}
+	pendingWatchAlerts.Set(float64(n))
+}
+
+// watchChannel resolves an entry's channel, falling back to WATCHLIST_CHANNEL
+// and then to every destination when the configured one does not exist.
+func (s *Service) watchChannel(channel string) string {
+	for _, ch := range []string{channel, s.cfg.WatchlistChannel} {
+		if ch == "" {
+			continue
+		}
+		if s.notifier.Has(ch) {
+			return ch
+		}
+		s.logger.Printf("watchlist channel %q is not a notify destination, falling back", ch)
+	}
+	return ""
+}
+
+// watchEntryRequest is the body of watchlist create and update calls.
+type watchEntryRequest struct {
+	List       string   `json:"list"`
+	Name       string   `json:"name"`
+	Kind       string   `json:"kind"`
+	EntityType string   `json:"entity_type"`
+	Aliases    []string `json:"aliases"`
+	Channel    string   `json:"channel"`
+	// Enabled defaults to true.
+	Enabled *bool `json:"enabled"`
+}
+
+// entry validates the request as the entry with id.
+func (s *Service) entry(req watchEntryRequest, id int64) (watchlist.Entry, error) {
+	e := watchlist.Entry{
+		ID:         id,
+		List:       req.List,
+		Name:       req.Name,
+		Kind:       req.Kind,
+		EntityType: req.EntityType,
+		Aliases:    req.Aliases,
+		Channel:    req.Channel,
+		Enabled:    req.Enabled == nil || *req.Enabled,
+	}
+	if err := e.Normalize(); err != nil {
+		return e, err
+	}
+	if e.Channel != "" && !s.notifier.Has(e.Channel) {
+		return e, fmt.Errorf("channel %q is not a notify destination", e.Channel)
+	}
+	return e, nil
+}
+
+// watchlistHandler lists (GET, optionally ?list=) and creates (POST) entries.
+func (s *Service) watchlistHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		entries, err := s.store.ListWatchEntries(r.Context(), strings.TrimSpace(r.URL.Query().Get("list")))
+		if err != nil {
+			s.logger.Printf("list watchlist failed: %v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, struct {
+			Count   int               `json:"count"`
+			Entries []watchlist.Entry `json:"entries"`
+		}{
+			Count:   len(entries),
+			Entries: entries,
+		})
+	case http.MethodPost:
+		var req watchEntryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json body", http.StatusBadRequest)
+			return
+		}
+		e, err := s.entry(req, 0)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		id, err := s.store.CreateWatchEntry(r.Context(), e)
+		if err != nil {
+			s.logger.Printf("%v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		s.reloadWatchlist(r.Context())
+		s.writeWatchEntry(w, r, id, http.StatusCreated)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// watchlistEntryHandler reads (GET), replaces (PUT) and deletes (DELETE)
+// /api/v1/admin/watchlist/{id}.
+func (s *Service) watchlistEntryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/admin/watchlist/"), "/"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid entry id", http.StatusBadRequest)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		s.writeWatchEntry(w, r, id, http.StatusOK)
+	case http.MethodPut:
+		var req watchEntryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json body", http.StatusBadRequest)
+			return
+		}
+		e, err := s.entry(req, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !s.watchEntryResult(w, id, s.store.UpdateWatchEntry(r.Context(), e)) {
+			return
+		}
+		s.reloadWatchlist(r.Context())
+		s.writeWatchEntry(w, r, id, http.StatusOK)
+	case http.MethodDelete:
+		if !s.watchEntryResult(w, id, s.store.DeleteWatchEntry(r.Context(), id)) {
+			return
+		}
+		s.reloadWatchlist(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Service) writeWatchEntry(w http.ResponseWriter, r *http.Request, id int64, status int) {
+	e, err := s.store.GetWatchEntry(r.Context(), id)
+	if !s.watchEntryResult(w, id, err) {
+		return
+	}
+	s.writeJSON(w, status, e)
+}
+
+// watchEntryResult writes the error response for err and reports whether the
+// handler may go on.
+func (s *Service) watchEntryResult(w http.ResponseWriter, id int64, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, storage.ErrNotFound):
+		http.Error(w, "watchlist entry not found", http.StatusNotFound)
+	default:
+		s.logger.Printf("watchlist entry %d: %v", id, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+	return false
+}
+
+// itemWatchlistHandler lists the watchlist matches of an item and why they matched.
+func (s *Service) itemWatchlistHandler(w http.ResponseWriter, r *http.Request, itemID int64) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	matches, err := s.store.ListWatchMatches(r.Context(), itemID)
+	if err != nil {
+		s.logger.Printf("list watchlist matches for item %d failed: %v", itemID, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, struct {
+		ItemID  int64                `json:"item_id"`
+		Count   int                  `json:"count"`
+		Matches []storage.WatchMatch `json:"matches"`
+	}{
+		ItemID:  itemID,
+		Count:   len(matches),
+		Matches: matches,
+	})
+}
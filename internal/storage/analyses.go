@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"aiweb3news/internal/analysis"
+)
+
+const createAnalysesTable = `
+CREATE TABLE IF NOT EXISTS analyses (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	item_id BIGINT NOT NULL,
+	model VARCHAR(128) NOT NULL,
+	prompt_version VARCHAR(64) NOT NULL,
+	relevant TINYINT(1) NOT NULL,
+	category VARCHAR(255),
+	reason TEXT,
+	tags TEXT,
+	raw_response MEDIUMTEXT,
+	latency_ms INT NOT NULL DEFAULT 0,
+	prompt_tokens INT NOT NULL DEFAULT 0,
+	completion_tokens INT NOT NULL DEFAULT 0,
+	total_tokens INT NOT NULL DEFAULT 0,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	INDEX idx_analyses_item (item_id, id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+// ErrNotFound is returned when a requested row does not exist.
+var ErrNotFound = errors.New("not found")
+
+// AnalysisRecord is one immutable entry of an item's analysis history.
+type AnalysisRecord struct {
+	ID               int64    `json:"id"`
+	ItemID           int64    `json:"item_id"`
+	Model            string   `json:"model"`
+	PromptVersion    string   `json:"prompt_version"`
+	Relevant         bool     `json:"relevant"`
+	Category         string   `json:"category"`
+	Reason           string   `json:"reason"`
+	Tags             []string `json:"tags"`
+	Importance       int      `json:"importance"`
+	Confidence       float64  `json:"confidence"`
+	RawResponse      string   `json:"raw_response"`
+	LatencyMS        int64    `json:"latency_ms"`
+	PromptTokens     int      `json:"prompt_tokens"`
+	CompletionTokens int      `json:"completion_tokens"`
+	TotalTokens      int      `json:"total_tokens"`
+	// FewShotIDs lists the labeled items shown to the model as examples.
+	FewShotIDs []int64   `json:"few_shot_ids,omitempty"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AnalysisDiff lists the differences between two analyses of the same item.
+type AnalysisDiff struct {
+	From        AnalysisRecord `json:"from"`
+	To          AnalysisRecord `json:"to"`
+	Changed     []string       `json:"changed"`
+	TagsAdded   []string       `json:"tags_added"`
+	TagsRemoved []string       `json:"tags_removed"`
+}
+
+func insertAnalysis(ctx context.Context, tx *sql.Tx, itemID int64, result analysis.Result) (int64, error) {
+	tagsJSON, _ := json.Marshal(result.Tags)
+	meta := result.Meta
+	var fewShot sql.NullString
+	if len(meta.ExampleIDs) > 0 {
+		ids, _ := json.Marshal(meta.ExampleIDs)
+		fewShot = sql.NullString{String: string(ids), Valid: true}
+	}
+	res, err := tx.ExecContext(ctx, `
+INSERT INTO analyses (item_id, model, prompt_version, relevant, category, reason, tags, importance, confidence, raw_response,
+	latency_ms, prompt_tokens, completion_tokens, total_tokens, few_shot_ids)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		itemID, meta.Model, meta.PromptVersion, result.Relevant, result.Category, result.Reason, string(tagsJSON), result.Importance, result.Confidence, meta.RawResponse,
+		meta.Latency.Milliseconds(), meta.PromptTokens, meta.CompletionTokens, meta.TotalTokens, fewShot)
+	if err != nil {
+		return 0, fmt.Errorf("insert analysis: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("insert analysis: %w", err)
+	}
+	return id, nil
+}
+
+// ListAnalyses returns the analysis history of an item, newest first.
+func (s *Store) ListAnalyses(ctx context.Context, itemID int64) ([]AnalysisRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT a.id, a.item_id, a.model, a.prompt_version, a.relevant, a.category, a.reason, a.tags, a.importance, a.confidence, a.raw_response,
+	a.latency_ms, a.prompt_tokens, a.completion_tokens, a.total_tokens, a.created_at, a.few_shot_ids,
+	COALESCE(n.current_analysis_id = a.id, 0)
+FROM analyses a
+LEFT JOIN news_analysis n ON n.id = a.item_id
+WHERE a.item_id = ?
+ORDER BY a.id DESC`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("list analyses: %w", err)
+	}
+	defer rows.Close()
+
+	var records []AnalysisRecord
+	for rows.Next() {
+		rec, err := scanAnalysis(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// GetAnalysis loads a single analysis belonging to itemID.
+func (s *Store) GetAnalysis(ctx context.Context, itemID, analysisID int64) (AnalysisRecord, error) {
+	row := s.db.QueryRowContext(ctx, `
+SELECT a.id, a.item_id, a.model, a.prompt_version, a.relevant, a.category, a.reason, a.tags, a.importance, a.confidence, a.raw_response,
+	a.latency_ms, a.prompt_tokens, a.completion_tokens, a.total_tokens, a.created_at, a.few_shot_ids,
+	COALESCE(n.current_analysis_id = a.id, 0)
+FROM analyses a
+LEFT JOIN news_analysis n ON n.id = a.item_id
+WHERE a.item_id = ? AND a.id = ?`, itemID, analysisID)
+	rec, err := scanAnalysis(row)
+	if err == sql.ErrNoRows {
+		return AnalysisRecord{}, ErrNotFound
+	}
+	return rec, err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAnalysis(row rowScanner) (AnalysisRecord, error) {
+	var (
+		rec      AnalysisRecord
+		category sql.NullString
+		reason   sql.NullString
+		tags     sql.NullString
+		raw      sql.NullString
+		fewShot  sql.NullString
+		current  int
+	)
+	if err := row.Scan(&rec.ID, &rec.ItemID, &rec.Model, &rec.PromptVersion, &rec.Relevant, &category, &reason, &tags, &rec.Importance, &rec.Confidence, &raw,
+		&rec.LatencyMS, &rec.PromptTokens, &rec.CompletionTokens, &rec.TotalTokens, &rec.CreatedAt, &fewShot, &current); err != nil {
+		return AnalysisRecord{}, err
+	}
+	rec.Category = category.String
+	rec.Reason = reason.String
+	rec.RawResponse = raw.String
+	rec.Current = current == 1
+	rec.Tags = decodeTags(tags)
+	if fewShot.Valid {
+		_ = json.Unmarshal([]byte(fewShot.String), &rec.FewShotIDs)
+	}
+	return rec, nil
+}
+
+func decodeTags(tags sql.NullString) []string {
+	if !tags.Valid || tags.String == "" {
+		return nil
+	}
+	var parsed []string
+	if err := json.Unmarshal([]byte(tags.String), &parsed); err != nil {
+		return nil
+	}
+	return parsed
+}
+
+// DiffAnalyses compares two analyses field by field.
+func DiffAnalyses(from, to AnalysisRecord) AnalysisDiff {
+	diff := AnalysisDiff{From: from, To: to, Changed: []string{}}
+	if from.Model != to.Model {
+		diff.Changed = append(diff.Changed, "model")
+	}
+	if from.PromptVersion != to.PromptVersion {
+		diff.Changed = append(diff.Changed, "prompt_version")
+	}
+	if from.Relevant != to.Relevant {
+		diff.Changed = append(diff.Changed, "relevant")
+	}
+	if from.Category != to.Category {
+		diff.Changed = append(diff.Changed, "category")
+	}
+	if from.Reason != to.Reason {
+		diff.Changed = append(diff.Changed, "reason")
+	}
+	if from.Importance != to.Importance {
+		diff.Changed = append(diff.Changed, "importance")
+	}
+	if from.Confidence != to.Confidence {
+		diff.Changed = append(diff.Changed, "confidence")
+	}
+
+	before := make(map[string]bool, len(from.Tags))
+	for _, t := range from.Tags {
+		before[t] = true
+	}
+	after := make(map[string]bool, len(to.Tags))
+	for _, t := range to.Tags {
+		after[t] = true
+		if !before[t] {
+			diff.TagsAdded = append(diff.TagsAdded, t)
+		}
+	}
+	for _, t := range from.Tags {
+		if !after[t] {
+			diff.TagsRemoved = append(diff.TagsRemoved, t)
+		}
+	}
+	if len(diff.TagsAdded) > 0 || len(diff.TagsRemoved) > 0 {
+		diff.Changed = append(diff.Changed, "tags")
+	}
+	return diff
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"aiweb3news/internal/analysis"
+)
+
+const createAnalysisCacheTable = `
+CREATE TABLE IF NOT EXISTS analysis_cache (
+	cache_key CHAR(64) PRIMARY KEY,
+	model VARCHAR(128) NOT NULL,
+	prompt_version VARCHAR(64) NOT NULL,
+	result MEDIUMTEXT NOT NULL,
+	raw_response MEDIUMTEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	INDEX idx_analysis_cache_created (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+// CachedAnalysis implements analysis.CacheStore.
+func (s *Store) CachedAnalysis(ctx context.Context, key string, since time.Time) (analysis.CacheEntry, bool, error) {
+	var (
+		entry       analysis.CacheEntry
+		result      string
+		rawResponse sql.NullString
+	)
+	err := s.db.QueryRowContext(ctx, `
+SELECT cache_key, model, prompt_version, result, raw_response, created_at
+FROM analysis_cache
+WHERE cache_key = ? AND created_at >= ?`, key, since).Scan(&entry.Key, &entry.Model, &entry.PromptVersion, &result, &rawResponse, &entry.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entry, false, nil
+	}
+	if err != nil {
+		return entry, false, fmt.Errorf("cached analysis: %w", err)
+	}
+	if err := json.Unmarshal([]byte(result), &entry.Result); err != nil {
+		return entry, false, fmt.Errorf("decode cached analysis %s: %w", key, err)
+	}
+	entry.RawResponse = rawResponse.String
+	return entry, true, nil
+}
+
+// SaveCachedAnalysis implements analysis.CacheStore. A newer classification
+// of the same key replaces the old one and restarts its TTL.
+func (s *Store) SaveCachedAnalysis(ctx context.Context, entry analysis.CacheEntry) error {
+	result, err := json.Marshal(entry.Result)
+	if err != nil {
+		return fmt.Errorf("encode cached analysis: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+INSERT INTO analysis_cache (cache_key, model, prompt_version, result, raw_response)
+VALUES (?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE model = VALUES(model), prompt_version = VALUES(prompt_version), result = VALUES(result),
+	raw_response = VALUES(raw_response), created_at = CURRENT_TIMESTAMP`,
+		entry.Key, truncate(entry.Model, 128), truncate(entry.PromptVersion, 64), result, entry.RawResponse)
+	if err != nil {
+		return fmt.Errorf("save cached analysis: %w", err)
+	}
+	return nil
+}
+
+// PurgeAnalysisCache deletes cache entries created before before. With dryRun it only counts them.
+func (s *Store) PurgeAnalysisCache(ctx context.Context, before time.Time, dryRun bool) (int64, error) {
+	if dryRun {
+		return s.count(ctx, "SELECT COUNT(*) FROM analysis_cache WHERE created_at < ?", before)
+	}
+	res, err := s.db.ExecContext(ctx, "DELETE FROM analysis_cache WHERE created_at < ?", before)
+	if err != nil {
+		return 0, fmt.Errorf("purge analysis cache: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"aiweb3news/internal/rss"
+)
+
+const createDeferredItemsTable = `
+CREATE TABLE IF NOT EXISTS deferred_items (
+	guid VARCHAR(255) PRIMARY KEY,
+	title VARCHAR(512) NOT NULL,
+	link VARCHAR(1024) NOT NULL,
+	published_at DATETIME NULL,
+	summary TEXT,
+	push TINYINT(1) NOT NULL DEFAULT 1,
+	reason TEXT,
+	attempts INT NOT NULL DEFAULT 0,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+// DeferredItem is an item whose analysis was postponed, e.g. because the
+// model provider was unavailable.
+type DeferredItem struct {
+	Item     rss.Item
+	Push     bool
+	Reason   string
+	Attempts int
+	Created  time.Time
+}
+
+// DeferItem queues an item for a later attempt. Deferring a queued item
+// again records the new reason; failed counts it as a failed attempt, as
+// opposed to one that was not made at all.
+func (s *Store) DeferItem(ctx context.Context, item rss.Item, push bool, reason string, failed bool) error {
+	var published any
+	if !item.PublishedAt.IsZero() {
+		published = item.PublishedAt
+	}
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO deferred_items (guid, title, link, published_at, summary, push, reason, attempts)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE reason = VALUES(reason), attempts = attempts + VALUES(attempts)`,
+		truncate(item.GUID, 255), truncate(item.Title, 512), truncate(item.Link, 1024), published, item.Description, push, reason, boolInt(failed))
+	if err != nil {
+		return fmt.Errorf("defer item: %w", err)
+	}
+	return nil
+}
+
+// DeferredItems returns queued items, oldest first.
+func (s *Store) DeferredItems(ctx context.Context, limit int) ([]DeferredItem, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT guid, title, link, published_at, COALESCE(summary, ''), push, COALESCE(reason, ''), attempts, created_at
+FROM deferred_items
+ORDER BY created_at, guid
+LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list deferred items: %w", err)
+	}
+	defer rows.Close()
+
+	var out []DeferredItem
+	for rows.Next() {
+		var (
+			d         DeferredItem
+			published sql.NullTime
+		)
+		if err := rows.Scan(&d.Item.GUID, &d.Item.Title, &d.Item.Link, &published, &d.Item.Description, &d.Push, &d.Reason, &d.Attempts, &d.Created); err != nil {
+			return nil, err
+		}
+		d.Item.PublishedAt = published.Time
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// CountDeferred returns how many items are queued.
+func (s *Store) CountDeferred(ctx context.Context) (int64, error) {
+	return s.count(ctx, "SELECT COUNT(*) FROM deferred_items")
+}
+
+// DeleteDeferred removes an item from the queue.
+func (s *Store) DeleteDeferred(ctx context.Context, guid string) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM deferred_items WHERE guid = ?", guid); err != nil {
+		return fmt.Errorf("delete deferred item: %w", err)
+	}
+	return nil
+}
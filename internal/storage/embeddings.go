@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+const createItemEmbeddingsTable = `
+CREATE TABLE IF NOT EXISTS item_embeddings (
+	item_id BIGINT NOT NULL PRIMARY KEY,
+	model VARCHAR(128) NOT NULL,
+	dim INT NOT NULL,
+	content_hash CHAR(64) NOT NULL,
+	vector MEDIUMBLOB NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	INDEX idx_item_embeddings_model (model)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+// EmbeddingHash returns the content hash stored for an item's vector, or ""
+// when the item has no vector from model.
+func (s *Store) EmbeddingHash(ctx context.Context, itemID int64, model string) (string, error) {
+	var hash string
+	err := s.db.QueryRowContext(ctx, "SELECT content_hash FROM item_embeddings WHERE item_id = ? AND model = ?", itemID, model).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("embedding hash: %w", err)
+	}
+	return hash, nil
+}
+
+// SaveEmbedding stores the vector of an item, replacing an older one.
+func (s *Store) SaveEmbedding(ctx context.Context, itemID int64, model, contentHash string, vec []float32) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO item_embeddings (item_id, model, dim, content_hash, vector)
+VALUES (?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE
+	model=VALUES(model),
+	dim=VALUES(dim),
+	content_hash=VALUES(content_hash),
+	vector=VALUES(vector)`, itemID, model, len(vec), contentHash, encodeVector(vec))
+	if err != nil {
+		return fmt.Errorf("save embedding: %w", err)
+	}
+	return nil
+}
+
+// LoadEmbeddings passes the stored vectors produced by model of items
+// published since since to fn.
+func (s *Store) LoadEmbeddings(ctx context.Context, model string, since time.Time, fn func(itemID int64, vec []float32)) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT e.item_id, e.vector
+FROM item_embeddings e
+JOIN news_analysis n ON n.id = e.item_id
+WHERE e.model = ? AND COALESCE(n.published_at, n.created_at) >= ?`, model, since)
+	if err != nil {
+		return 0, fmt.Errorf("load embeddings: %w", err)
+	}
+	defer rows.Close()
+	n := 0
+	for rows.Next() {
+		var (
+			id  int64
+			raw []byte
+		)
+		if err := rows.Scan(&id, &raw); err != nil {
+			return n, err
+		}
+		fn(id, decodeVector(raw))
+		n++
+	}
+	return n, rows.Err()
+}
+
+// ItemsWithoutEmbedding lists up to limit items, oldest first, that have no
+// vector from model, for backfilling.
+func (s *Store) ItemsWithoutEmbedding(ctx context.Context, model string, afterID int64, limit int) ([]StoredItem, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT `+itemColumns+` FROM news_analysis
+WHERE id > ? AND NOT EXISTS (SELECT 1 FROM item_embeddings e WHERE e.item_id = news_analysis.id AND e.model = ?)
+ORDER BY id
+LIMIT ?`, afterID, model, limit)
+	if err != nil {
+		return nil, fmt.Errorf("items without embedding: %w", err)
+	}
+	defer rows.Close()
+	var items []StoredItem
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func encodeVector(vec []float32) []byte {
+	buf := make([]byte, 4*len(vec))
+	for i, x := range vec {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(x))
+	}
+	return buf
+}
+
+func decodeVector(buf []byte) []float32 {
+	vec := make([]float32, len(buf)/4)
+	for i := range vec {
+		vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return vec
+}
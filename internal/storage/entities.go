@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"aiweb3news/internal/analysis"
+)
+
+const createItemEntitiesTable = `
+CREATE TABLE IF NOT EXISTS item_entities (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	item_id BIGINT NOT NULL,
+	type VARCHAR(32) NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	amount DECIMAL(24,2) NULL,
+	currency VARCHAR(8),
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	INDEX idx_item_entities_item (item_id),
+	INDEX idx_item_entities_name (type, name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+// EntityCount aggregates how often an entity appears across items.
+type EntityCount struct {
+	Type     string    `json:"type"`
+	Name     string    `json:"name"`
+	Count    int       `json:"count"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// EntityFilter narrows the entity listing.
+type EntityFilter struct {
+	Type  string
+	Query string
+	Since time.Time
+	Limit int
+}
+
+// replaceEntities swaps the entities of an item for those of its current analysis.
+func replaceEntities(ctx context.Context, tx *sql.Tx, itemID int64, entities []analysis.Entity) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM item_entities WHERE item_id = ?", itemID); err != nil {
+		return fmt.Errorf("replace entities: %w", err)
+	}
+	for _, e := range entities {
+		var amount sql.NullFloat64
+		if e.Type == analysis.EntityAmount && e.Amount > 0 {
+			amount = sql.NullFloat64{Float64: e.Amount, Valid: true}
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO item_entities (item_id, type, name, amount, currency) VALUES (?, ?, ?, ?, ?)",
+			itemID, e.Type, truncate(e.Name, 255), amount, e.Currency); err != nil {
+			return fmt.Errorf("insert entity: %w", err)
+		}
+	}
+	return nil
+}
+
+// ItemEntities returns the entities stored for an item.
+func (s *Store) ItemEntities(ctx context.Context, itemID int64) ([]analysis.Entity, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT type, name, amount, currency FROM item_entities WHERE item_id = ? ORDER BY id", itemID)
+	if err != nil {
+		return nil, fmt.Errorf("item entities: %w", err)
+	}
+	defer rows.Close()
+
+	entities := []analysis.Entity{}
+	for rows.Next() {
+		var (
+			e        analysis.Entity
+			amount   sql.NullFloat64
+			currency sql.NullString
+		)
+		if err := rows.Scan(&e.Type, &e.Name, &amount, &currency); err != nil {
+			return nil, err
+		}
+		e.Amount = amount.Float64
+		e.Currency = currency.String
+		entities = append(entities, e)
+	}
+	return entities, rows.Err()
+}
+
+// ListEntities returns entities ordered by the number of items mentioning them.
+func (s *Store) ListEntities(ctx context.Context, f EntityFilter) ([]EntityCount, error) {
+	clauses := []string{"e.type <> ?"}
+	args := []any{analysis.EntityAmount}
+	if f.Type != "" {
+		clauses = append(clauses, "e.type = ?")
+		args = append(args, f.Type)
+	}
+	if f.Query != "" {
+		clauses = append(clauses, "e.name LIKE ?")
+		args = append(args, "%"+likeEscape(f.Query)+"%")
+	}
+	if !f.Since.IsZero() {
+		clauses = append(clauses, "COALESCE(n.published_at, n.created_at) >= ?")
+		args = append(args, f.Since)
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, `
+SELECT e.type, e.name, COUNT(DISTINCT e.item_id), MAX(n.published_at)
+FROM item_entities e
+JOIN news_analysis n ON n.id = e.item_id
+WHERE `+strings.Join(clauses, " AND ")+`
+GROUP BY e.type, e.name
+ORDER BY COUNT(DISTINCT e.item_id) DESC, e.name
+LIMIT ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list entities: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []EntityCount{}
+	for rows.Next() {
+		var (
+			c    EntityCount
+			last sql.NullTime
+		)
+		if err := rows.Scan(&c.Type, &c.Name, &c.Count, &last); err != nil {
+			return nil, err
+		}
+		c.LastSeen = last.Time
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
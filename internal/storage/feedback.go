@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const createItemFeedbackTable = `
+CREATE TABLE IF NOT EXISTS item_feedback (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	item_id BIGINT NOT NULL,
+	user VARCHAR(128) NOT NULL,
+	relevant TINYINT(1) NOT NULL,
+	category VARCHAR(255) NOT NULL DEFAULT '',
+	comment TEXT,
+	analysis_id BIGINT NULL,
+	model_relevant TINYINT(1) NOT NULL,
+	model_category VARCHAR(255) NOT NULL DEFAULT '',
+	agrees TINYINT(1) NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	INDEX idx_item_feedback_item (item_id),
+	INDEX idx_item_feedback_created (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+// Feedback is an analyst's label for an item together with the model
+// answer it was compared against.
+type Feedback struct {
+	ID            int64     `json:"id"`
+	ItemID        int64     `json:"item_id"`
+	User          string    `json:"user"`
+	Relevant      bool      `json:"relevant"`
+	Category      string    `json:"category"`
+	Comment       string    `json:"comment"`
+	AnalysisID    int64     `json:"analysis_id,omitempty"`
+	ModelRelevant bool      `json:"model_relevant"`
+	ModelCategory string    `json:"model_category"`
+	Agrees        bool      `json:"agrees"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AgreementPeriod is the agreement between analysts and the model over one period.
+type AgreementPeriod struct {
+	Period string `json:"period"`
+	Labels int    `json:"labels"`
+	// RelevanceAgreement only compares the relevant flag; Agreement also
+	// requires the category to match for items both sides found relevant.
+	RelevanceAgreement float64 `json:"relevance_agreement"`
+	Agreement          float64 `json:"agreement"`
+}
+
+// LabeledItem is an item with its most recent human label.
+type LabeledItem struct {
+	ItemID      int64     `json:"item_id"`
+	Title       string    `json:"title"`
+	Link        string    `json:"link"`
+	PublishedAt time.Time `json:"published_at"`
+	Summary     string    `json:"summary"`
+	Relevant    bool      `json:"relevant"`
+	Category    string    `json:"category"`
+	User        string    `json:"user"`
+	Comment     string    `json:"comment"`
+	LabeledAt   time.Time `json:"labeled_at"`
+}
+
+// SaveFeedback stores a label against the item's current analysis. Earlier
+// labels of the item are kept; the latest one is authoritative.
+func (s *Store) SaveFeedback(ctx context.Context, f Feedback) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `
+INSERT INTO item_feedback (item_id, user, relevant, category, comment, analysis_id, model_relevant, model_category, agrees)
+SELECT ?, ?, ?, ?, ?, current_analysis_id, ?, ?, ? FROM news_analysis WHERE id = ?`,
+		f.ItemID, truncate(f.User, 128), f.Relevant, truncate(f.Category, 255), f.Comment, f.ModelRelevant, truncate(f.ModelCategory, 255), f.Agrees, f.ItemID)
+	if err != nil {
+		return 0, fmt.Errorf("save feedback: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// ListFeedback returns every label of an item, newest first.
+func (s *Store) ListFeedback(ctx context.Context, itemID int64) ([]Feedback, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, item_id, user, relevant, category, comment, analysis_id, model_relevant, model_category, agrees, created_at
+FROM item_feedback
+WHERE item_id = ?
+ORDER BY id DESC`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("list feedback: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Feedback
+	for rows.Next() {
+		var (
+			f          Feedback
+			comment    sql.NullString
+			analysisID sql.NullInt64
+		)
+		if err := rows.Scan(&f.ID, &f.ItemID, &f.User, &f.Relevant, &f.Category, &comment, &analysisID, &f.ModelRelevant, &f.ModelCategory, &f.Agrees, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		f.Comment = comment.String
+		f.AnalysisID = analysisID.Int64
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+// FeedbackAgreement returns the agreement rate per week or month for labels
+// created since, oldest period first.
+func (s *Store) FeedbackAgreement(ctx context.Context, period string, since time.Time) ([]AgreementPeriod, error) {
+	format := "%Y-%m"
+	if period == "week" {
+		format = "%x-W%v"
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT DATE_FORMAT(created_at, '`+format+`'), COUNT(*), AVG(relevant = model_relevant), AVG(agrees)
+FROM item_feedback
+WHERE created_at >= ?
+GROUP BY 1
+ORDER BY 1`, since)
+	if err != nil {
+		return nil, fmt.Errorf("feedback agreement: %w", err)
+	}
+	defer rows.Close()
+
+	var out []AgreementPeriod
+	for rows.Next() {
+		var p AgreementPeriod
+		if err := rows.Scan(&p.Period, &p.Labels, &p.RelevanceAgreement, &p.Agreement); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// LabeledItems returns items with their latest label, most recently labeled
+// first. A zero limit returns every labeled item.
+func (s *Store) LabeledItems(ctx context.Context, limit int) ([]LabeledItem, error) {
+	query := `
+SELECT n.id, n.title, n.link, n.published_at, n.summary, f.relevant, f.category, f.user, f.comment, f.created_at
+FROM item_feedback f
+JOIN news_analysis n ON n.id = f.item_id
+WHERE f.id IN (SELECT MAX(id) FROM item_feedback GROUP BY item_id)
+ORDER BY f.id DESC`
+	var args []any
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("labeled items: %w", err)
+	}
+	defer rows.Close()
+
+	var out []LabeledItem
+	for rows.Next() {
+		var (
+			l             LabeledItem
+			link, summary sql.NullString
+			comment       sql.NullString
+			pub           sql.NullTime
+		)
+		if err := rows.Scan(&l.ItemID, &l.Title, &link, &pub, &summary, &l.Relevant, &l.Category, &l.User, &comment, &l.LabeledAt); err != nil {
+			return nil, err
+		}
+		l.Link = link.String
+		l.PublishedAt = pub.Time
+		l.Summary = summary.String
+		l.Comment = comment.String
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
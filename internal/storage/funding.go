@@ -0,0 +1,292 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const createFundingTables = `
+CREATE TABLE IF NOT EXISTS funding_rounds (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	project VARCHAR(255) NOT NULL,
+	project_key VARCHAR(255) NOT NULL,
+	amount_usd DECIMAL(20,2) NOT NULL DEFAULT 0,
+	round VARCHAR(64) NOT NULL DEFAULT '',
+	sector VARCHAR(64) NOT NULL DEFAULT '',
+	announced_on DATE NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	INDEX idx_funding_project (project_key, round),
+	INDEX idx_funding_announced (announced_on)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+const createFundingInvestorsTable = `
+CREATE TABLE IF NOT EXISTS funding_investors (
+	round_id BIGINT NOT NULL,
+	investor VARCHAR(255) NOT NULL,
+	lead TINYINT(1) NOT NULL DEFAULT 0,
+	PRIMARY KEY (round_id, investor),
+	INDEX idx_funding_investor (investor)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+const createFundingRoundItemsTable = `
+CREATE TABLE IF NOT EXISTS funding_round_items (
+	round_id BIGINT NOT NULL,
+	item_id BIGINT NOT NULL,
+	PRIMARY KEY (round_id, item_id),
+	INDEX idx_funding_item (item_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+// fundingDedupWindowDays is how far apart two reports of the same project and
+// round may be dated and still be treated as one round.
+const fundingDedupWindowDays = 45
+
+// FundingRound is a deduplicated financing event and the items reporting it.
+type FundingRound struct {
+	ID            int64     `json:"id"`
+	Project       string    `json:"project"`
+	AmountUSD     float64   `json:"amount_usd"`
+	Round         string    `json:"round"`
+	Sector        string    `json:"sector"`
+	AnnouncedOn   time.Time `json:"announced_on"`
+	LeadInvestors []string  `json:"lead_investors"`
+	Investors     []string  `json:"investors"`
+	ItemIDs       []int64   `json:"item_ids"`
+}
+
+// FundingFilter narrows funding listings and aggregations.
+type FundingFilter struct {
+	Sector    string
+	Investor  string
+	Round     string
+	Since     time.Time
+	Until     time.Time
+	MinAmount float64
+	Limit     int
+}
+
+// FundingAggregate is one bucket of a funding aggregation.
+type FundingAggregate struct {
+	Key      string  `json:"key"`
+	Rounds   int     `json:"rounds"`
+	TotalUSD float64 `json:"total_usd"`
+}
+
+// SaveFundingRound records a round reported by itemID. A round of the same
+// project and stage announced within the dedup window is merged: the largest
+// amount wins, missing fields are filled and investors are unioned. An unknown
+// stage on either side matches any stage, preferring an exact one.
+func (s *Store) SaveFundingRound(ctx context.Context, itemID int64, round FundingRound) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("save funding round: %w", err)
+	}
+	defer tx.Rollback()
+
+	projectKey := strings.ToLower(round.Project)
+	var roundID int64
+	err = tx.QueryRowContext(ctx, `
+SELECT id FROM funding_rounds
+WHERE project_key = ? AND (round = ? OR round = '' OR ? = '') AND ABS(DATEDIFF(announced_on, ?)) <= ?
+ORDER BY round <> ?, ABS(DATEDIFF(announced_on, ?))
+LIMIT 1
+FOR UPDATE`, projectKey, round.Round, round.Round, round.AnnouncedOn, fundingDedupWindowDays, round.Round, round.AnnouncedOn).Scan(&roundID)
+	switch {
+	case err == sql.ErrNoRows:
+		res, err := tx.ExecContext(ctx, `
+INSERT INTO funding_rounds (project, project_key, amount_usd, round, sector, announced_on)
+VALUES (?, ?, ?, ?, ?, ?)`, round.Project, projectKey, round.AmountUSD, round.Round, round.Sector, round.AnnouncedOn)
+		if err != nil {
+			return 0, fmt.Errorf("insert funding round: %w", err)
+		}
+		if roundID, err = res.LastInsertId(); err != nil {
+			return 0, fmt.Errorf("insert funding round: %w", err)
+		}
+	case err != nil:
+		return 0, fmt.Errorf("lookup funding round: %w", err)
+	default:
+		if _, err := tx.ExecContext(ctx, `
+UPDATE funding_rounds SET
+	amount_usd = GREATEST(amount_usd, ?),
+	round = IF(round = '', ?, round),
+	sector = IF(sector = '', ?, sector),
+	announced_on = LEAST(announced_on, ?)
+WHERE id = ?`, round.AmountUSD, round.Round, round.Sector, round.AnnouncedOn, roundID); err != nil {
+			return 0, fmt.Errorf("merge funding round: %w", err)
+		}
+	}
+
+	for _, inv := range []struct {
+		names []string
+		lead  bool
+	}{{round.LeadInvestors, true}, {round.Investors, false}} {
+		for _, name := range inv.names {
+			if name == "" {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, `
+INSERT INTO funding_investors (round_id, investor, lead) VALUES (?, ?, ?)
+ON DUPLICATE KEY UPDATE lead = GREATEST(lead, VALUES(lead))`, roundID, truncate(name, 255), inv.lead); err != nil {
+				return 0, fmt.Errorf("save funding investor: %w", err)
+			}
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT IGNORE INTO funding_round_items (round_id, item_id) VALUES (?, ?)", roundID, itemID); err != nil {
+		return 0, fmt.Errorf("link funding item: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("save funding round: %w", err)
+	}
+	return roundID, nil
+}
+
+func (f FundingFilter) where() (string, []any) {
+	clauses := []string{"1=1"}
+	var args []any
+	if f.Sector != "" {
+		clauses = append(clauses, "r.sector = ?")
+		args = append(args, f.Sector)
+	}
+	if f.Round != "" {
+		clauses = append(clauses, "r.round = ?")
+		args = append(args, f.Round)
+	}
+	if f.Investor != "" {
+		clauses = append(clauses, "EXISTS (SELECT 1 FROM funding_investors fi WHERE fi.round_id = r.id AND fi.investor = ?)")
+		args = append(args, f.Investor)
+	}
+	if !f.Since.IsZero() {
+		clauses = append(clauses, "r.announced_on >= ?")
+		args = append(args, f.Since)
+	}
+	if !f.Until.IsZero() {
+		clauses = append(clauses, "r.announced_on < ?")
+		args = append(args, f.Until)
+	}
+	if f.MinAmount > 0 {
+		clauses = append(clauses, "r.amount_usd >= ?")
+		args = append(args, f.MinAmount)
+	}
+	return strings.Join(clauses, " AND "), args
+}
+
+// ListFundingRounds returns rounds matching f, most recent first.
+func (s *Store) ListFundingRounds(ctx context.Context, f FundingFilter) ([]FundingRound, error) {
+	where, args := f.where()
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	args = append(args, limit)
+	rows, err := s.db.QueryContext(ctx, `
+SELECT r.id, r.project, r.amount_usd, r.round, r.sector, r.announced_on
+FROM funding_rounds r
+WHERE `+where+`
+ORDER BY r.announced_on DESC, r.id DESC
+LIMIT ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list funding rounds: %w", err)
+	}
+	rounds := []FundingRound{}
+	for rows.Next() {
+		var r FundingRound
+		if err := rows.Scan(&r.ID, &r.Project, &r.AmountUSD, &r.Round, &r.Sector, &r.AnnouncedOn); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		rounds = append(rounds, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range rounds {
+		if err := s.loadFundingDetails(ctx, &rounds[i]); err != nil {
+			return nil, err
+		}
+	}
+	return rounds, nil
+}
+
+func (s *Store) loadFundingDetails(ctx context.Context, r *FundingRound) error {
+	rows, err := s.db.QueryContext(ctx, "SELECT investor, lead FROM funding_investors WHERE round_id = ? ORDER BY lead DESC, investor", r.ID)
+	if err != nil {
+		return fmt.Errorf("load funding investors: %w", err)
+	}
+	defer rows.Close()
+	r.LeadInvestors, r.Investors = []string{}, []string{}
+	for rows.Next() {
+		var (
+			name string
+			lead bool
+		)
+		if err := rows.Scan(&name, &lead); err != nil {
+			return err
+		}
+		if lead {
+			r.LeadInvestors = append(r.LeadInvestors, name)
+		} else {
+			r.Investors = append(r.Investors, name)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	items, err := s.db.QueryContext(ctx, "SELECT item_id FROM funding_round_items WHERE round_id = ? ORDER BY item_id", r.ID)
+	if err != nil {
+		return fmt.Errorf("load funding items: %w", err)
+	}
+	defer items.Close()
+	r.ItemIDs = []int64{}
+	for items.Next() {
+		var id int64
+		if err := items.Scan(&id); err != nil {
+			return err
+		}
+		r.ItemIDs = append(r.ItemIDs, id)
+	}
+	return items.Err()
+}
+
+// AggregateFunding groups rounds matching f by "sector", "investor" or "month".
+func (s *Store) AggregateFunding(ctx context.Context, f FundingFilter, by string) ([]FundingAggregate, error) {
+	var query string
+	where, args := f.where()
+	switch by {
+	case "sector":
+		query = `SELECT IF(r.sector = '', '其他', r.sector), COUNT(*), SUM(r.amount_usd) FROM funding_rounds r WHERE ` + where + ` GROUP BY 1 ORDER BY 3 DESC`
+	case "month":
+		query = `SELECT DATE_FORMAT(r.announced_on, '%Y-%m'), COUNT(*), SUM(r.amount_usd) FROM funding_rounds r WHERE ` + where + ` GROUP BY 1 ORDER BY 1 DESC`
+	case "investor":
+		query = `SELECT i.investor, COUNT(*), SUM(r.amount_usd) FROM funding_rounds r JOIN funding_investors i ON i.round_id = r.id WHERE ` + where + ` GROUP BY 1 ORDER BY 2 DESC, 3 DESC`
+	default:
+		return nil, fmt.Errorf("unsupported aggregation %q", by)
+	}
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate funding: %w", err)
+	}
+	defer rows.Close()
+	out := []FundingAggregate{}
+	for rows.Next() {
+		var a FundingAggregate
+		if err := rows.Scan(&a.Key, &a.Rounds, &a.TotalUSD); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
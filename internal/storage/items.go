@@ -0,0 +1,327 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ItemFilter selects news_analysis rows. Zero values mean "no constraint".
+type ItemFilter struct {
+	Relevant *bool
+	Category string
+	Tag      string
+	Query    string
+	// Since and Until bound the publish time, or the crawl time of undated items.
+	Since time.Time
+	Until time.Time
+	// MinImportance keeps items scored at or above it.
+	MinImportance int
+	NeedsReview   *bool
+	// Entity keeps items mentioning the canonical entity name, optionally of EntityType.
+	Entity     string
+	EntityType string
+	StoryID    int64
+	// Watchlisted keeps items that did (or did not) match a watchlist entry;
+	// Watchlist narrows matches to one list.
+	Watchlisted *bool
+	Watchlist   string
+	Limit       int
+	Offset      int
+}
+
+// ItemFilterParams lists the query parameters ParseItemFilter reads, with a
+// description, so the export command can offer every one of them as a flag.
+var ItemFilterParams = []struct{ Name, Usage string }{
+	{"relevant", "relevance: true or false"},
+	{"category", "category"},
+	{"tag", "tag"},
+	{"q", "keyword in title, summary or reason"},
+	{"min_importance", "minimum importance (1-10)"},
+	{"needs_review", "held for review: true or false"},
+	{"entity", "canonical entity name"},
+	{"entity_type", "entity type of -entity"},
+	{"story_id", "story id"},
+	{"watchlisted", "matched a watchlist entry: true or false"},
+	{"watchlist", "name of the watchlist matched"},
+	{"since", "published at or after (YYYY-MM-DD or RFC3339)"},
+	{"until", "published before (YYYY-MM-DD or RFC3339)"},
+	{"limit", "maximum rows (default all)"},
+	{"offset", "rows to skip"},
+}
+
+// ParseItemFilter reads a filter from query parameters. The HTTP list/export
+// endpoints and the export command all go through it so they agree on semantics.
+func ParseItemFilter(values url.Values) (ItemFilter, error) {
+	var f ItemFilter
+	if v := values.Get("relevant"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, fmt.Errorf("invalid relevant=%q", v)
+		}
+		f.Relevant = &b
+	}
+	if v := values.Get("needs_review"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, fmt.Errorf("invalid needs_review=%q", v)
+		}
+		f.NeedsReview = &b
+	}
+	if v := values.Get("watchlisted"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, fmt.Errorf("invalid watchlisted=%q", v)
+		}
+		f.Watchlisted = &b
+	}
+	f.Watchlist = strings.TrimSpace(values.Get("watchlist"))
+	f.Category = strings.TrimSpace(values.Get("category"))
+	f.Tag = strings.TrimSpace(values.Get("tag"))
+	f.Query = strings.TrimSpace(values.Get("q"))
+	f.Entity = strings.TrimSpace(values.Get("entity"))
+	f.EntityType = strings.TrimSpace(values.Get("entity_type"))
+
+	var err error
+	if f.Since, err = ParseFilterTime(values.Get("since")); err != nil {
+		return f, fmt.Errorf("invalid since: %w", err)
+	}
+	if f.Until, err = ParseFilterTime(values.Get("until")); err != nil {
+		return f, fmt.Errorf("invalid until: %w", err)
+	}
+	if v := values.Get("story_id"); v != "" {
+		if f.StoryID, err = strconv.ParseInt(v, 10, 64); err != nil || f.StoryID <= 0 {
+			return f, fmt.Errorf("invalid story_id=%q", v)
+		}
+	}
+	if f.MinImportance, err = parseFilterInt(values.Get("min_importance")); err != nil {
+		return f, fmt.Errorf("invalid min_importance: %w", err)
+	}
+	if f.Limit, err = parseFilterInt(values.Get("limit")); err != nil {
+		return f, fmt.Errorf("invalid limit: %w", err)
+	}
+	if f.Offset, err = parseFilterInt(values.Get("offset")); err != nil {
+		return f, fmt.Errorf("invalid offset: %w", err)
+	}
+	return f, nil
+}
+
+// ParseFilterTime accepts YYYY-MM-DD (local time) or RFC3339; empty means no bound.
+func ParseFilterTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", v, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+func parseFilterInt(v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a non-negative integer", v)
+	}
+	return n, nil
+}
+
+func (f ItemFilter) where() (string, []any) {
+	clauses := []string{"1=1"}
+	var args []any
+	if f.Relevant != nil {
+		clauses = append(clauses, "relevant = ?")
+		args = append(args, *f.Relevant)
+	}
+	if f.Category != "" {
+		clauses = append(clauses, "category = ?")
+		args = append(args, f.Category)
+	}
+	if f.Tag != "" {
+		// Tags are stored as a JSON array of strings.
+		clauses = append(clauses, "tags LIKE ?")
+		args = append(args, "%"+likeEscape(jsonString(f.Tag))+"%")
+	}
+	if f.Query != "" {
+		clauses = append(clauses, "(title LIKE ? OR summary LIKE ? OR reason LIKE ?)")
+		pattern := "%" + likeEscape(f.Query) + "%"
+		args = append(args, pattern, pattern, pattern)
+	}
+	if f.MinImportance > 0 {
+		clauses = append(clauses, "importance >= ?")
+		args = append(args, f.MinImportance)
+	}
+	if f.Entity != "" {
+		sub := "EXISTS (SELECT 1 FROM item_entities e WHERE e.item_id = news_analysis.id AND e.name = ?"
+		args = append(args, f.Entity)
+		if f.EntityType != "" {
+			sub += " AND e.type = ?"
+			args = append(args, f.EntityType)
+		}
+		clauses = append(clauses, sub+")")
+	}
+	if f.StoryID > 0 {
+		clauses = append(clauses, "story_id = ?")
+		args = append(args, f.StoryID)
+	}
+	if f.NeedsReview != nil {
+		clauses = append(clauses, "needs_review = ?")
+		args = append(args, *f.NeedsReview)
+	}
+	if f.Watchlisted != nil || f.Watchlist != "" {
+		sub := "EXISTS (SELECT 1 FROM watchlist_matches w WHERE w.item_id = news_analysis.id"
+		if f.Watchlist != "" {
+			sub += " AND w.list = ?"
+			args = append(args, f.Watchlist)
+		}
+		sub += ")"
+		if f.Watchlisted != nil && !*f.Watchlisted {
+			sub = "NOT " + sub
+		}
+		clauses = append(clauses, sub)
+	}
+	if !f.Since.IsZero() {
+		clauses = append(clauses, "COALESCE(published_at, created_at) >= ?")
+		args = append(args, f.Since)
+	}
+	if !f.Until.IsZero() {
+		clauses = append(clauses, "COALESCE(published_at, created_at) < ?")
+		args = append(args, f.Until)
+	}
+	return strings.Join(clauses, " AND "), args
+}
+
+func likeEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// jsonString renders s the way SaveAnalysis writes it inside the tags column.
+func jsonString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+const itemColumns = "id, guid, title, link, published_at, summary, category, reason, tags, relevant, importance, confidence, needs_review, story_id, " +
+	"summary_zh, title_en, summary_en, rule_decision, rule_hits"
+
+func scanItem(row rowScanner) (StoredItem, error) {
+	var (
+		item                            StoredItem
+		link, summary, category, reason sql.NullString
+		tags                            sql.NullString
+		pub                             sql.NullTime
+		story                           sql.NullInt64
+		summaryZH, titleEN, summaryEN   sql.NullString
+		ruleDecision, ruleHits          sql.NullString
+	)
+	if err := row.Scan(&item.ID, &item.GUID, &item.Title, &link, &pub, &summary, &category, &reason, &tags, &item.Relevant,
+		&item.Importance, &item.Confidence, &item.NeedsReview, &story,
+		&summaryZH, &titleEN, &summaryEN, &ruleDecision, &ruleHits); err != nil {
+		return StoredItem{}, err
+	}
+	item.Link = link.String
+	item.PublishedAt = pub.Time
+	item.Summary = summary.String
+	item.Category = category.String
+	item.Reason = reason.String
+	item.Tags = decodeTags(tags)
+	item.StoryID = story.Int64
+	item.SummaryZH = summaryZH.String
+	item.TitleEN = titleEN.String
+	item.SummaryEN = summaryEN.String
+	item.RuleDecision = ruleDecision.String
+	if ruleHits.Valid {
+		_ = json.Unmarshal([]byte(ruleHits.String), &item.RuleHits)
+	}
+	return item, nil
+}
+
+// ListItems returns items matching f, newest first.
+func (s *Store) ListItems(ctx context.Context, f ItemFilter) ([]StoredItem, error) {
+	var items []StoredItem
+	err := s.StreamItems(ctx, f, func(item StoredItem) error {
+		items = append(items, item)
+		return nil
+	})
+	return items, err
+}
+
+// StreamItems calls fn for each item matching f, newest first, one row at a
+// time so large exports never hold the result set in memory.
+func (s *Store) StreamItems(ctx context.Context, f ItemFilter, fn func(StoredItem) error) error {
+	where, args := f.where()
+	query := "SELECT " + itemColumns + " FROM news_analysis WHERE " + where + " ORDER BY published_at DESC, id DESC"
+	if f.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, f.Limit, f.Offset)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("list items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetItem loads a single item by id.
+func (s *Store) GetItem(ctx context.Context, id int64) (StoredItem, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+itemColumns+" FROM news_analysis WHERE id = ?", id)
+	item, err := scanItem(row)
+	if err == sql.ErrNoRows {
+		return StoredItem{}, ErrNotFound
+	}
+	return item, err
+}
+
+// ClearReview removes the human review flag from an item.
+func (s *Store) ClearReview(ctx context.Context, id int64) error {
+	if _, err := s.db.ExecContext(ctx, "UPDATE news_analysis SET needs_review = 0 WHERE id = ?", id); err != nil {
+		return fmt.Errorf("clear review: %w", err)
+	}
+	return nil
+}
+
+// CountItems returns how many items match f, ignoring its limit and offset.
+func (s *Store) CountItems(ctx context.Context, f ItemFilter) (int64, error) {
+	where, args := f.where()
+	return s.count(ctx, "SELECT COUNT(*) FROM news_analysis WHERE "+where, args...)
+}
+
+// ItemsAfter returns up to limit items matching f with ids above afterID, in
+// id order, so batch jobs can resume where they stopped.
+func (s *Store) ItemsAfter(ctx context.Context, f ItemFilter, afterID int64, limit int) ([]StoredItem, error) {
+	where, args := f.where()
+	args = append(args, afterID, limit)
+	rows, err := s.db.QueryContext(ctx, "SELECT "+itemColumns+" FROM news_analysis WHERE "+where+" AND id > ? ORDER BY id LIMIT ?", args...)
+	if err != nil {
+		return nil, fmt.Errorf("items after: %w", err)
+	}
+	defer rows.Close()
+
+	var items []StoredItem
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
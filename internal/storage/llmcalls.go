@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"time"
+
+	"aiweb3news/internal/analysis"
+)
+
+const createLLMCallsTable = `
+CREATE TABLE IF NOT EXISTS llm_calls (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	item_guid VARCHAR(255) NOT NULL,
+	purpose VARCHAR(64) NOT NULL,
+	model VARCHAR(128) NOT NULL,
+	prompt_version VARCHAR(64),
+	prompt MEDIUMBLOB,
+	response MEDIUMBLOB,
+	compressed TINYINT(1) NOT NULL DEFAULT 0,
+	finish_reason VARCHAR(64),
+	prompt_tokens INT NOT NULL DEFAULT 0,
+	completion_tokens INT NOT NULL DEFAULT 0,
+	total_tokens INT NOT NULL DEFAULT 0,
+	latency_ms INT NOT NULL DEFAULT 0,
+	error TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	INDEX idx_llm_calls_guid (item_guid, id),
+	INDEX idx_llm_calls_created (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+// LLMCall is an audited model request/response pair.
+type LLMCall struct {
+	ID               int64     `json:"id"`
+	ItemGUID         string    `json:"item_guid"`
+	Purpose          string    `json:"purpose"`
+	Model            string    `json:"model"`
+	PromptVersion    string    `json:"prompt_version"`
+	Prompt           string    `json:"prompt"`
+	Response         string    `json:"response"`
+	FinishReason     string    `json:"finish_reason"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	LatencyMS        int64     `json:"latency_ms"`
+	Cost             float64   `json:"cost_usd"`
+	Error            string    `json:"error,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// SaveLLMCall persists the payload of one model call and its cost in USD.
+// With compress the prompt and response are stored gzip-compressed.
+func (s *Store) SaveLLMCall(ctx context.Context, guid string, meta analysis.Meta, callErr error, cost float64, compress bool) error {
+	prompt, response := []byte(meta.Prompt), []byte(meta.RawResponse)
+	if compress {
+		var err error
+		if prompt, err = gzipBytes(prompt); err != nil {
+			return fmt.Errorf("compress prompt: %w", err)
+		}
+		if response, err = gzipBytes(response); err != nil {
+			return fmt.Errorf("compress response: %w", err)
+		}
+	}
+	var errText sql.NullString
+	if callErr != nil {
+		errText = sql.NullString{String: callErr.Error(), Valid: true}
+	}
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO llm_calls (item_guid, purpose, model, prompt_version, prompt, response, compressed, finish_reason,
+	prompt_tokens, completion_tokens, total_tokens, latency_ms, cost, error)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		guid, meta.Purpose, meta.Model, meta.PromptVersion, prompt, response, compress, meta.FinishReason,
+		meta.PromptTokens, meta.CompletionTokens, meta.TotalTokens, meta.Latency.Milliseconds(), cost, errText)
+	if err != nil {
+		return fmt.Errorf("save llm call: %w", err)
+	}
+	return nil
+}
+
+// ListLLMCalls returns the audited calls made for an item, newest first.
+func (s *Store) ListLLMCalls(ctx context.Context, guid string) ([]LLMCall, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, item_guid, purpose, model, prompt_version, prompt, response, compressed, finish_reason,
+	prompt_tokens, completion_tokens, total_tokens, latency_ms, cost, error, created_at
+FROM llm_calls
+WHERE item_guid = ?
+ORDER BY id DESC`, guid)
+	if err != nil {
+		return nil, fmt.Errorf("list llm calls: %w", err)
+	}
+	defer rows.Close()
+
+	var calls []LLMCall
+	for rows.Next() {
+		var (
+			call                                LLMCall
+			prompt, response                    []byte
+			compressed                          bool
+			promptVersion, finishReason, errMsg sql.NullString
+		)
+		if err := rows.Scan(&call.ID, &call.ItemGUID, &call.Purpose, &call.Model, &promptVersion, &prompt, &response, &compressed, &finishReason,
+			&call.PromptTokens, &call.CompletionTokens, &call.TotalTokens, &call.LatencyMS, &call.Cost, &errMsg, &call.CreatedAt); err != nil {
+			return nil, err
+		}
+		if compressed {
+			if prompt, err = gunzipBytes(prompt); err != nil {
+				return nil, fmt.Errorf("decompress llm call %d: %w", call.ID, err)
+			}
+			if response, err = gunzipBytes(response); err != nil {
+				return nil, fmt.Errorf("decompress llm call %d: %w", call.ID, err)
+			}
+		}
+		call.Prompt = string(prompt)
+		call.Response = string(response)
+		call.PromptVersion = promptVersion.String
+		call.FinishReason = finishReason.String
+		call.Error = errMsg.String
+		calls = append(calls, call)
+	}
+	return calls, rows.Err()
+}
+
+// PurgeLLMCalls deletes audited calls older than before. With dryRun it only counts them.
+func (s *Store) PurgeLLMCalls(ctx context.Context, before time.Time, dryRun bool) (int64, error) {
+	if dryRun {
+		return s.count(ctx, "SELECT COUNT(*) FROM llm_calls WHERE created_at < ?", before)
+	}
+	res, err := s.db.ExecContext(ctx, "DELETE FROM llm_calls WHERE created_at < ?", before)
+	if err != nil {
+		return 0, fmt.Errorf("purge llm calls: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func gzipBytes(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gunzipBytes(b []byte) ([]byte, error) {
+	if len(b) == 0 {
+		return b, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
@@ -10,6 +10,7 @@ import (
 
 	"aiweb3news/internal/analysis"
 	"aiweb3news/internal/config"
+	"aiweb3news/internal/prefilter"
 	"aiweb3news/internal/rss"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -23,14 +24,29 @@ type Store struct {
 
 // StoredItem represents a row from the database.
 type StoredItem struct {
+	ID          int64
 	GUID        string
 	Title       string
 	Link        string
 	PublishedAt time.Time
+	Summary     string
 	Category    string
 	Reason      string
 	Tags        []string
 	Relevant    bool
+	Importance  int
+	Confidence  float64
+	NeedsReview bool
+	// StoryID is the story cluster the item belongs to, 0 when unclustered.
+	StoryID int64
+	// Generated summaries, empty until the summary stage ran.
+	SummaryZH string
+	TitleEN   string
+	SummaryEN string
+	// RuleDecision and RuleHits are the prefilter verdict, empty when the
+	// prefilter was off.
+	RuleDecision string
+	RuleHits     []prefilter.Hit
 }
 
 // NewMySQLStore creates the database (if needed), ensures schema, and returns a ready store.
@@ -88,9 +104,98 @@ CREATE TABLE IF NOT EXISTS news_analysis (
 	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 `
-	_, err := s.db.ExecContext(ctx, createTable)
-	if err != nil {
-		return fmt.Errorf("ensure schema: %w", err)
+	for _, stmt := range []string{createTable, createAnalysesTable, createLLMCallsTable, createItemEntitiesTable,
+		createFundingTables, createFundingInvestorsTable, createFundingRoundItemsTable,
+		createRegulatoryEventsTable, createSecurityIncidentsTable, createSecurityIncidentItemsTable,
+		createStoriesTable, createItemEmbeddingsTable, createTrendAlertsTable,
+		createItemFeedbackTable, createReanalysisJobsTable, createReanalysisFlipsTable,
+		createEnsembleVotesTable, createLLMUsageTable, createAnalysisCacheTable, createDeferredItemsTable,
+		createWatchlistEntriesTable, createWatchlistMatchesTable, createWatchlistAlertsTable,
+	} {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("ensure schema: %w", err)
+		}
+	}
+
+	columns := []struct{ table, column, definition string }{
+		{"news_analysis", "current_analysis_id", "BIGINT NULL"},
+		{"news_analysis", "archived_at", "DATETIME NULL"},
+		{"news_analysis", "importance", "TINYINT NOT NULL DEFAULT 0"},
+		{"news_analysis", "confidence", "DECIMAL(4,3) NOT NULL DEFAULT 0"},
+		{"news_analysis", "needs_review", "TINYINT(1) NOT NULL DEFAULT 0"},
+		{"news_analysis", "story_id", "BIGINT NULL"},
+		{"news_analysis", "simhash", "BIGINT UNSIGNED NULL"},
+		{"news_analysis", "summary_zh", "TEXT NULL"},
+		{"news_analysis", "title_en", "VARCHAR(512) NULL"},
+		{"news_analysis", "summary_en", "TEXT NULL"},
+		{"news_analysis", "summary_hash", "CHAR(64) NULL"},
+		{"news_analysis", "rule_decision", "VARCHAR(8) NULL"},
+		{"news_analysis", "rule_score", "DECIMAL(8,2) NULL"},
+		{"news_analysis", "rule_hits", "TEXT NULL"},
+		{"news_analysis", "rule_enforced", "TINYINT(1) NOT NULL DEFAULT 0"},
+		{"analyses", "importance", "TINYINT NOT NULL DEFAULT 0"},
+		{"analyses", "confidence", "DECIMAL(4,3) NOT NULL DEFAULT 0"},
+		{"analyses", "few_shot_ids", "TEXT NULL"},
+		{"llm_calls", "cost", "DECIMAL(12,6) NOT NULL DEFAULT 0"},
+		{"reanalysis_jobs", "bypass_cache", "TINYINT(1) NOT NULL DEFAULT 0"},
+		{"reanalysis_jobs", "owner", "VARCHAR(128) NOT NULL DEFAULT ''"},
+		{"reanalysis_jobs", "heartbeat_at", "DATETIME NULL"},
+		{"watchlist_alerts", "delivered", "TEXT NULL"},
+		{"watchlist_alerts", "failed_at", "DATETIME NULL"},
+	}
+	for _, c := range columns {
+		if err := s.ensureColumn(ctx, c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+
+	indexes := []struct{ table, name, columns string }{
+		{"news_analysis", "idx_news_published", "published_at"},
+		{"news_analysis", "idx_news_importance", "importance, published_at"},
+		{"news_analysis", "idx_news_review", "needs_review"},
+		{"news_analysis", "idx_news_story", "story_id"},
+	}
+	for _, idx := range indexes {
+		if err := s.ensureIndex(ctx, idx.table, idx.name, idx.columns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureIndex creates a secondary index when it is missing.
+func (s *Store) ensureIndex(ctx context.Context, table, name, columns string) error {
+	row := s.db.QueryRowContext(ctx, `
+SELECT COUNT(*) FROM information_schema.STATISTICS
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND INDEX_NAME = ?`, table, name)
+	var n int
+	if err := row.Scan(&n); err != nil {
+		return fmt.Errorf("inspect index %s.%s: %w", table, name, err)
+	}
+	if n > 0 {
+		return nil
+	}
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf("CREATE INDEX `%s` ON `%s` (%s)", name, table, columns)); err != nil {
+		return fmt.Errorf("create index %s.%s: %w", table, name, err)
+	}
+	return nil
+}
+
+// ensureColumn adds a column to an existing table when it is missing, so
+// databases created by older versions pick up new fields.
+func (s *Store) ensureColumn(ctx context.Context, table, column, definition string) error {
+	row := s.db.QueryRowContext(ctx, `
+SELECT COUNT(*) FROM information_schema.COLUMNS
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?`, table, column)
+	var n int
+	if err := row.Scan(&n); err != nil {
+		return fmt.Errorf("inspect column %s.%s: %w", table, column, err)
+	}
+	if n > 0 {
+		return nil
+	}
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN `%s` %s", table, column, definition)); err != nil {
+		return fmt.Errorf("add column %s.%s: %w", table, column, err)
 	}
 	return nil
 }
@@ -109,12 +214,32 @@ func (s *Store) Exists(ctx context.Context, guid string) (bool, error) {
 	return true, nil
 }
 
-// SaveAnalysis stores or updates an analyzed item.
-func (s *Store) SaveAnalysis(ctx context.Context, item rss.Item, result analysis.Result) error {
+// ItemID returns the id of the item stored under guid, or 0 when there is none.
+func (s *Store) ItemID(ctx context.Context, guid string) (int64, error) {
+	var id int64
+	err := s.db.QueryRowContext(ctx, "SELECT id FROM news_analysis WHERE guid = ? LIMIT 1", guid).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// SaveAnalysis stores or updates an analyzed item. Every call appends a row to
+// the analyses history and points the item at it as the current verdict.
+// needsReview flags the item for a human decision before it is pushed. It
+// returns the item id.
+func (s *Store) SaveAnalysis(ctx context.Context, item rss.Item, result analysis.Result, needsReview bool) (int64, error) {
 	tagsJSON, _ := json.Marshal(result.Tags)
-	_, err := s.db.ExecContext(ctx, `
-INSERT INTO news_analysis (guid, title, link, published_at, summary, relevant, category, reason, tags)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("save analysis: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+INSERT INTO news_analysis (guid, title, link, published_at, summary, relevant, category, reason, tags,
+	importance, confidence, needs_review)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON DUPLICATE KEY UPDATE
 	title=VALUES(title),
 	link=VALUES(link),
@@ -124,52 +249,42 @@ ON DUPLICATE KEY UPDATE
 	category=VALUES(category),
 	reason=VALUES(reason),
 	tags=VALUES(tags),
+	importance=VALUES(importance),
+	confidence=VALUES(confidence),
+	needs_review=VALUES(needs_review),
 	updated_at=CURRENT_TIMESTAMP
-`, item.GUID, item.Title, item.Link, item.PublishedAt, item.Description, result.Relevant, result.Category, result.Reason, string(tagsJSON))
+`, item.GUID, item.Title, item.Link, sql.NullTime{Time: item.PublishedAt, Valid: !item.PublishedAt.IsZero()}, item.Description, result.Relevant, result.Category, result.Reason, string(tagsJSON),
+		result.Importance, result.Confidence, needsReview)
 	if err != nil {
-		return fmt.Errorf("save analysis: %w", err)
+		return 0, fmt.Errorf("save analysis: %w", err)
 	}
-	return nil
+
+	var itemID int64
+	if err := tx.QueryRowContext(ctx, "SELECT id FROM news_analysis WHERE guid = ?", item.GUID).Scan(&itemID); err != nil {
+		return 0, fmt.Errorf("save analysis: lookup item id: %w", err)
+	}
+	analysisID, err := insertAnalysis(ctx, tx, itemID, result)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE news_analysis SET current_analysis_id = ? WHERE id = ?", analysisID, itemID); err != nil {
+		return 0, fmt.Errorf("save analysis: set current: %w", err)
+	}
+	if err := replaceEntities(ctx, tx, itemID, result.Entities); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("save analysis: %w", err)
+	}
+	return itemID, nil
 }
 
 // ListRelevant returns the most recent relevant items.
 func (s *Store) ListRelevant(ctx context.Context, limit int) ([]StoredItem, error) {
-	rows, err := s.db.QueryContext(ctx, `
-SELECT guid, title, link, published_at, category, reason, tags, relevant
-FROM news_analysis
-WHERE relevant = 1
-ORDER BY published_at DESC, id DESC
-LIMIT ?`, limit)
+	relevant := true
+	items, err := s.ListItems(ctx, ItemFilter{Relevant: &relevant, Limit: limit})
 	if err != nil {
 		return nil, fmt.Errorf("list relevant: %w", err)
 	}
-	defer rows.Close()
-
-	var items []StoredItem
-	for rows.Next() {
-		var (
-			item   StoredItem
-			tags   sql.NullString
-			pub    sql.NullTime
-			relInt int
-		)
-		if err := rows.Scan(&item.GUID, &item.Title, &item.Link, &pub, &item.Category, &item.Reason, &tags, &relInt); err != nil {
-			return nil, err
-		}
-		if pub.Valid {
-			item.PublishedAt = pub.Time
-		}
-		item.Relevant = relInt == 1
-		if tags.Valid && tags.String != "" {
-			var parsed []string
-			if err := json.Unmarshal([]byte(tags.String), &parsed); err == nil {
-				item.Tags = parsed
-			}
-		}
-		items = append(items, item)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
 	return items, nil
 }
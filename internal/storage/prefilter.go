@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"aiweb3news/internal/prefilter"
+)
+
+// maxPrefilterExamples caps the disagreements listed in a prefilter report.
+const maxPrefilterExamples = 50
+
+// SaveRuleVerdict stores the prefilter outcome of an item. enforced marks
+// items the rules decided without a model call.
+func (s *Store) SaveRuleVerdict(ctx context.Context, itemID int64, v prefilter.Verdict, enforced bool) error {
+	hits, _ := json.Marshal(v.Hits)
+	_, err := s.db.ExecContext(ctx, "UPDATE news_analysis SET rule_decision = ?, rule_score = ?, rule_hits = ?, rule_enforced = ? WHERE id = ?",
+		v.Decision, v.Score, string(hits), enforced, itemID)
+	if err != nil {
+		return fmt.Errorf("save rule verdict: %w", err)
+	}
+	return nil
+}
+
+// RuleStat counts how the model judged the items a rule matched.
+type RuleStat struct {
+	Rule          string `json:"rule"`
+	Hits          int    `json:"hits"`
+	LLMRelevant   int    `json:"llm_relevant"`
+	LLMIrrelevant int    `json:"llm_irrelevant"`
+}
+
+// DecisionStat counts how the model judged the items of one rule decision.
+type DecisionStat struct {
+	Items       int `json:"items"`
+	LLMRelevant int `json:"llm_relevant"`
+}
+
+// RuleDisagreement is an item the rules would have decided differently from the model.
+type RuleDisagreement struct {
+	ItemID   int64           `json:"item_id"`
+	Title    string          `json:"title"`
+	Decision string          `json:"decision"`
+	Score    float64         `json:"score"`
+	Relevant bool            `json:"llm_relevant"`
+	Hits     []prefilter.Hit `json:"hits"`
+}
+
+// PrefilterReport compares rule decisions with model judgements on items the
+// model classified, i.e. items the rules only shadowed or passed on.
+type PrefilterReport struct {
+	Since            time.Time               `json:"since"`
+	Items            int                     `json:"items"`
+	Decisions        map[string]DecisionStat `json:"decisions"`
+	Disagreements    int                     `json:"disagreements"`
+	DisagreementRate float64                 `json:"disagreement_rate"`
+	Rules            []RuleStat              `json:"rules"`
+	Examples         []RuleDisagreement      `json:"examples"`
+}
+
+// PrefilterReport aggregates the rule verdicts of items published since.
+// A disagreement is a rejected item the model found relevant or an accepted
+// item it did not.
+func (s *Store) PrefilterReport(ctx context.Context, since time.Time) (PrefilterReport, error) {
+	report := PrefilterReport{Since: since, Decisions: map[string]DecisionStat{}, Rules: []RuleStat{}, Examples: []RuleDisagreement{}}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, title, relevant, rule_decision, rule_score, rule_hits
+FROM news_analysis
+WHERE rule_decision IS NOT NULL AND rule_enforced = 0 AND COALESCE(published_at, created_at) >= ?
+ORDER BY id DESC`, since)
+	if err != nil {
+		return report, fmt.Errorf("prefilter report: %w", err)
+	}
+	defer rows.Close()
+
+	rules := map[string]*RuleStat{}
+	for rows.Next() {
+		var (
+			d    RuleDisagreement
+			hits sql.NullString
+		)
+		if err := rows.Scan(&d.ItemID, &d.Title, &d.Relevant, &d.Decision, &d.Score, &hits); err != nil {
+			return report, err
+		}
+		if hits.Valid {
+			_ = json.Unmarshal([]byte(hits.String), &d.Hits)
+		}
+
+		report.Items++
+		stat := report.Decisions[d.Decision]
+		stat.Items++
+		if d.Relevant {
+			stat.LLMRelevant++
+		}
+		report.Decisions[d.Decision] = stat
+		for _, h := range d.Hits {
+			rs, ok := rules[h.Rule]
+			if !ok {
+				rs = &RuleStat{Rule: h.Rule}
+				rules[h.Rule] = rs
+			}
+			rs.Hits++
+			if d.Relevant {
+				rs.LLMRelevant++
+			} else {
+				rs.LLMIrrelevant++
+			}
+		}
+		if (d.Decision == prefilter.Reject && d.Relevant) || (d.Decision == prefilter.Accept && !d.Relevant) {
+			report.Disagreements++
+			if len(report.Examples) < maxPrefilterExamples {
+				report.Examples = append(report.Examples, d)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	decided := report.Decisions[prefilter.Reject].Items + report.Decisions[prefilter.Accept].Items
+	if decided > 0 {
+		report.DisagreementRate = float64(report.Disagreements) / float64(decided)
+	}
+	for _, rs := range rules {
+		report.Rules = append(report.Rules, *rs)
+	}
+	sort.Slice(report.Rules, func(i, j int) bool {
+		if report.Rules[i].Hits != report.Rules[j].Hits {
+			return report.Rules[i].Hits > report.Rules[j].Hits
+		}
+		return report.Rules[i].Rule < report.Rules[j].Rule
+	})
+	return report, nil
+}
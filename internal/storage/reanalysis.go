@@ -0,0 +1,270 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const createReanalysisJobsTable = `
+CREATE TABLE IF NOT EXISTS reanalysis_jobs (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	filter TEXT NOT NULL,
+	model VARCHAR(128) NOT NULL,
+	prompt_version VARCHAR(64) NOT NULL,
+	notify TINYINT(1) NOT NULL DEFAULT 0,
+	rate_per_minute INT NOT NULL DEFAULT 0,
+	status VARCHAR(16) NOT NULL,
+	total INT NOT NULL DEFAULT 0,
+	processed INT NOT NULL DEFAULT 0,
+	failed INT NOT NULL DEFAULT 0,
+	flipped INT NOT NULL DEFAULT 0,
+	last_item_id BIGINT NOT NULL DEFAULT 0,
+	error TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	finished_at DATETIME NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+const createReanalysisFlipsTable = `
+CREATE TABLE IF NOT EXISTS reanalysis_flips (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	job_id BIGINT NOT NULL,
+	item_id BIGINT NOT NULL,
+	title VARCHAR(512) NOT NULL,
+	was_relevant TINYINT(1) NOT NULL,
+	now_relevant TINYINT(1) NOT NULL,
+	was_category VARCHAR(255) NOT NULL DEFAULT '',
+	now_category VARCHAR(255) NOT NULL DEFAULT '',
+	reason TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	INDEX idx_reanalysis_flips_job (job_id, id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+// Re-analysis job states.
+const (
+	JobRunning = "running"
+	JobPaused  = "paused"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// ReanalysisJob re-evaluates the stored items matching Filter with a chosen
+// model and prompt version. Items are processed in id order; LastItemID is
+// the resume cursor.
+type ReanalysisJob struct {
+	ID int64 `json:"id"`
+	// Filter holds the item filter as GET /api/v1/items query parameters.
+	Filter        string     `json:"filter"`
+	Model         string     `json:"model"`
+	PromptVersion string     `json:"prompt_version"`
+	Notify        bool       `json:"notify"`
+	RatePerMinute int        `json:"rate_per_minute"`
+	BypassCache   bool       `json:"bypass_cache"`
+	Status        string     `json:"status"`
+	Total         int        `json:"total"`
+	Processed     int        `json:"processed"`
+	Failed        int        `json:"failed"`
+	Flipped       int        `json:"flipped"`
+	LastItemID    int64      `json:"last_item_id"`
+	Error         string     `json:"error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+}
+
+// ReanalysisFlip is an item whose relevance changed during a job.
+type ReanalysisFlip struct {
+	ItemID      int64  `json:"item_id"`
+	Title       string `json:"title"`
+	WasRelevant bool   `json:"was_relevant"`
+	NowRelevant bool   `json:"now_relevant"`
+	WasCategory string `json:"was_category"`
+	NowCategory string `json:"now_category"`
+	Reason      string `json:"reason"`
+}
+
+const reanalysisJobColumns = `id, filter, model, prompt_version, notify, rate_per_minute, bypass_cache, status, total, processed, failed, flipped,
+	last_item_id, error, created_at, updated_at, finished_at`
+
+// CreateReanalysisJob stores a new running job and returns its id.
+func (s *Store) CreateReanalysisJob(ctx context.Context, job ReanalysisJob) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `
+INSERT INTO reanalysis_jobs (filter, model, prompt_version, notify, rate_per_minute, bypass_cache, status, total)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, job.Filter, job.Model, job.PromptVersion, job.Notify, job.RatePerMinute, job.BypassCache, JobRunning, job.Total)
+	if err != nil {
+		return 0, fmt.Errorf("create reanalysis job: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// GetReanalysisJob loads a job.
+func (s *Store) GetReanalysisJob(ctx context.Context, id int64) (ReanalysisJob, error) {
+	job, err := scanReanalysisJob(s.db.QueryRowContext(ctx, "SELECT "+reanalysisJobColumns+" FROM reanalysis_jobs WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return ReanalysisJob{}, ErrNotFound
+	}
+	return job, err
+}
+
+// ListReanalysisJobs returns the most recent jobs first.
+func (s *Store) ListReanalysisJobs(ctx context.Context, limit int) ([]ReanalysisJob, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+reanalysisJobColumns+" FROM reanalysis_jobs ORDER BY id DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, fmt.Errorf("list reanalysis jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []ReanalysisJob
+	for rows.Next() {
+		job, err := scanReanalysisJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+func scanReanalysisJob(row rowScanner) (ReanalysisJob, error) {
+	var (
+		job      ReanalysisJob
+		errMsg   sql.NullString
+		finished sql.NullTime
+	)
+	if err := row.Scan(&job.ID, &job.Filter, &job.Model, &job.PromptVersion, &job.Notify, &job.RatePerMinute, &job.BypassCache, &job.Status,
+		&job.Total, &job.Processed, &job.Failed, &job.Flipped, &job.LastItemID, &errMsg, &job.CreatedAt, &job.UpdatedAt, &finished); err != nil {
+		return ReanalysisJob{}, err
+	}
+	job.Error = errMsg.String
+	if finished.Valid {
+		job.FinishedAt = &finished.Time
+	}
+	return job, nil
+}
+
+// AdvanceReanalysisJob moves the cursor past itemID and counts its outcome.
+// It reports false when owner no longer holds the job.
+func (s *Store) AdvanceReanalysisJob(ctx context.Context, id int64, owner string, itemID int64, failed, flipped bool) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+UPDATE reanalysis_jobs
+SET last_item_id = ?, processed = processed + 1, failed = failed + ?, flipped = flipped + ?, heartbeat_at = NOW()
+WHERE id = ? AND owner = ?`, itemID, boolInt(failed), boolInt(flipped), id, owner)
+	if err != nil {
+		return false, fmt.Errorf("advance reanalysis job: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ClaimReanalysisJob makes owner the single runner of a running job. It
+// fails while another runner holds the job and its heartbeat is younger
+// than stale, whichever process that runner lives in.
+func (s *Store) ClaimReanalysisJob(ctx context.Context, id int64, owner string, stale time.Duration) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+UPDATE reanalysis_jobs SET owner = ?, heartbeat_at = NOW()
+WHERE id = ? AND status = ? AND (owner = '' OR heartbeat_at IS NULL OR heartbeat_at < NOW() - INTERVAL ? SECOND)`,
+		owner, id, JobRunning, int(stale.Seconds()))
+	if err != nil {
+		return false, fmt.Errorf("claim reanalysis job: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// HeartbeatReanalysisJob tells other processes owner is still running the job.
+func (s *Store) HeartbeatReanalysisJob(ctx context.Context, id int64, owner string) error {
+	if _, err := s.db.ExecContext(ctx, "UPDATE reanalysis_jobs SET heartbeat_at = NOW() WHERE id = ? AND owner = ?", id, owner); err != nil {
+		return fmt.Errorf("heartbeat reanalysis job: %w", err)
+	}
+	return nil
+}
+
+// ReleaseReanalysisJob gives up owner's claim on a job.
+func (s *Store) ReleaseReanalysisJob(ctx context.Context, id int64, owner string) error {
+	if _, err := s.db.ExecContext(ctx, "UPDATE reanalysis_jobs SET owner = '' WHERE id = ? AND owner = ?", id, owner); err != nil {
+		return fmt.Errorf("release reanalysis job: %w", err)
+	}
+	return nil
+}
+
+// PauseAbandonedReanalysisJobs pauses running jobs nobody has worked on for
+// stale, e.g. after a crash, so they can be resumed. It returns their number.
+func (s *Store) PauseAbandonedReanalysisJobs(ctx context.Context, stale time.Duration) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `
+UPDATE reanalysis_jobs SET status = ?, error = 'interrupted, resume to continue', owner = ''
+WHERE status = ? AND (owner = '' OR heartbeat_at IS NULL OR heartbeat_at < NOW() - INTERVAL ? SECOND)`,
+		JobPaused, JobRunning, int(stale.Seconds()))
+	if err != nil {
+		return 0, fmt.Errorf("pause abandoned reanalysis jobs: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// TransitionReanalysisJob sets the status of a job currently in one of from
+// and reports whether it did. Done and failed jobs get their finish time.
+func (s *Store) TransitionReanalysisJob(ctx context.Context, id int64, to, errMsg string, from ...string) (bool, error) {
+	query := "UPDATE reanalysis_jobs SET status = ?, error = ?, finished_at = IF(? IN ('done', 'failed'), NOW(), NULL) WHERE id = ?"
+	args := []any{to, errMsg, to, id}
+	if len(from) > 0 {
+		query += " AND status IN (?" + strings.Repeat(", ?", len(from)-1) + ")"
+		for _, f := range from {
+			args = append(args, f)
+		}
+	}
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("update reanalysis job: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// SaveReanalysisFlip records an item whose relevance changed.
+func (s *Store) SaveReanalysisFlip(ctx context.Context, jobID int64, f ReanalysisFlip) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO reanalysis_flips (job_id, item_id, title, was_relevant, now_relevant, was_category, now_category, reason)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, jobID, f.ItemID, truncate(f.Title, 512), f.WasRelevant, f.NowRelevant, truncate(f.WasCategory, 255), truncate(f.NowCategory, 255), f.Reason)
+	if err != nil {
+		return fmt.Errorf("save reanalysis flip: %w", err)
+	}
+	return nil
+}
+
+// ListReanalysisFlips returns the relevance flips of a job in processing order.
+func (s *Store) ListReanalysisFlips(ctx context.Context, jobID int64) ([]ReanalysisFlip, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT item_id, title, was_relevant, now_relevant, was_category, now_category, reason
+FROM reanalysis_flips
+WHERE job_id = ?
+ORDER BY id`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("list reanalysis flips: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ReanalysisFlip
+	for rows.Next() {
+		var (
+			f      ReanalysisFlip
+			reason sql.NullString
+		)
+		if err := rows.Scan(&f.ItemID, &f.Title, &f.WasRelevant, &f.NowRelevant, &f.WasCategory, &f.NowCategory, &reason); err != nil {
+			return nil, err
+		}
+		f.Reason = reason.String
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const createRegulatoryEventsTable = `
+CREATE TABLE IF NOT EXISTS regulatory_events (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	item_id BIGINT NOT NULL,
+	jurisdiction VARCHAR(16) NOT NULL,
+	regulator VARCHAR(255) NOT NULL DEFAULT '',
+	instrument VARCHAR(32) NOT NULL DEFAULT '',
+	stage VARCHAR(32) NOT NULL DEFAULT '',
+	effective_date DATE NULL,
+	summary TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	UNIQUE KEY uniq_regulatory_item (item_id),
+	INDEX idx_regulatory_jurisdiction (jurisdiction)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+// RegulatoryEvent is a regulatory action attached to the item reporting it.
+type RegulatoryEvent struct {
+	ItemID        int64      `json:"item_id"`
+	Jurisdiction  string     `json:"jurisdiction"`
+	Regulator     string     `json:"regulator"`
+	Instrument    string     `json:"instrument"`
+	Stage         string     `json:"stage"`
+	EffectiveDate *time.Time `json:"effective_date,omitempty"`
+	Summary       string     `json:"summary"`
+	Title         string     `json:"title"`
+	Link          string     `json:"link"`
+	PublishedAt   time.Time  `json:"published_at"`
+	Importance    int        `json:"importance"`
+}
+
+// RegulatoryFilter narrows the regulatory timeline.
+type RegulatoryFilter struct {
+	Jurisdiction string
+	Regulator    string
+	Instrument   string
+	Stage        string
+	Since        time.Time
+	Until        time.Time
+	Limit        int
+}
+
+// JurisdictionCount is the number of regulatory events in a jurisdiction.
+type JurisdictionCount struct {
+	Jurisdiction string    `json:"jurisdiction"`
+	Count        int       `json:"count"`
+	Latest       time.Time `json:"latest"`
+}
+
+// SaveRegulatoryEvent stores the regulatory action of an item, replacing the
+// one extracted by an earlier analysis.
+func (s *Store) SaveRegulatoryEvent(ctx context.Context, ev RegulatoryEvent) error {
+	var effective sql.NullTime
+	if ev.EffectiveDate != nil {
+		effective = sql.NullTime{Time: *ev.EffectiveDate, Valid: true}
+	}
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO regulatory_events (item_id, jurisdiction, regulator, instrument, stage, effective_date, summary)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE
+	jurisdiction=VALUES(jurisdiction),
+	regulator=VALUES(regulator),
+	instrument=VALUES(instrument),
+	stage=VALUES(stage),
+	effective_date=VALUES(effective_date),
+	summary=VALUES(summary)`,
+		ev.ItemID, truncate(ev.Jurisdiction, 16), truncate(ev.Regulator, 255), ev.Instrument, ev.Stage, effective, ev.Summary)
+	if err != nil {
+		return fmt.Errorf("save regulatory event: %w", err)
+	}
+	return nil
+}
+
+// ListRegulatoryEvents returns the regulatory timeline matching f, newest first.
+func (s *Store) ListRegulatoryEvents(ctx context.Context, f RegulatoryFilter) ([]RegulatoryEvent, error) {
+	clauses := []string{"1=1"}
+	var args []any
+	if f.Jurisdiction != "" {
+		clauses = append(clauses, "e.jurisdiction = ?")
+		args = append(args, f.Jurisdiction)
+	}
+	if f.Regulator != "" {
+		clauses = append(clauses, "e.regulator LIKE ?")
+		args = append(args, "%"+likeEscape(f.Regulator)+"%")
+	}
+	if f.Instrument != "" {
+		clauses = append(clauses, "e.instrument = ?")
+		args = append(args, f.Instrument)
+	}
+	if f.Stage != "" {
+		clauses = append(clauses, "e.stage = ?")
+		args = append(args, f.Stage)
+	}
+	if !f.Since.IsZero() {
+		clauses = append(clauses, "COALESCE(n.published_at, n.created_at) >= ?")
+		args = append(args, f.Since)
+	}
+	if !f.Until.IsZero() {
+		clauses = append(clauses, "COALESCE(n.published_at, n.created_at) < ?")
+		args = append(args, f.Until)
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, `
+SELECT e.item_id, e.jurisdiction, e.regulator, e.instrument, e.stage, e.effective_date, e.summary,
+	n.title, n.link, n.published_at, n.importance
+FROM regulatory_events e
+JOIN news_analysis n ON n.id = e.item_id
+WHERE `+strings.Join(clauses, " AND ")+`
+ORDER BY n.published_at DESC, e.item_id DESC
+LIMIT ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list regulatory events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []RegulatoryEvent{}
+	for rows.Next() {
+		var (
+			ev             RegulatoryEvent
+			effective, pub sql.NullTime
+			summary, link  sql.NullString
+		)
+		if err := rows.Scan(&ev.ItemID, &ev.Jurisdiction, &ev.Regulator, &ev.Instrument, &ev.Stage, &effective, &summary,
+			&ev.Title, &link, &pub, &ev.Importance); err != nil {
+			return nil, err
+		}
+		if effective.Valid {
+			ev.EffectiveDate = &effective.Time
+		}
+		ev.Summary = summary.String
+		ev.Link = link.String
+		ev.PublishedAt = pub.Time
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+// ListJurisdictions counts regulatory events per jurisdiction.
+func (s *Store) ListJurisdictions(ctx context.Context) ([]JurisdictionCount, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT e.jurisdiction, COUNT(*), MAX(n.published_at)
+FROM regulatory_events e
+JOIN news_analysis n ON n.id = e.item_id
+GROUP BY e.jurisdiction
+ORDER BY COUNT(*) DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("list jurisdictions: %w", err)
+	}
+	defer rows.Close()
+
+	out := []JurisdictionCount{}
+	for rows.Next() {
+		var (
+			c      JurisdictionCount
+			latest sql.NullTime
+		)
+		if err := rows.Scan(&c.Jurisdiction, &c.Count, &latest); err != nil {
+			return nil, err
+		}
+		c.Latest = latest.Time
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,276 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// itemChildTables hold rows keyed by item_id that are deleted with their item.
+// item_feedback is not among them: labeled items are never deleted.
+var itemChildTables = []string{"analyses", "item_entities", "funding_round_items", "regulatory_events", "security_incident_items", "item_embeddings", "reanalysis_flips", "ensemble_votes", "watchlist_matches"}
+
+// itemGUIDTables hold rows keyed by an item's guid, in the named column,
+// that are deleted with their item.
+var itemGUIDTables = []struct{ table, column string }{
+	{"watchlist_alerts", "guid"},
+	{"llm_calls", "item_guid"},
+}
+
+// itemParents are the tables that group items through a link table among
+// itemChildTables; a parent left without items is deleted with the last one,
+// together with its rows in details, which are keyed by the same column.
+var itemParents = []struct {
+	table, links, column string
+	details              []string
+}{
+	{"funding_rounds", "funding_round_items", "round_id", []string{"funding_investors"}},
+	{"security_incidents", "security_incident_items", "incident_id", nil},
+}
+
+// retentionBatch bounds how many items are archived per query so large
+// backlogs do not hold a long-running cursor open.
+const retentionBatch = 500
+
+// ArchivedItem is the archival form of a news_analysis row and its history.
+type ArchivedItem struct {
+	ID          int64            `json:"id"`
+	GUID        string           `json:"guid"`
+	Title       string           `json:"title"`
+	Link        string           `json:"link"`
+	PublishedAt time.Time        `json:"published_at"`
+	Summary     string           `json:"summary"`
+	Relevant    bool             `json:"relevant"`
+	Category    string           `json:"category"`
+	Reason      string           `json:"reason"`
+	Tags        []string         `json:"tags"`
+	CreatedAt   time.Time        `json:"created_at"`
+	Analyses    []AnalysisRecord `json:"analyses"`
+}
+
+// StripIrrelevantSummaries clears the stored body of irrelevant items older than before.
+// With dryRun it only counts the affected rows.
+func (s *Store) StripIrrelevantSummaries(ctx context.Context, before time.Time, dryRun bool) (int64, error) {
+	const where = "relevant = 0 AND summary IS NOT NULL AND summary <> '' AND COALESCE(published_at, created_at) < ?"
+	if dryRun {
+		return s.count(ctx, "SELECT COUNT(*) FROM news_analysis WHERE "+where, before)
+	}
+	res, err := s.db.ExecContext(ctx, "UPDATE news_analysis SET summary = NULL WHERE "+where, before)
+	if err != nil {
+		return 0, fmt.Errorf("strip irrelevant summaries: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// ArchiveItems passes every not yet archived item older than before to write
+// and marks it archived once write succeeds. With dryRun it only counts them.
+func (s *Store) ArchiveItems(ctx context.Context, before time.Time, dryRun bool, write func(ArchivedItem) error) (int64, error) {
+	const where = "archived_at IS NULL AND COALESCE(published_at, created_at) < ?"
+	if dryRun {
+		return s.count(ctx, "SELECT COUNT(*) FROM news_analysis WHERE "+where, before)
+	}
+
+	var total int64
+	var lastID int64
+	for {
+		batch, err := s.loadArchiveBatch(ctx, where, before, lastID)
+		if err != nil {
+			return total, err
+		}
+		if len(batch) == 0 {
+			return total, nil
+		}
+		for _, item := range batch {
+			records, err := s.ListAnalyses(ctx, item.ID)
+			if err != nil {
+				return total, err
+			}
+			item.Analyses = records
+			if err := write(item); err != nil {
+				return total, fmt.Errorf("archive item %d: %w", item.ID, err)
+			}
+			if _, err := s.db.ExecContext(ctx, "UPDATE news_analysis SET archived_at = NOW() WHERE id = ?", item.ID); err != nil {
+				return total, fmt.Errorf("mark archived %d: %w", item.ID, err)
+			}
+			total++
+			lastID = item.ID
+		}
+	}
+}
+
+func (s *Store) loadArchiveBatch(ctx context.Context, where string, before time.Time, afterID int64) ([]ArchivedItem, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, guid, title, link, published_at, summary, relevant, category, reason, tags, created_at
+FROM news_analysis
+WHERE `+where+` AND id > ?
+ORDER BY id
+LIMIT ?`, before, afterID, retentionBatch)
+	if err != nil {
+		return nil, fmt.Errorf("load archive batch: %w", err)
+	}
+	defer rows.Close()
+
+	var batch []ArchivedItem
+	for rows.Next() {
+		var (
+			item                            ArchivedItem
+			link, summary, category, reason sql.NullString
+			tags                            sql.NullString
+			pub                             sql.NullTime
+		)
+		if err := rows.Scan(&item.ID, &item.GUID, &item.Title, &link, &pub, &summary, &item.Relevant, &category, &reason, &tags, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		item.Link = link.String
+		item.PublishedAt = pub.Time
+		item.Summary = summary.String
+		item.Category = category.String
+		item.Reason = reason.String
+		item.Tags = decodeTags(tags)
+		batch = append(batch, item)
+	}
+	return batch, rows.Err()
+}
+
+// DeleteItems hard-deletes items older than before together with their
+// dependent rows. When archiveBefore is set, unarchived rows are kept; since
+// a dry run archives nothing, it also counts the rows older than
+// archiveBefore that the archive step of a real run marks first. Items
+// with analyst feedback are kept too, since their labels are the evaluation
+// set and the few-shot examples. Funding rounds and security incidents left
+// without items are deleted and stories are re-pointed at their remaining
+// items, in the same transaction.
+func (s *Store) DeleteItems(ctx context.Context, before, archiveBefore time.Time, dryRun bool) (int64, error) {
+	where := "COALESCE(n.published_at, n.created_at) < ? AND NOT EXISTS (SELECT 1 FROM item_feedback f WHERE f.item_id = n.id)"
+	if dryRun {
+		args := []any{before}
+		if !archiveBefore.IsZero() {
+			where += " AND (n.archived_at IS NOT NULL OR COALESCE(n.published_at, n.created_at) < ?)"
+			args = append(args, archiveBefore)
+		}
+		return s.count(ctx, "SELECT COUNT(*) FROM news_analysis n WHERE "+where, args...)
+	}
+	if !archiveBefore.IsZero() {
+		where += " AND n.archived_at IS NOT NULL"
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("delete items: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Parents and stories of the deleted items, collected before their links go.
+	parents := make([][]any, len(itemParents))
+	for i, p := range itemParents {
+		if parents[i], err = queryIDs(ctx, tx, "SELECT DISTINCT l."+p.column+" FROM "+p.links+" l JOIN news_analysis n ON n.id = l.item_id WHERE "+where, before); err != nil {
+			return 0, fmt.Errorf("list %s: %w", p.table, err)
+		}
+	}
+	stories, err := queryIDs(ctx, tx, "SELECT DISTINCT n.story_id FROM news_analysis n WHERE n.story_id > 0 AND "+where, before)
+	if err != nil {
+		return 0, fmt.Errorf("list stories: %w", err)
+	}
+
+	for _, table := range itemChildTables {
+		if _, err := tx.ExecContext(ctx, "DELETE a FROM "+table+" a JOIN news_analysis n ON n.id = a.item_id WHERE "+where, before); err != nil {
+			return 0, fmt.Errorf("delete %s: %w", table, err)
+		}
+	}
+	for _, t := range itemGUIDTables {
+		if _, err := tx.ExecContext(ctx, "DELETE a FROM "+t.table+" a JOIN news_analysis n ON n.guid = a."+t.column+" WHERE "+where, before); err != nil {
+			return 0, fmt.Errorf("delete %s: %w", t.table, err)
+		}
+	}
+	res, err := tx.ExecContext(ctx, "DELETE n FROM news_analysis n WHERE "+where, before)
+	if err != nil {
+		return 0, fmt.Errorf("delete items: %w", err)
+	}
+	for i, p := range itemParents {
+		err := inBatches(parents[i], func(ids []any) error {
+			for _, d := range p.details {
+				if _, err := tx.ExecContext(ctx, "DELETE FROM "+d+" WHERE "+p.column+" IN ("+placeholders(len(ids))+
+					") AND NOT EXISTS (SELECT 1 FROM "+p.links+" l WHERE l."+p.column+" = "+d+"."+p.column+")", ids...); err != nil {
+					return fmt.Errorf("%s: %w", d, err)
+				}
+			}
+			_, err := tx.ExecContext(ctx, "DELETE FROM "+p.table+" WHERE id IN ("+placeholders(len(ids))+
+				") AND NOT EXISTS (SELECT 1 FROM "+p.links+" l WHERE l."+p.column+" = "+p.table+".id)", ids...)
+			return err
+		})
+		if err != nil {
+			return 0, fmt.Errorf("delete %s: %w", p.table, err)
+		}
+	}
+	// Stories keep their remaining items, the earliest becoming canonical, and
+	// go when none is left.
+	err = inBatches(stories, func(ids []any) error {
+		if _, err := tx.ExecContext(ctx, `
+UPDATE stories st
+JOIN (
+	SELECT story_id, COUNT(*) AS items, MIN(id) AS first_id
+	FROM news_analysis
+	WHERE story_id IN (`+placeholders(len(ids))+`)
+	GROUP BY story_id
+) r ON r.story_id = st.id
+JOIN news_analysis c ON c.id = r.first_id
+SET st.item_count = r.items, st.canonical_item_id = r.first_id, st.title = c.title`, ids...); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "DELETE FROM stories WHERE id IN ("+placeholders(len(ids))+
+			") AND NOT EXISTS (SELECT 1 FROM news_analysis n WHERE n.story_id = stories.id)", ids...)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("update stories: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("delete items: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// queryIDs returns the ids selected by query, as arguments for an IN list.
+func queryIDs(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]any, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []any
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// inBatches calls fn with ids split into batches of at most retentionBatch.
+func inBatches(ids []any, fn func([]any) error) error {
+	for len(ids) > 0 {
+		n := min(len(ids), retentionBatch)
+		if err := fn(ids[:n]); err != nil {
+			return err
+		}
+		ids = ids[n:]
+	}
+	return nil
+}
+
+// placeholders returns n comma-separated query placeholders.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func (s *Store) count(ctx context.Context, query string, args ...any) (int64, error) {
+	var n int64
+	if err := s.db.QueryRowContext(ctx, query, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,283 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const createSecurityIncidentsTable = `
+CREATE TABLE IF NOT EXISTS security_incidents (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	protocol VARCHAR(255) NOT NULL,
+	protocol_key VARCHAR(255) NOT NULL,
+	chain VARCHAR(64) NOT NULL DEFAULT '',
+	loss_usd DECIMAL(20,2) NOT NULL DEFAULT 0,
+	attack_vector VARCHAR(32) NOT NULL DEFAULT '',
+	recovered_usd DECIMAL(20,2) NOT NULL DEFAULT 0,
+	occurred_on DATE NOT NULL,
+	summary TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	INDEX idx_security_protocol (protocol_key, occurred_on),
+	INDEX idx_security_occurred (occurred_on),
+	INDEX idx_security_loss (loss_usd)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+const createSecurityIncidentItemsTable = `
+CREATE TABLE IF NOT EXISTS security_incident_items (
+	incident_id BIGINT NOT NULL,
+	item_id BIGINT NOT NULL,
+	PRIMARY KEY (incident_id, item_id),
+	INDEX idx_security_item (item_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+// securityDedupWindowDays is how far apart two reports of an incident on the
+// same protocol may be dated and still be treated as one incident.
+const securityDedupWindowDays = 7
+
+// SecurityIncident is a deduplicated hack or exploit and the items reporting it.
+type SecurityIncident struct {
+	ID           int64     `json:"id"`
+	Protocol     string    `json:"protocol"`
+	Chain        string    `json:"chain"`
+	LossUSD      float64   `json:"loss_usd"`
+	AttackVector string    `json:"attack_vector"`
+	RecoveredUSD float64   `json:"recovered_usd"`
+	OccurredOn   time.Time `json:"occurred_on"`
+	Summary      string    `json:"summary"`
+	ItemIDs      []int64   `json:"item_ids"`
+}
+
+// SecurityFilter narrows security incident listings.
+type SecurityFilter struct {
+	Chain        string
+	AttackVector string
+	Protocol     string
+	Since        time.Time
+	Until        time.Time
+	MinLoss      float64
+	// SortByDate orders by occurrence instead of loss size.
+	SortByDate bool
+	Limit      int
+}
+
+// SecurityBucket aggregates incidents sharing a chain or attack vector.
+type SecurityBucket struct {
+	Key          string  `json:"key"`
+	Incidents    int     `json:"incidents"`
+	LossUSD      float64 `json:"loss_usd"`
+	RecoveredUSD float64 `json:"recovered_usd"`
+}
+
+// SecurityReport summarizes the incidents of one month.
+type SecurityReport struct {
+	Month        string             `json:"month"`
+	Incidents    int                `json:"incidents"`
+	LossUSD      float64            `json:"loss_usd"`
+	RecoveredUSD float64            `json:"recovered_usd"`
+	ByVector     []SecurityBucket   `json:"by_vector"`
+	ByChain      []SecurityBucket   `json:"by_chain"`
+	Top          []SecurityIncident `json:"top"`
+}
+
+// SaveSecurityIncident records an incident reported by itemID, merging it into
+// an incident on the same protocol within the dedup window when one exists.
+func (s *Store) SaveSecurityIncident(ctx context.Context, itemID int64, inc SecurityIncident) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("save security incident: %w", err)
+	}
+	defer tx.Rollback()
+
+	protocolKey := strings.ToLower(inc.Protocol)
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+SELECT id FROM security_incidents
+WHERE protocol_key = ? AND ABS(DATEDIFF(occurred_on, ?)) <= ?
+ORDER BY ABS(DATEDIFF(occurred_on, ?))
+LIMIT 1
+FOR UPDATE`, protocolKey, inc.OccurredOn, securityDedupWindowDays, inc.OccurredOn).Scan(&id)
+	switch {
+	case err == sql.ErrNoRows:
+		res, err := tx.ExecContext(ctx, `
+INSERT INTO security_incidents (protocol, protocol_key, chain, loss_usd, attack_vector, recovered_usd, occurred_on, summary)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			truncate(inc.Protocol, 255), truncate(protocolKey, 255), truncate(inc.Chain, 64), inc.LossUSD, inc.AttackVector, inc.RecoveredUSD, inc.OccurredOn, inc.Summary)
+		if err != nil {
+			return 0, fmt.Errorf("insert security incident: %w", err)
+		}
+		if id, err = res.LastInsertId(); err != nil {
+			return 0, fmt.Errorf("insert security incident: %w", err)
+		}
+	case err != nil:
+		return 0, fmt.Errorf("lookup security incident: %w", err)
+	default:
+		// Later reports usually carry firmer loss and recovery figures.
+		if _, err := tx.ExecContext(ctx, `
+UPDATE security_incidents SET
+	loss_usd = GREATEST(loss_usd, ?),
+	recovered_usd = GREATEST(recovered_usd, ?),
+	chain = IF(chain = '', ?, chain),
+	attack_vector = IF(attack_vector IN ('', 'other'), ?, attack_vector),
+	occurred_on = LEAST(occurred_on, ?),
+	summary = IF(summary IS NULL OR summary = '', ?, summary)
+WHERE id = ?`, inc.LossUSD, inc.RecoveredUSD, truncate(inc.Chain, 64), inc.AttackVector, inc.OccurredOn, inc.Summary, id); err != nil {
+			return 0, fmt.Errorf("merge security incident: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT IGNORE INTO security_incident_items (incident_id, item_id) VALUES (?, ?)", id, itemID); err != nil {
+		return 0, fmt.Errorf("link security item: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("save security incident: %w", err)
+	}
+	return id, nil
+}
+
+// ListSecurityIncidents returns incidents matching f, largest loss first
+// unless f.SortByDate is set.
+func (s *Store) ListSecurityIncidents(ctx context.Context, f SecurityFilter) ([]SecurityIncident, error) {
+	clauses := []string{"1=1"}
+	var args []any
+	if f.Chain != "" {
+		clauses = append(clauses, "chain = ?")
+		args = append(args, f.Chain)
+	}
+	if f.AttackVector != "" {
+		clauses = append(clauses, "attack_vector = ?")
+		args = append(args, f.AttackVector)
+	}
+	if f.Protocol != "" {
+		clauses = append(clauses, "protocol_key = ?")
+		args = append(args, strings.ToLower(f.Protocol))
+	}
+	if !f.Since.IsZero() {
+		clauses = append(clauses, "occurred_on >= ?")
+		args = append(args, f.Since)
+	}
+	if !f.Until.IsZero() {
+		clauses = append(clauses, "occurred_on < ?")
+		args = append(args, f.Until)
+	}
+	if f.MinLoss > 0 {
+		clauses = append(clauses, "loss_usd >= ?")
+		args = append(args, f.MinLoss)
+	}
+	order := "loss_usd DESC, occurred_on DESC"
+	if f.SortByDate {
+		order = "occurred_on DESC, id DESC"
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, protocol, chain, loss_usd, attack_vector, recovered_usd, occurred_on, summary
+FROM security_incidents
+WHERE `+strings.Join(clauses, " AND ")+`
+ORDER BY `+order+`
+LIMIT ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list security incidents: %w", err)
+	}
+	incidents := []SecurityIncident{}
+	for rows.Next() {
+		var (
+			inc     SecurityIncident
+			summary sql.NullString
+		)
+		if err := rows.Scan(&inc.ID, &inc.Protocol, &inc.Chain, &inc.LossUSD, &inc.AttackVector, &inc.RecoveredUSD, &inc.OccurredOn, &summary); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		inc.Summary = summary.String
+		incidents = append(incidents, inc)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range incidents {
+		ids, err := s.incidentItems(ctx, incidents[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		incidents[i].ItemIDs = ids
+	}
+	return incidents, nil
+}
+
+func (s *Store) incidentItems(ctx context.Context, incidentID int64) ([]int64, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT item_id FROM security_incident_items WHERE incident_id = ? ORDER BY item_id", incidentID)
+	if err != nil {
+		return nil, fmt.Errorf("load incident items: %w", err)
+	}
+	defer rows.Close()
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// SecurityMonthlyReport summarizes the incidents that occurred in the month
+// starting at month, listing the top largest losses.
+func (s *Store) SecurityMonthlyReport(ctx context.Context, month time.Time, top int) (SecurityReport, error) {
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	report := SecurityReport{Month: start.Format("2006-01")}
+
+	if err := s.db.QueryRowContext(ctx, `
+SELECT COUNT(*), COALESCE(SUM(loss_usd), 0), COALESCE(SUM(recovered_usd), 0)
+FROM security_incidents
+WHERE occurred_on >= ? AND occurred_on < ?`, start, end).Scan(&report.Incidents, &report.LossUSD, &report.RecoveredUSD); err != nil {
+		return report, fmt.Errorf("security report totals: %w", err)
+	}
+
+	var err error
+	if report.ByVector, err = s.securityBuckets(ctx, "IF(attack_vector = '', 'other', attack_vector)", start, end); err != nil {
+		return report, err
+	}
+	if report.ByChain, err = s.securityBuckets(ctx, "IF(chain = '', 'unknown', chain)", start, end); err != nil {
+		return report, err
+	}
+	if report.Top, err = s.ListSecurityIncidents(ctx, SecurityFilter{Since: start, Until: end, Limit: top}); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+func (s *Store) securityBuckets(ctx context.Context, keyExpr string, start, end time.Time) ([]SecurityBucket, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT `+keyExpr+`, COUNT(*), SUM(loss_usd), SUM(recovered_usd)
+FROM security_incidents
+WHERE occurred_on >= ? AND occurred_on < ?
+GROUP BY 1
+ORDER BY 3 DESC`, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("security report buckets: %w", err)
+	}
+	defer rows.Close()
+	buckets := []SecurityBucket{}
+	for rows.Next() {
+		var b SecurityBucket
+		if err := rows.Scan(&b.Key, &b.Incidents, &b.LossUSD, &b.RecoveredUSD); err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, b)
+	}
+	return buckets, rows.Err()
+}
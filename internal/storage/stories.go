@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"aiweb3news/internal/cluster"
+)
+
+const createStoriesTable = `
+CREATE TABLE IF NOT EXISTS stories (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	canonical_item_id BIGINT NOT NULL,
+	title VARCHAR(512) NOT NULL,
+	item_count INT NOT NULL DEFAULT 1,
+	first_seen DATETIME NOT NULL,
+	last_seen DATETIME NOT NULL,
+	notified_at DATETIME NULL,
+	INDEX idx_stories_last_seen (last_seen)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+// Story groups the items reporting the same event. The canonical item is the
+// first one seen; later items are updates to it.
+type Story struct {
+	ID              int64      `json:"id"`
+	CanonicalItemID int64      `json:"canonical_item_id"`
+	Title           string     `json:"title"`
+	ItemCount       int        `json:"item_count"`
+	FirstSeen       time.Time  `json:"first_seen"`
+	LastSeen        time.Time  `json:"last_seen"`
+	NotifiedAt      *time.Time `json:"notified_at,omitempty"`
+}
+
+// StoryFilter narrows the story listing.
+type StoryFilter struct {
+	Since    time.Time
+	MinItems int
+	Limit    int
+}
+
+// StoryCandidates returns clustered or fingerprinted items published within
+// window of around, excluding excludeID.
+func (s *Store) StoryCandidates(ctx context.Context, around time.Time, window time.Duration, excludeID int64) ([]cluster.Candidate, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, COALESCE(story_id, 0), title, simhash
+FROM news_analysis
+WHERE simhash IS NOT NULL AND id <> ?
+	AND COALESCE(published_at, created_at) BETWEEN ? AND ?`, excludeID, around.Add(-window), around.Add(window))
+	if err != nil {
+		return nil, fmt.Errorf("story candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var out []cluster.Candidate
+	for rows.Next() {
+		var c cluster.Candidate
+		if err := rows.Scan(&c.ItemID, &c.StoryID, &c.Title, &c.SimHash); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// AssignStory stores the fingerprint of an item and files it under storyID,
+// or under a new story with the item as canonical when storyID is zero. An
+// item that already belongs to a story keeps it. It returns the story id.
+func (s *Store) AssignStory(ctx context.Context, itemID int64, fp uint64, storyID int64) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("assign story: %w", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		current sql.NullInt64
+		title   string
+		seen    time.Time
+	)
+	if err := tx.QueryRowContext(ctx, "SELECT story_id, title, COALESCE(published_at, created_at) FROM news_analysis WHERE id = ? FOR UPDATE", itemID).
+		Scan(&current, &title, &seen); err != nil {
+		return 0, fmt.Errorf("assign story: lookup item: %w", err)
+	}
+	if current.Valid {
+		_, err := tx.ExecContext(ctx, "UPDATE news_analysis SET simhash = ? WHERE id = ?", fp, itemID)
+		if err != nil {
+			return 0, fmt.Errorf("assign story: %w", err)
+		}
+		return current.Int64, tx.Commit()
+	}
+
+	if storyID == 0 {
+		res, err := tx.ExecContext(ctx, "INSERT INTO stories (canonical_item_id, title, first_seen, last_seen) VALUES (?, ?, ?, ?)",
+			itemID, truncate(title, 512), seen, seen)
+		if err != nil {
+			return 0, fmt.Errorf("create story: %w", err)
+		}
+		if storyID, err = res.LastInsertId(); err != nil {
+			return 0, fmt.Errorf("create story: %w", err)
+		}
+	} else if _, err := tx.ExecContext(ctx, "UPDATE stories SET item_count = item_count + 1, last_seen = GREATEST(last_seen, ?) WHERE id = ?", seen, storyID); err != nil {
+		return 0, fmt.Errorf("append to story: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE news_analysis SET simhash = ?, story_id = ? WHERE id = ?", fp, storyID, itemID); err != nil {
+		return 0, fmt.Errorf("assign story: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("assign story: %w", err)
+	}
+	return storyID, nil
+}
+
+// ClaimStoryNotification marks a story as pushed. It reports false when an
+// earlier item of the story was already pushed.
+func (s *Store) ClaimStoryNotification(ctx context.Context, storyID int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, "UPDATE stories SET notified_at = NOW() WHERE id = ? AND notified_at IS NULL", storyID)
+	if err != nil {
+		return false, fmt.Errorf("claim story notification: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("claim story notification: %w", err)
+	}
+	return n == 1, nil
+}
+
+// ListStories returns stories most recently updated first.
+func (s *Store) ListStories(ctx context.Context, f StoryFilter) ([]Story, error) {
+	query := "SELECT id, canonical_item_id, title, item_count, first_seen, last_seen, notified_at FROM stories WHERE item_count >= ?"
+	args := []any{max(f.MinItems, 1)}
+	if !f.Since.IsZero() {
+		query += " AND last_seen >= ?"
+		args = append(args, f.Since)
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	query += " ORDER BY last_seen DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list stories: %w", err)
+	}
+	defer rows.Close()
+	stories := []Story{}
+	for rows.Next() {
+		story, err := scanStory(rows)
+		if err != nil {
+			return nil, err
+		}
+		stories = append(stories, story)
+	}
+	return stories, rows.Err()
+}
+
+// GetStory loads a single story.
+func (s *Store) GetStory(ctx context.Context, id int64) (Story, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT id, canonical_item_id, title, item_count, first_seen, last_seen, notified_at FROM stories WHERE id = ?", id)
+	story, err := scanStory(row)
+	if err == sql.ErrNoRows {
+		return Story{}, ErrNotFound
+	}
+	return story, err
+}
+
+func scanStory(row rowScanner) (Story, error) {
+	var (
+		story    Story
+		notified sql.NullTime
+	)
+	if err := row.Scan(&story.ID, &story.CanonicalItemID, &story.Title, &story.ItemCount, &story.FirstSeen, &story.LastSeen, &notified); err != nil {
+		return Story{}, err
+	}
+	if notified.Valid {
+		story.NotifiedAt = &notified.Time
+	}
+	return story, nil
+}
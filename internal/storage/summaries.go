@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// SummaryHash returns the content hash the stored summaries were generated
+// from, "" when the item has none.
+func (s *Store) SummaryHash(ctx context.Context, itemID int64) (string, error) {
+	var hash sql.NullString
+	if err := s.db.QueryRowContext(ctx, "SELECT summary_hash FROM news_analysis WHERE id = ?", itemID).Scan(&hash); err != nil {
+		return "", fmt.Errorf("summary hash: %w", err)
+	}
+	return hash.String, nil
+}
+
+// SaveSummaries stores the generated Chinese summary and English title and
+// summary of an item together with the hash of the content they describe.
+func (s *Store) SaveSummaries(ctx context.Context, itemID int64, hash, summaryZH, titleEN, summaryEN string) error {
+	_, err := s.db.ExecContext(ctx, `
+UPDATE news_analysis SET summary_zh = ?, title_en = ?, summary_en = ?, summary_hash = ? WHERE id = ?`,
+		summaryZH, truncate(titleEN, 512), summaryEN, hash, itemID)
+	if err != nil {
+		return fmt.Errorf("save summaries: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"aiweb3news/internal/analysis"
+	"aiweb3news/internal/trends"
+)
+
+const createTrendAlertsTable = `
+CREATE TABLE IF NOT EXISTS trend_alerts (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	kind VARCHAR(32) NOT NULL,
+	term VARCHAR(255) NOT NULL,
+	count INT NOT NULL,
+	baseline DECIMAL(10,2) NOT NULL,
+	ratio DECIMAL(10,2) NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	INDEX idx_trend_alerts_term (kind, term, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+// TagMentions returns one mention per tag of every item published since.
+func (s *Store) TagMentions(ctx context.Context, since time.Time) ([]trends.Mention, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, title, link, importance, published_at, tags
+FROM news_analysis
+WHERE published_at >= ? AND tags IS NOT NULL AND tags <> ''`, since)
+	if err != nil {
+		return nil, fmt.Errorf("tag mentions: %w", err)
+	}
+	defer rows.Close()
+
+	var out []trends.Mention
+	for rows.Next() {
+		var (
+			m    trends.Mention
+			link sql.NullString
+			tags sql.NullString
+		)
+		if err := rows.Scan(&m.ItemID, &m.Title, &link, &m.Importance, &m.PublishedAt, &tags); err != nil {
+			return nil, err
+		}
+		m.Kind = "tag"
+		m.Link = link.String
+		for _, tag := range decodeTags(tags) {
+			m.Term = tag
+			out = append(out, m)
+		}
+	}
+	return out, rows.Err()
+}
+
+// EntityMentions returns one mention per extracted entity, amounts excluded,
+// of every item published since. Kind is the entity type.
+func (s *Store) EntityMentions(ctx context.Context, since time.Time) ([]trends.Mention, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT n.id, n.title, n.link, n.importance, n.published_at, e.type, e.name
+FROM item_entities e
+JOIN news_analysis n ON n.id = e.item_id
+WHERE n.published_at >= ? AND e.type <> ?`, since, analysis.EntityAmount)
+	if err != nil {
+		return nil, fmt.Errorf("entity mentions: %w", err)
+	}
+	defer rows.Close()
+
+	var out []trends.Mention
+	for rows.Next() {
+		var (
+			m    trends.Mention
+			link sql.NullString
+		)
+		if err := rows.Scan(&m.ItemID, &m.Title, &link, &m.Importance, &m.PublishedAt, &m.Kind, &m.Term); err != nil {
+			return nil, err
+		}
+		m.Link = link.String
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// TrendAlertedSince reports whether a trend alert for the term was sent after since.
+// PurgeTrendAlerts deletes alert records older than before. With dryRun it
+// only counts them.
+func (s *Store) PurgeTrendAlerts(ctx context.Context, before time.Time, dryRun bool) (int64, error) {
+	if dryRun {
+		return s.count(ctx, "SELECT COUNT(*) FROM trend_alerts WHERE created_at < ?", before)
+	}
+	res, err := s.db.ExecContext(ctx, "DELETE FROM trend_alerts WHERE created_at < ?", before)
+	if err != nil {
+		return 0, fmt.Errorf("purge trend alerts: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func (s *Store) TrendAlertedSince(ctx context.Context, kind, term string, since time.Time) (bool, error) {
+	n, err := s.count(ctx, "SELECT COUNT(*) FROM trend_alerts WHERE kind = ? AND term = ? AND created_at >= ?", kind, truncate(term, 255), since)
+	return n > 0, err
+}
+
+// SaveTrendAlert records that an alert for t was sent.
+func (s *Store) SaveTrendAlert(ctx context.Context, t trends.Trend) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO trend_alerts (kind, term, count, baseline, ratio) VALUES (?, ?, ?, ?, ?)",
+		t.Kind, truncate(t.Term, 255), t.Count, t.Baseline, t.Ratio)
+	if err != nil {
+		return fmt.Errorf("save trend alert: %w", err)
+	}
+	return nil
+}
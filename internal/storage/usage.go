@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const createLLMUsageTable = `
+CREATE TABLE IF NOT EXISTS llm_usage (
+	day DATE NOT NULL,
+	model VARCHAR(128) NOT NULL,
+	purpose VARCHAR(64) NOT NULL,
+	calls INT NOT NULL DEFAULT 0,
+	errors INT NOT NULL DEFAULT 0,
+	prompt_tokens BIGINT NOT NULL DEFAULT 0,
+	completion_tokens BIGINT NOT NULL DEFAULT 0,
+	cost DECIMAL(14,6) NOT NULL DEFAULT 0,
+	PRIMARY KEY (day, model, purpose)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+// UsageCall is the accounting of one model call.
+type UsageCall struct {
+	Day              string
+	Model            string
+	Purpose          string
+	Failed           bool
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+}
+
+// UsageTotal is the token usage and cost of one model and purpose over a
+// period ("2006-01-02" for days, "2006-01" for months).
+type UsageTotal struct {
+	Period           string  `json:"period"`
+	Model            string  `json:"model"`
+	Purpose          string  `json:"purpose"`
+	Calls            int64   `json:"calls"`
+	Errors           int64   `json:"errors"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost_usd"`
+}
+
+// AddUsage adds a call to the daily totals of its model and purpose.
+func (s *Store) AddUsage(ctx context.Context, call UsageCall) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO llm_usage (day, model, purpose, calls, errors, prompt_tokens, completion_tokens, cost)
+VALUES (?, ?, ?, 1, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE calls = calls + 1, errors = errors + VALUES(errors),
+	prompt_tokens = prompt_tokens + VALUES(prompt_tokens), completion_tokens = completion_tokens + VALUES(completion_tokens),
+	cost = cost + VALUES(cost)`,
+		call.Day, truncate(call.Model, 128), truncate(call.Purpose, 64), boolInt(call.Failed), call.PromptTokens, call.CompletionTokens, call.Cost)
+	if err != nil {
+		return fmt.Errorf("add usage: %w", err)
+	}
+	return nil
+}
+
+// UsageTotals returns usage per period, model and purpose for the days from
+// since on. period is "day" or "month".
+func (s *Store) UsageTotals(ctx context.Context, period string, since time.Time) ([]UsageTotal, error) {
+	format := "%Y-%m-%d"
+	if period == "month" {
+		format = "%Y-%m"
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT DATE_FORMAT(day, '`+format+`'), model, purpose, SUM(calls), SUM(errors), SUM(prompt_tokens), SUM(completion_tokens), SUM(cost)
+FROM llm_usage
+WHERE day >= ?
+GROUP BY 1, 2, 3
+ORDER BY 1, 2, 3`, since.UTC().Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("usage totals: %w", err)
+	}
+	defer rows.Close()
+
+	var out []UsageTotal
+	for rows.Next() {
+		var t UsageTotal
+		if err := rows.Scan(&t.Period, &t.Model, &t.Purpose, &t.Calls, &t.Errors, &t.PromptTokens, &t.CompletionTokens, &t.Cost); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// UsageCost returns the total cost of day.
+func (s *Store) UsageCost(ctx context.Context, day string) (float64, error) {
+	var cost float64
+	if err := s.db.QueryRowContext(ctx, "SELECT COALESCE(SUM(cost), 0) FROM llm_usage WHERE day = ?", day).Scan(&cost); err != nil {
+		return 0, fmt.Errorf("usage cost: %w", err)
+	}
+	return cost, nil
+}
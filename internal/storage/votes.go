@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"aiweb3news/internal/analysis"
+)
+
+const createEnsembleVotesTable = `
+CREATE TABLE IF NOT EXISTS ensemble_votes (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	item_id BIGINT NOT NULL,
+	analysis_id BIGINT NULL,
+	model VARCHAR(128) NOT NULL,
+	relevant TINYINT(1) NOT NULL,
+	category VARCHAR(255) NOT NULL DEFAULT '',
+	confidence DECIMAL(4,3) NOT NULL DEFAULT 0,
+	error TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	INDEX idx_ensemble_votes_item (item_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+// StoredVote is an ensemble member's answer for one analysis of an item.
+type StoredVote struct {
+	analysis.Vote
+	AnalysisID int64     `json:"analysis_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// SaveVotes stores the ensemble votes behind the item's current analysis.
+func (s *Store) SaveVotes(ctx context.Context, itemID int64, votes []analysis.Vote) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("save votes: %w", err)
+	}
+	defer tx.Rollback()
+	for _, v := range votes {
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO ensemble_votes (item_id, analysis_id, model, relevant, category, confidence, error)
+SELECT ?, current_analysis_id, ?, ?, ?, ?, ? FROM news_analysis WHERE id = ?`,
+			itemID, truncate(v.Model, 128), v.Relevant, truncate(v.Category, 255), v.Confidence, v.Error, itemID); err != nil {
+			return fmt.Errorf("save vote of %s: %w", v.Model, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ListVotes returns the ensemble votes of an item, newest analysis first.
+func (s *Store) ListVotes(ctx context.Context, itemID int64) ([]StoredVote, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT COALESCE(analysis_id, 0), model, relevant, category, confidence, COALESCE(error, ''), created_at
+FROM ensemble_votes
+WHERE item_id = ?
+ORDER BY id DESC`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("list votes: %w", err)
+	}
+	defer rows.Close()
+
+	var out []StoredVote
+	for rows.Next() {
+		var v StoredVote
+		if err := rows.Scan(&v.AnalysisID, &v.Model, &v.Relevant, &v.Category, &v.Confidence, &v.Error, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
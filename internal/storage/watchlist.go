@@ -0,0 +1,342 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"aiweb3news/internal/rss"
+	"aiweb3news/internal/watchlist"
+)
+
+const createWatchlistEntriesTable = `
+CREATE TABLE IF NOT EXISTS watchlist_entries (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	list VARCHAR(128) NOT NULL DEFAULT '',
+	name VARCHAR(255) NOT NULL,
+	kind VARCHAR(16) NOT NULL,
+	entity_type VARCHAR(32) NOT NULL DEFAULT '',
+	aliases TEXT,
+	channel VARCHAR(128) NOT NULL DEFAULT '',
+	enabled TINYINT(1) NOT NULL DEFAULT 1,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	INDEX idx_watchlist_entries_list (list)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+const createWatchlistMatchesTable = `
+CREATE TABLE IF NOT EXISTS watchlist_matches (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	item_id BIGINT NOT NULL,
+	entry_id BIGINT NOT NULL,
+	list VARCHAR(128) NOT NULL DEFAULT '',
+	name VARCHAR(255) NOT NULL,
+	term VARCHAR(255) NOT NULL,
+	field VARCHAR(16) NOT NULL,
+	reason TEXT,
+	channel VARCHAR(128) NOT NULL DEFAULT '',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE KEY uk_watchlist_matches (item_id, entry_id),
+	INDEX idx_watchlist_matches_list (list)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+// watchlist_alerts is the outbox of watchlist alerts. Rows are keyed by guid
+// because alerts are queued before the item is classified and stored.
+// delivered lists the destinations that already received an alert sent to
+// several of them, so a retry only goes to the ones that failed; failed_at
+// marks alerts given up after repeated failures.
+const createWatchlistAlertsTable = `
+CREATE TABLE IF NOT EXISTS watchlist_alerts (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	guid VARCHAR(255) NOT NULL,
+	entry_id BIGINT NOT NULL,
+	title VARCHAR(512) NOT NULL,
+	link VARCHAR(1024) NOT NULL,
+	channel VARCHAR(128) NOT NULL DEFAULT '',
+	reason_zh TEXT,
+	reason_en TEXT,
+	attempts INT NOT NULL DEFAULT 0,
+	delivered TEXT NULL,
+	last_error TEXT,
+	sent_at DATETIME NULL,
+	failed_at DATETIME NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE KEY uk_watchlist_alerts (guid, entry_id),
+	INDEX idx_watchlist_alerts_pending (sent_at, id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
+// WatchAlert is a queued watchlist alert for one entry matched by an item.
+type WatchAlert struct {
+	ID       int64
+	GUID     string
+	EntryID  int64
+	Title    string
+	Link     string
+	Channel  string
+	ReasonZH string
+	ReasonEN string
+	Attempts int
+	// Delivered lists the destinations that already received the alert.
+	Delivered []string
+}
+
+// WatchMatch is a stored watchlist match of an item.
+type WatchMatch struct {
+	watchlist.Match
+	ItemID   int64     `json:"item_id"`
+	Reason   string    `json:"reason"`
+	Notified bool      `json:"notified"`
+	Created  time.Time `json:"created_at"`
+}
+
+const watchEntryColumns = "id, list, name, kind, entity_type, COALESCE(aliases, ''), channel, enabled, created_at, updated_at"
+
+// ListWatchEntries returns watchlist entries ordered by list and name,
+// optionally only those of one list.
+func (s *Store) ListWatchEntries(ctx context.Context, list string) ([]watchlist.Entry, error) {
+	query := "SELECT " + watchEntryColumns + " FROM watchlist_entries"
+	var args []any
+	if list != "" {
+		query += " WHERE list = ?"
+		args = append(args, list)
+	}
+	rows, err := s.db.QueryContext(ctx, query+" ORDER BY list, name, id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("list watchlist entries: %w", err)
+	}
+	defer rows.Close()
+
+	var out []watchlist.Entry
+	for rows.Next() {
+		e, err := scanWatchEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// GetWatchEntry loads one watchlist entry.
+func (s *Store) GetWatchEntry(ctx context.Context, id int64) (watchlist.Entry, error) {
+	e, err := scanWatchEntry(s.db.QueryRowContext(ctx, "SELECT "+watchEntryColumns+" FROM watchlist_entries WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return watchlist.Entry{}, ErrNotFound
+	}
+	return e, err
+}
+
+// CreateWatchEntry stores a new entry and returns its id.
+func (s *Store) CreateWatchEntry(ctx context.Context, e watchlist.Entry) (int64, error) {
+	aliases, _ := json.Marshal(e.Aliases)
+	res, err := s.db.ExecContext(ctx, `
+INSERT INTO watchlist_entries (list, name, kind, entity_type, aliases, channel, enabled)
+VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		truncate(e.List, 128), truncate(e.Name, 255), e.Kind, e.EntityType, string(aliases), truncate(e.Channel, 128), e.Enabled)
+	if err != nil {
+		return 0, fmt.Errorf("create watchlist entry: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// UpdateWatchEntry replaces an entry's fields.
+func (s *Store) UpdateWatchEntry(ctx context.Context, e watchlist.Entry) error {
+	aliases, _ := json.Marshal(e.Aliases)
+	res, err := s.db.ExecContext(ctx, `
+UPDATE watchlist_entries SET list = ?, name = ?, kind = ?, entity_type = ?, aliases = ?, channel = ?, enabled = ?
+WHERE id = ?`,
+		truncate(e.List, 128), truncate(e.Name, 255), e.Kind, e.EntityType, string(aliases), truncate(e.Channel, 128), e.Enabled, e.ID)
+	if err != nil {
+		return fmt.Errorf("update watchlist entry: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	// MySQL reports zero affected rows when nothing changed, too.
+	n, err := s.count(ctx, "SELECT COUNT(*) FROM watchlist_entries WHERE id = ?", e.ID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteWatchEntry removes an entry. Matches it already produced are kept as
+// a record of why items were flagged.
+func (s *Store) DeleteWatchEntry(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM watchlist_entries WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("delete watchlist entry: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanWatchEntry(row rowScanner) (watchlist.Entry, error) {
+	var (
+		e       watchlist.Entry
+		aliases string
+	)
+	if err := row.Scan(&e.ID, &e.List, &e.Name, &e.Kind, &e.EntityType, &aliases, &e.Channel, &e.Enabled, &e.CreatedAt, &e.UpdatedAt); err != nil {
+		return e, err
+	}
+	if aliases != "" {
+		_ = json.Unmarshal([]byte(aliases), &e.Aliases)
+	}
+	if e.Aliases == nil {
+		e.Aliases = []string{}
+	}
+	return e, nil
+}
+
+// SaveWatchMatches records the current matches of an item: matches found
+// again are updated in place and matches no longer found are removed, so a
+// re-analysis leaves the matches of its latest run.
+func (s *Store) SaveWatchMatches(ctx context.Context, itemID int64, matches []watchlist.Match) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("save watchlist matches: %w", err)
+	}
+	defer tx.Rollback()
+	keep := []any{itemID}
+	for _, m := range matches {
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO watchlist_matches (item_id, entry_id, list, name, term, field, reason, channel)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE list = VALUES(list), name = VALUES(name), term = VALUES(term), field = VALUES(field),
+	reason = VALUES(reason), channel = VALUES(channel)`,
+			itemID, m.EntryID, truncate(m.List, 128), truncate(m.Name, 255), truncate(m.Term, 255), m.Field, m.Reason(), truncate(m.Channel, 128)); err != nil {
+			return fmt.Errorf("save watchlist match %s: %w", m.Name, err)
+		}
+		keep = append(keep, m.EntryID)
+	}
+	query := "DELETE FROM watchlist_matches WHERE item_id = ?"
+	if len(keep) > 1 {
+		query += " AND entry_id NOT IN (?" + strings.Repeat(", ?", len(keep)-2) + ")"
+	}
+	if _, err := tx.ExecContext(ctx, query, keep...); err != nil {
+		return fmt.Errorf("save watchlist matches: %w", err)
+	}
+	return tx.Commit()
+}
+
+// ListWatchMatches returns the watchlist matches of an item; Notified tells
+// whether the alert for the match was delivered.
+func (s *Store) ListWatchMatches(ctx context.Context, itemID int64) ([]WatchMatch, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT m.item_id, m.entry_id, m.list, m.name, m.term, m.field, COALESCE(m.reason, ''), m.channel,
+	a.sent_at IS NOT NULL, m.created_at
+FROM watchlist_matches m
+JOIN news_analysis n ON n.id = m.item_id
+LEFT JOIN watchlist_alerts a ON a.guid = n.guid AND a.entry_id = m.entry_id
+WHERE m.item_id = ?
+ORDER BY m.id`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("list watchlist matches: %w", err)
+	}
+	defer rows.Close()
+
+	var out []WatchMatch
+	for rows.Next() {
+		var m WatchMatch
+		if err := rows.Scan(&m.ItemID, &m.EntryID, &m.List, &m.Name, &m.Term, &m.Field, &m.Reason, &m.Channel, &m.Notified, &m.Created); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// QueueWatchAlerts adds alerts for the matches of an item to the outbox. An
+// entry already queued for the item keeps its row, and its delivery state;
+// unsent rows take the latest reasons.
+func (s *Store) QueueWatchAlerts(ctx context.Context, item rss.Item, matches []watchlist.Match) error {
+	for _, m := range matches {
+		if _, err := s.db.ExecContext(ctx, `
+INSERT INTO watchlist_alerts (guid, entry_id, title, link, channel, reason_zh, reason_en)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE
+	reason_zh = IF(sent_at IS NULL, VALUES(reason_zh), reason_zh),
+	reason_en = IF(sent_at IS NULL, VALUES(reason_en), reason_en),
+	channel = IF(sent_at IS NULL, VALUES(channel), channel)`,
+			truncate(item.GUID, 255), m.EntryID, truncate(item.Title, 512), truncate(item.Link, 1024), truncate(m.Channel, 128), m.Reason(), m.ReasonEN()); err != nil {
+			return fmt.Errorf("queue watchlist alert %s: %w", m.Name, err)
+		}
+	}
+	return nil
+}
+
+// PendingWatchAlerts returns unsent alerts that were not given up, oldest
+// first; a non-empty guid limits them to one item.
+func (s *Store) PendingWatchAlerts(ctx context.Context, guid string, limit int) ([]WatchAlert, error) {
+	query := `
+SELECT id, guid, entry_id, title, link, channel, COALESCE(reason_zh, ''), COALESCE(reason_en, ''), attempts, COALESCE(delivered, '')
+FROM watchlist_alerts
+WHERE sent_at IS NULL AND failed_at IS NULL`
+	var args []any
+	if guid != "" {
+		query += " AND guid = ?"
+		args = append(args, guid)
+	}
+	rows, err := s.db.QueryContext(ctx, query+" ORDER BY id LIMIT ?", append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("list pending watchlist alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var out []WatchAlert
+	for rows.Next() {
+		var (
+			a         WatchAlert
+			delivered string
+		)
+		if err := rows.Scan(&a.ID, &a.GUID, &a.EntryID, &a.Title, &a.Link, &a.Channel, &a.ReasonZH, &a.ReasonEN, &a.Attempts, &delivered); err != nil {
+			return nil, err
+		}
+		if delivered != "" {
+			_ = json.Unmarshal([]byte(delivered), &a.Delivered)
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// FinishWatchAlerts records a delivery attempt of alerts and the destinations
+// that have received them so far: sent ones leave the outbox, failed ones
+// count the attempt and keep the error for the retry, and are given up once
+// they reach maxAttempts.
+func (s *Store) FinishWatchAlerts(ctx context.Context, ids []int64, delivered []string, sendErr error, maxAttempts int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	list, _ := json.Marshal(delivered)
+	query := "UPDATE watchlist_alerts SET attempts = attempts + 1, delivered = ?, sent_at = NOW(), last_error = NULL"
+	args := []any{string(list)}
+	if sendErr != nil {
+		query = "UPDATE watchlist_alerts SET failed_at = IF(attempts + 1 >= ?, NOW(), NULL), attempts = attempts + 1, delivered = ?, last_error = ?"
+		args = []any{maxAttempts, string(list), sendErr.Error()}
+	}
+	query += " WHERE id IN (?" + strings.Repeat(", ?", len(ids)-1) + ")"
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("finish watchlist alerts: %w", err)
+	}
+	return nil
+}
+
+// CountPendingWatchAlerts returns how many alerts wait for delivery.
+func (s *Store) CountPendingWatchAlerts(ctx context.Context) (int64, error) {
+	return s.count(ctx, "SELECT COUNT(*) FROM watchlist_alerts WHERE sent_at IS NULL AND failed_at IS NULL")
+}
@@ -0,0 +1,181 @@
+// Package trends detects tags and entities whose mention counts spike above
+// their own recent baseline.
+package trends
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Mention is one item mentioning a term.
+type Mention struct {
+	// Kind is "tag" or an entity type such as "organization".
+	Kind        string
+	Term        string
+	ItemID      int64
+	Title       string
+	Link        string
+	Importance  int
+	PublishedAt time.Time
+}
+
+// Params tunes spike detection.
+type Params struct {
+	// Window is the length of the current period and of each baseline period.
+	Window time.Duration
+	// BaselineWindows is how many periods before the current one form the baseline.
+	BaselineWindows int
+	// MinCount ignores terms mentioned by fewer items in the current window.
+	MinCount int
+	// MinRatio is the minimum growth over the baseline mean.
+	MinRatio float64
+	// MinZScore is the minimum number of standard deviations above the baseline.
+	MinZScore float64
+}
+
+// Item is an item contributing to a trend.
+type Item struct {
+	ID         int64  `json:"id"`
+	Title      string `json:"title"`
+	Link       string `json:"link"`
+	Importance int    `json:"importance"`
+}
+
+// Trend is a term whose current count is significantly above its baseline.
+type Trend struct {
+	Kind     string  `json:"kind"`
+	Term     string  `json:"term"`
+	Count    int     `json:"count"`
+	Baseline float64 `json:"baseline"`
+	Ratio    float64 `json:"ratio"`
+	ZScore   float64 `json:"z_score"`
+	// History holds item counts per window, oldest first; the last entry is the current window.
+	History []int  `json:"history"`
+	Items   []Item `json:"items"`
+}
+
+// Since returns the earliest publish time Detect looks at.
+func (p Params) Since(now time.Time) time.Time {
+	return now.Add(-time.Duration(p.BaselineWindows+1) * p.Window)
+}
+
+type termKey struct{ kind, term string }
+
+type termStats struct {
+	display string
+	counts  []int
+	seen    []map[int64]bool
+	items   map[int64]Item
+}
+
+// Detect buckets mentions into windows ending at now and returns the terms
+// that spike in the last window, strongest first, each with up to topItems
+// of its most important current items.
+//
+// Counts are distinct items per window. A term spikes when its current count
+// reaches MinCount, is at least MinRatio times the baseline mean (smoothed by
+// one so new terms do not divide by zero) and lies MinZScore deviations above
+// it. The deviation uses the larger of the observed baseline variance and the
+// Poisson variance, which keeps sparse terms from looking significant.
+func Detect(mentions []Mention, now time.Time, p Params, topItems int) []Trend {
+	windows := p.BaselineWindows + 1
+	start := p.Since(now)
+	stats := make(map[termKey]*termStats)
+	for _, m := range mentions {
+		if m.PublishedAt.Before(start) || !m.PublishedAt.Before(now) || strings.TrimSpace(m.Term) == "" {
+			continue
+		}
+		idx := int(m.PublishedAt.Sub(start) / p.Window)
+		if idx >= windows {
+			idx = windows - 1
+		}
+		key := termKey{m.Kind, strings.ToLower(strings.TrimSpace(m.Term))}
+		st, ok := stats[key]
+		if !ok {
+			st = &termStats{display: strings.TrimSpace(m.Term), counts: make([]int, windows), seen: make([]map[int64]bool, windows), items: map[int64]Item{}}
+			stats[key] = st
+		}
+		if st.seen[idx] == nil {
+			st.seen[idx] = map[int64]bool{}
+		}
+		if st.seen[idx][m.ItemID] {
+			continue
+		}
+		st.seen[idx][m.ItemID] = true
+		st.counts[idx]++
+		if idx == windows-1 {
+			st.items[m.ItemID] = Item{ID: m.ItemID, Title: m.Title, Link: m.Link, Importance: m.Importance}
+		}
+	}
+
+	var out []Trend
+	for key, st := range stats {
+		current := st.counts[windows-1]
+		if current < p.MinCount {
+			continue
+		}
+		mean, variance := meanVariance(st.counts[:windows-1])
+		ratio := (float64(current) + 1) / (mean + 1)
+		z := (float64(current) - mean) / math.Sqrt(math.Max(math.Max(variance, mean), 1))
+		if ratio < p.MinRatio || z < p.MinZScore {
+			continue
+		}
+		out = append(out, Trend{
+			Kind:     key.kind,
+			Term:     st.display,
+			Count:    current,
+			Baseline: round2(mean),
+			Ratio:    round2(ratio),
+			ZScore:   round2(z),
+			History:  st.counts,
+			Items:    topContributors(st.items, topItems),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].ZScore != out[j].ZScore {
+			return out[i].ZScore > out[j].ZScore
+		}
+		return out[i].Term < out[j].Term
+	})
+	return out
+}
+
+func meanVariance(counts []int) (float64, float64) {
+	if len(counts) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, c := range counts {
+		sum += float64(c)
+	}
+	mean := sum / float64(len(counts))
+	var sq float64
+	for _, c := range counts {
+		d := float64(c) - mean
+		sq += d * d
+	}
+	return mean, sq / float64(len(counts))
+}
+
+func topContributors(items map[int64]Item, n int) []Item {
+	out := make([]Item, 0, len(items))
+	for _, it := range items {
+		out = append(out, it)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Importance != out[j].Importance {
+			return out[i].Importance > out[j].Importance
+		}
+		return out[i].ID > out[j].ID
+	})
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
@@ -0,0 +1,155 @@
+// Package usage prices model calls and keeps the running daily spend that
+// the budget is checked against.
+package usage
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed prices.json
+var defaultPrices []byte
+
+// Price is the cost of a model in USD per million tokens.
+type Price struct {
+	Prompt     float64 `json:"prompt"`
+	Completion float64 `json:"completion"`
+}
+
+// Prices maps model names to their price.
+type Prices map[string]Price
+
+// LoadPrices returns the built-in price table overridden by the JSON object
+// in path, when set.
+func LoadPrices(path string) (Prices, error) {
+	prices := Prices{}
+	if err := json.Unmarshal(defaultPrices, &prices); err != nil {
+		return nil, fmt.Errorf("parse built-in prices: %w", err)
+	}
+	if path == "" {
+		return prices, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read prices file: %w", err)
+	}
+	var custom Prices
+	if err := json.Unmarshal(raw, &custom); err != nil {
+		return nil, fmt.Errorf("parse prices file %s: %w", path, err)
+	}
+	for model, price := range custom {
+		prices[model] = price
+	}
+	return prices, nil
+}
+
+// Lookup finds the price of model. Dated snapshots such as
+// "gpt-4o-2024-08-06" use the longest listed prefix.
+func (p Prices) Lookup(model string) (Price, bool) {
+	if price, ok := p[model]; ok {
+		return price, true
+	}
+	best := ""
+	for name := range p {
+		if strings.HasPrefix(model, name+"-") && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return p[best], true
+}
+
+// Cost prices one call. Models missing from the table are priced at
+// fallback and reported with known set to false.
+func (p Prices) Cost(model string, promptTokens, completionTokens int, fallback Price) (cost float64, known bool) {
+	price, known := p.Lookup(model)
+	if !known {
+		price = fallback
+	}
+	return (float64(promptTokens)*price.Prompt + float64(completionTokens)*price.Completion) / 1e6, known
+}
+
+// Day is the accounting day of t. Days are counted in UTC.
+func Day(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// Budget tracks today's spend against a daily limit. A zero limit disables it.
+type Budget struct {
+	limit float64
+
+	mu     sync.Mutex
+	day    string
+	spent  float64
+	loaded bool
+}
+
+// NewBudget creates a budget of limit USD per day.
+func NewBudget(limit float64) *Budget {
+	return &Budget{limit: limit}
+}
+
+// Limit returns the daily limit.
+func (b *Budget) Limit() float64 {
+	return b.limit
+}
+
+// Spent returns today's spend as far as it is known.
+func (b *Budget) Spent() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollover(time.Now())
+	return b.spent
+}
+
+// Loaded reports whether today's spend was seeded, see Seed.
+func (b *Budget) Loaded() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollover(time.Now())
+	return b.loaded
+}
+
+// Seed sets today's spend from storage after a restart. Storage also holds
+// the calls added since the process started, so the larger value wins.
+func (b *Budget) Seed(day string, spent float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollover(time.Now())
+	if day != b.day {
+		return
+	}
+	b.spent = max(b.spent, spent)
+	b.loaded = true
+}
+
+// Add records the cost of a call made at t.
+func (b *Budget) Add(t time.Time, cost float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollover(t)
+	if Day(t) == b.day {
+		b.spent += cost
+	}
+}
+
+// Exceeded reports whether today's spend reached the limit.
+func (b *Budget) Exceeded() bool {
+	if b.limit <= 0 {
+		return false
+	}
+	return b.Spent() >= b.limit
+}
+
+func (b *Budget) rollover(now time.Time) {
+	if day := Day(now); day > b.day {
+		b.day, b.spent, b.loaded = day, 0, false
+	}
+}
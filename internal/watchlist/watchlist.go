@@ -0,0 +1,245 @@
+// Package watchlist matches items against entities and keywords that must
+// never be missed, whatever the classifier decides.
+package watchlist
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"aiweb3news/internal/analysis"
+)
+
+// Entry kinds.
+const (
+	// KindEntity matches extracted entities by name or alias, as well as
+	// mentions in the title and summary.
+	KindEntity = "entity"
+	// KindKeyword only matches the title and summary.
+	KindKeyword = "keyword"
+)
+
+// Fields a match was found in.
+const (
+	FieldEntity  = "entity"
+	FieldTitle   = "title"
+	FieldSummary = "summary"
+)
+
+// Entry is a watched entity or keyword.
+type Entry struct {
+	ID int64 `json:"id"`
+	// List groups entries, e.g. "portfolio", "regulators", "competitors".
+	List string `json:"list"`
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	// EntityType optionally restricts entity matches to one entity type.
+	EntityType string   `json:"entity_type,omitempty"`
+	Aliases    []string `json:"aliases"`
+	// Channel is the notify destination matches are pushed to; empty uses
+	// WATCHLIST_CHANNEL.
+	Channel   string    `json:"channel,omitempty"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+var entityTypes = map[string]bool{
+	analysis.EntityOrganization: true,
+	analysis.EntityPerson:       true,
+	analysis.EntityJurisdiction: true,
+	analysis.EntityChain:        true,
+	analysis.EntityToken:        true,
+	analysis.EntityProtocol:     true,
+}
+
+// Normalize trims the entry, drops duplicate aliases and validates it.
+func (e *Entry) Normalize() error {
+	e.List = strings.TrimSpace(e.List)
+	e.Name = strings.TrimSpace(e.Name)
+	e.Kind = strings.ToLower(strings.TrimSpace(e.Kind))
+	e.EntityType = strings.ToLower(strings.TrimSpace(e.EntityType))
+	e.Channel = strings.TrimSpace(e.Channel)
+	if e.Kind == "" {
+		e.Kind = KindEntity
+	}
+	switch {
+	case e.Name == "":
+		return errors.New("name is required")
+	case e.Kind != KindEntity && e.Kind != KindKeyword:
+		return fmt.Errorf("kind must be %s or %s", KindEntity, KindKeyword)
+	case e.EntityType != "" && e.Kind != KindEntity:
+		return errors.New("entity_type only applies to kind entity")
+	case e.EntityType != "" && !entityTypes[e.EntityType]:
+		return fmt.Errorf("unknown entity_type %q", e.EntityType)
+	}
+	seen := map[string]bool{strings.ToLower(e.Name): true}
+	aliases := make([]string, 0, len(e.Aliases))
+	for _, a := range e.Aliases {
+		a = strings.TrimSpace(a)
+		if a == "" || seen[strings.ToLower(a)] {
+			continue
+		}
+		seen[strings.ToLower(a)] = true
+		aliases = append(aliases, a)
+	}
+	e.Aliases = aliases
+	return nil
+}
+
+// Terms returns the name followed by the aliases.
+func (e Entry) Terms() []string {
+	return append([]string{e.Name}, e.Aliases...)
+}
+
+// Match is an entry found in an item.
+type Match struct {
+	EntryID int64  `json:"entry_id"`
+	List    string `json:"list"`
+	Name    string `json:"name"`
+	Channel string `json:"channel,omitempty"`
+	// Term is the name or alias that matched, Field where it was found.
+	Term  string `json:"term"`
+	Field string `json:"field"`
+}
+
+// Reason explains the match in Chinese, for storage and notifications.
+func (m Match) Reason() string {
+	alias := ""
+	if !strings.EqualFold(m.Term, m.Name) {
+		alias = fmt.Sprintf("（别名 %q）", m.Term)
+	}
+	switch m.Field {
+	case FieldEntity:
+		return fmt.Sprintf("抽取的实体包含 %s%s", m.Name, alias)
+	case FieldTitle:
+		return fmt.Sprintf("标题提及 %s%s", m.Name, alias)
+	default:
+		return fmt.Sprintf("摘要提及 %s%s", m.Name, alias)
+	}
+}
+
+// ReasonEN explains the match in English.
+func (m Match) ReasonEN() string {
+	alias := ""
+	if !strings.EqualFold(m.Term, m.Name) {
+		alias = fmt.Sprintf(" (as %q)", m.Term)
+	}
+	switch m.Field {
+	case FieldEntity:
+		return fmt.Sprintf("entity %s%s extracted", m.Name, alias)
+	case FieldTitle:
+		return fmt.Sprintf("title mentions %s%s", m.Name, alias)
+	default:
+		return fmt.Sprintf("summary mentions %s%s", m.Name, alias)
+	}
+}
+
+// Matcher finds enabled entries in items.
+type Matcher struct {
+	entries []compiled
+}
+
+type compiled struct {
+	entry Entry
+	terms []term
+}
+
+type term struct {
+	text string
+	// pattern is set for terms made of letters and digits, which must match
+	// whole words so "SEC" does not match "second"; other terms, e.g.
+	// Chinese names, match as case-insensitive substrings.
+	pattern *regexp.Regexp
+}
+
+// NewMatcher compiles the enabled entries.
+func NewMatcher(entries []Entry) *Matcher {
+	m := &Matcher{}
+	for _, e := range entries {
+		if !e.Enabled {
+			continue
+		}
+		c := compiled{entry: e}
+		for _, t := range e.Terms() {
+			c.terms = append(c.terms, compileTerm(t))
+		}
+		m.entries = append(m.entries, c)
+	}
+	return m
+}
+
+func compileTerm(t string) term {
+	for _, r := range t {
+		if r > unicode.MaxASCII {
+			return term{text: strings.ToLower(t)}
+		}
+	}
+	return term{text: t, pattern: regexp.MustCompile(`(?i)(^|[^\pL\pN])` + regexp.QuoteMeta(t) + `($|[^\pL\pN])`)}
+}
+
+func (t term) in(text string) bool {
+	if t.pattern != nil {
+		return t.pattern.MatchString(text)
+	}
+	return strings.Contains(strings.ToLower(text), t.text)
+}
+
+// Len reports how many entries the matcher checks.
+func (m *Matcher) Len() int {
+	return len(m.entries)
+}
+
+// Match returns at most one match per entry, preferring an extracted entity
+// over a title mention over a summary mention.
+func (m *Matcher) Match(title, summary string, entities []analysis.Entity) []Match {
+	var out []Match
+	for _, c := range m.entries {
+		if match, ok := c.match(title, summary, entities); ok {
+			out = append(out, match)
+		}
+	}
+	return out
+}
+
+func (c compiled) match(title, summary string, entities []analysis.Entity) (Match, bool) {
+	found := func(t, field string) (Match, bool) {
+		return Match{EntryID: c.entry.ID, List: c.entry.List, Name: c.entry.Name, Channel: c.entry.Channel, Term: t, Field: field}, true
+	}
+	if c.entry.Kind == KindEntity {
+		for _, e := range entities {
+			if c.entry.EntityType != "" && e.Type != c.entry.EntityType {
+				continue
+			}
+			for _, t := range c.entry.Terms() {
+				if strings.EqualFold(strings.TrimSpace(e.Name), t) {
+					return found(t, FieldEntity)
+				}
+			}
+		}
+	}
+	for _, t := range c.terms {
+		if t.in(title) {
+			return found(termText(c, t), FieldTitle)
+		}
+	}
+	for _, t := range c.terms {
+		if t.in(summary) {
+			return found(termText(c, t), FieldSummary)
+		}
+	}
+	return Match{}, false
+}
+
+// termText returns the term as the user wrote it.
+func termText(c compiled, t term) string {
+	for i, ct := range c.terms {
+		if ct == t {
+			return c.entry.Terms()[i]
+		}
+	}
+	return t.text
+}
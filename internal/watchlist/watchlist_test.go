@@ -0,0 +1,87 @@
+package watchlist
+
+import (
+	"reflect"
+	"testing"
+
+	"aiweb3news/internal/analysis"
+)
+
+func TestMatcherMatch(t *testing.T) {
+	m := NewMatcher([]Entry{
+		{ID: 1, List: "regulators", Name: "SEC", Kind: KindEntity, EntityType: analysis.EntityOrganization, Aliases: []string{"美国证监会"}, Enabled: true},
+		{ID: 2, List: "portfolio", Name: "Lido", Kind: KindEntity, Aliases: []string{"stETH"}, Enabled: true},
+		{ID: 3, List: "topics", Name: "稳定币", Kind: KindKeyword, Enabled: true},
+		{ID: 4, List: "topics", Name: "re-staking", Kind: KindKeyword, Enabled: true},
+		{ID: 5, List: "paused", Name: "Tether", Kind: KindKeyword, Enabled: false},
+	})
+	if m.Len() != 4 {
+		t.Fatalf("Len = %d, want 4 enabled entries", m.Len())
+	}
+
+	type hit struct {
+		ID    int64
+		Term  string
+		Field string
+	}
+	tests := []struct {
+		name     string
+		title    string
+		summary  string
+		entities []analysis.Entity
+		want     []hit
+	}{
+		{
+			name:  "whole word in title, case-insensitive",
+			title: "sec sues exchange",
+			want:  []hit{{1, "SEC", FieldTitle}},
+		},
+		{
+			name:  "no match inside a longer word",
+			title: "Second layer stETHx launches; Lidocaine shortage",
+			want:  nil,
+		},
+		{
+			name:  "punctuation counts as a boundary",
+			title: "(SEC) approves ETF; stETH, re-staking grow",
+			want:  []hit{{1, "SEC", FieldTitle}, {2, "stETH", FieldTitle}, {4, "re-staking", FieldTitle}},
+		},
+		{
+			name:  "CJK alias matches as a substring",
+			title: "美国证监会批准现货以太坊ETF",
+			want:  []hit{{1, "美国证监会", FieldTitle}},
+		},
+		{
+			name:    "CJK keyword inside a sentence of the summary",
+			title:   "Circle 上市",
+			summary: "全球稳定币市值突破 2000 亿美元",
+			want:    []hit{{3, "稳定币", FieldSummary}},
+		},
+		{
+			name:     "extracted entity wins over the title",
+			title:    "SEC 调查 Lido",
+			entities: []analysis.Entity{{Type: analysis.EntityOrganization, Name: " sec "}},
+			want:     []hit{{1, "SEC", FieldEntity}, {2, "Lido", FieldTitle}},
+		},
+		{
+			name:     "entity type restricts entity matches",
+			entities: []analysis.Entity{{Type: analysis.EntityPerson, Name: "SEC"}, {Type: analysis.EntityProtocol, Name: "steth"}},
+			want:     []hit{{2, "stETH", FieldEntity}},
+		},
+		{
+			name:    "disabled entries never match",
+			title:   "Tether mints USDT",
+			summary: "Tether",
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		var got []hit
+		for _, match := range m.Match(tt.title, tt.summary, tt.entities) {
+			got = append(got, hit{match.EntryID, match.Term, match.Field})
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}